@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of parsed compose files",
+	Long: `portcheck scan keeps the parse of each compose file in a cache under
+the user cache directory (e.g. ~/.cache/portcheck), keyed by the file's
+contents, and reuses it while the file is unchanged. Use
+portcheck scan --no-cache to bypass it for one run.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached parse",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := scanner.DefaultCacheDir()
+		if err != nil {
+			return fmt.Errorf("no cache directory: %w", err)
+		}
+		if err := scanner.ClearCache(dir); err != nil {
+			return fmt.Errorf("failed to clear the cache: %w", err)
+		}
+		fmt.Printf("Cleared the cache in %s\n", dir)
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/capabilities"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+var capabilitiesJSON bool
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "List supported output formats and rules",
+	Long: `List the output formats, rule types and JSON schema version this build
+supports, so integrations can feature-detect.
+
+Examples:
+  portcheck capabilities
+  portcheck capabilities --json`,
+	Args: cobra.NoArgs,
+	RunE: runCapabilities,
+}
+
+func init() {
+	capabilitiesCmd.Flags().BoolVar(&capabilitiesJSON, "json", false, "Print machine-readable JSON")
+}
+
+func runCapabilities(cmd *cobra.Command, args []string) error {
+	caps := capabilities.Capabilities(version)
+
+	if capabilitiesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(caps)
+	}
+
+	fmt.Printf("portcheck %s (JSON schema %d)\n", caps.Version, caps.SchemaVersion)
+	fmt.Printf("Formats: %s\n", strings.Join(caps.Formats, ", "))
+	fmt.Println("Rules:")
+	for _, rule := range caps.Rules {
+		fmt.Printf("  %-30s %s\n", rule, scanner.RuleDescription(rule))
+	}
+	return nil
+}
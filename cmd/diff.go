@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/baseline"
+	"github.com/stackgen-cli/portcheck/internal/reporter"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+var (
+	diffBaseline string
+	diffFormat   string
+	diffStrict   bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-path> <new-path>",
+	Short: "Compare the issues of two scans",
+	Long: `Scan two trees, such as checkouts of the base branch and a PR, and
+report which issues were added, removed or left unchanged.
+
+Issues are matched by type, port and sorted service list, so reordering
+services or moving a declaration is not a change. With --baseline, the
+old issues come from a saved scan --format json report and only the new
+path is scanned.
+
+With --strict, only newly added errors fail (exit 1); pre-existing
+issues never do.
+
+Examples:
+  portcheck diff ../main .
+  portcheck diff --baseline main-report.json . --format json
+  portcheck diff ../main . --strict`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if diffBaseline != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffBaseline, "baseline", "", "Read the old issues from this scan --format json report instead of scanning old-path")
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text, json")
+	diffCmd.Flags().BoolVar(&diffStrict, "strict", false, "Exit 1 when the new scan adds errors")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if diffFormat != "text" && diffFormat != "json" {
+		return fmt.Errorf("unknown diff format %q (want text or json)", diffFormat)
+	}
+
+	var old []scanner.Issue
+	newPath := "."
+	if diffBaseline != "" {
+		issues, err := baseline.LoadIssues(diffBaseline)
+		if err != nil {
+			return fmt.Errorf("failed to read --baseline: %w", err)
+		}
+		old = issues
+		if len(args) > 0 {
+			newPath = args[0]
+		}
+	} else {
+		result, err := scanner.Scan(args[0])
+		if err != nil {
+			return fmt.Errorf("scan of %s failed: %w", args[0], err)
+		}
+		old = result.Issues
+		newPath = args[1]
+	}
+
+	result, err := scanner.Scan(newPath)
+	if err != nil {
+		return fmt.Errorf("scan of %s failed: %w", newPath, err)
+	}
+	d := baseline.Compare(old, result.Issues)
+
+	if diffFormat == "json" {
+		output, err := reporter.FormatDiffJSON(d)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+	} else {
+		fmt.Print(reporter.FormatDiff(d))
+	}
+
+	if diffStrict && len(d.AddedErrors()) > 0 {
+		os.Exit(scanner.ExitErrors)
+	}
+	return nil
+}
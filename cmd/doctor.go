@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/doctor"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [path]",
+	Short: "Diagnose the environment portcheck runs in",
+	Long: `Check Docker availability, privileged port permissions, compose
+files and portcheck config files in the directory, and whether common
+ports are already taken on this host.
+
+Exits non-zero only on hard problems such as an invalid config file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		report := doctor.New(path).Run()
+		fmt.Print(doctor.Format(report))
+		if report.Failed() {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
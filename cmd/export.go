@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/export"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+var (
+	exportProxy  string
+	exportDomain string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "Generate a reverse proxy config from the discovered bindings",
+	Long: `Emit a skeleton reverse proxy config that routes a hostname per
+service to the service's lowest published TCP port.
+
+Hostnames are <service>.<domain>; services whose name appears in
+several projects get <service>.<project>.<domain>.
+
+Examples:
+  portcheck export --proxy caddy > Caddyfile
+  portcheck export ./myproject --proxy nginx --domain dev.test`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		result, err := scanner.Scan(path)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		out, err := export.Proxy(result, exportProxy, exportDomain)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportProxy, "proxy", "caddy", "Proxy config to generate: caddy, nginx")
+	exportCmd.Flags().StringVar(&exportDomain, "domain", "localhost", "Domain appended to service hostnames")
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/rewrite"
+	"github.com/stackgen-cli/portcheck/internal/runtime"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+var (
+	fixWrite    bool
+	fixYes      bool
+	fixNoBackup bool
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix [path]",
+	Short: "Rewrite colliding host ports to free ones",
+	Long: `Move colliding bindings to free host ports by editing the compose
+files in place. Only the host port of each affected ports entry
+changes; comments and formatting are preserved.
+
+Without --write, prints a diff of the planned changes. With --write,
+asks for confirmation and, when a colliding port is published in
+several files, which binding keeps it; --yes keeps the first binding
+and applies without asking. Each modified file is backed up to
+<file>.bak unless --no-backup is given.
+
+Examples:
+  portcheck fix
+  portcheck fix ./myproject --write
+  portcheck fix --write --yes --no-backup`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runFix,
+}
+
+func init() {
+	fixCmd.Flags().BoolVar(&fixWrite, "write", false, "Write changes back to the compose files")
+	fixCmd.Flags().BoolVarP(&fixYes, "yes", "y", false, "Apply without asking, keeping the first binding of each collision")
+	fixCmd.Flags().BoolVar(&fixNoBackup, "no-backup", false, "Do not copy modified files to <file>.bak")
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	result, err := scanner.Scan(path)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	interactive := isTerminal(os.Stdin)
+	keep := func(scanner.Issue) int { return 0 }
+	if fixWrite && !fixYes && interactive {
+		keep = func(issue scanner.Issue) int { return chooseKept(issue, in, os.Stdout) }
+	}
+
+	changes := rewrite.PlanFixesKeeping(result, nextFreePort, keep)
+	edits, err := rewrite.Edit(changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = rewrite.Fix(changes, edits, rewrite.FixOptions{
+		DryRun:      !fixWrite,
+		AssumeYes:   fixYes,
+		Interactive: interactive,
+		Backup:      !fixNoBackup,
+		In:          in,
+		Out:         os.Stdout,
+	})
+	return err
+}
+
+// chooseKept asks which binding of a collision keeps its host port when
+// the bindings come from several files, defaulting to the first
+func chooseKept(issue scanner.Issue, in *bufio.Reader, out io.Writer) int {
+	files := make(map[string]bool)
+	for _, b := range issue.Bindings {
+		files[b.File] = true
+	}
+	if len(files) < 2 {
+		return 0
+	}
+
+	fmt.Fprintf(out, "Port %d is published in several files:\n", issue.Port)
+	for i, b := range issue.Bindings {
+		fmt.Fprintf(out, "  %d) %s in %s\n", i+1, b.Service, b.File)
+	}
+	fmt.Fprintf(out, "Keep which binding on %d? [1] ", issue.Port)
+
+	answer, _ := in.ReadString('\n')
+	n, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || n < 1 || n > len(issue.Bindings) {
+		return 0
+	}
+	return n - 1
+}
+
+// applyFixes moves colliding bindings to free host ports, confirming first
+// unless assumeYes is set
+func applyFixes(result *scanner.Result, dryRun, assumeYes bool) error {
+	changes := rewrite.PlanFixes(result, nextFreePort)
+	edits, err := rewrite.Edit(changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = rewrite.Fix(changes, edits, rewrite.FixOptions{
+		DryRun:      dryRun,
+		AssumeYes:   assumeYes,
+		Interactive: isTerminal(os.Stdin),
+		In:          os.Stdin,
+		Out:         os.Stdout,
+	})
+	return err
+}
+
+// nextFreePort returns a bindable host port for port that is not taken
+func nextFreePort(port int, taken map[int]bool) int {
+	candidate := port + 1
+	if suggested, ok := runtime.SuggestFreePorts([]int{port}, taken)[port]; ok {
+		candidate = suggested
+	}
+
+	return runtime.FindFreePort(candidate, 100, runtime.FreePortOptions{Exclude: taken})
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
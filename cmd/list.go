@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/reporter"
+	"github.com/stackgen-cli/portcheck/internal/runtime"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+var (
+	listFormat   string
+	listProtocol string
+	listFree     bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list [path]",
+	Short: "Print every published host port and the service that owns it",
+	Long: `List the effective port bindings of the compose files in a directory,
+sorted by host port, without reporting any issues.
+
+With --free, each host port is probed to show whether it can be bound
+on this machine right now. Ports that cannot be probed, such as
+privileged ports when running unprivileged, show as unknown.
+
+Examples:
+  portcheck list
+  portcheck list ./myproject --protocol udp
+  portcheck list --free --format csv > ports.csv`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		protocol := strings.ToLower(listProtocol)
+		if protocol != "" && protocol != "tcp" && protocol != "udp" {
+			return fmt.Errorf("unknown protocol %q (want tcp or udp)", listProtocol)
+		}
+
+		result, err := scanner.Scan(path)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+
+		var bindings []scanner.PortBinding
+		for _, b := range scanner.SortBindings(result.PortBindings) {
+			if protocol == "" || b.Protocol == protocol {
+				bindings = append(bindings, b)
+			}
+		}
+
+		var free map[scanner.PortBinding]bool
+		if listFree {
+			free = make(map[scanner.PortBinding]bool, len(bindings))
+			for _, b := range bindings {
+				if b.RandomHostPort {
+					// Docker picks a free port for a random host port
+					free[b] = true
+					continue
+				}
+				if b.Unresolved {
+					// No host port to probe
+					continue
+				}
+				inUse, err := runtime.ProbePort(b.HostPort, b.Protocol, b.HostIP)
+				if err != nil {
+					// Left out of free, so it reports as unknown
+					continue
+				}
+				free[b] = !inUse
+			}
+		}
+
+		var output string
+		switch listFormat {
+		case "text":
+			output = reporter.FormatInventoryText(bindings, free)
+		case "json":
+			output, err = reporter.FormatInventoryJSON(bindings, free)
+			output += "\n"
+		case "csv":
+			output, err = reporter.FormatInventoryCSV(bindings, free)
+		default:
+			return fmt.Errorf("unknown list format %q (want text, json or csv)", listFormat)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listFormat, "format", "text", "Output format: text, json, csv")
+	listCmd.Flags().StringVar(&listProtocol, "protocol", "", "Only list bindings of this protocol: tcp, udp")
+	listCmd.Flags().BoolVar(&listFree, "free", false, "Probe whether each host port can currently be bound")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/rewrite"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+var normalizeWrite bool
+
+var normalizeCmd = &cobra.Command{
+	Use:   "normalize [path]",
+	Short: "Rewrite ports to canonical long syntax",
+	Long: `Rewrite every service's ports into the canonical long syntax
+(target, published, protocol, host_ip), preserving comments and order.
+
+Without --write, prints a diff of the planned changes. Ports that
+depend on unresolved variables are left untouched.
+
+Examples:
+  portcheck normalize
+  portcheck normalize ./myproject --write`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runNormalize,
+}
+
+func init() {
+	normalizeCmd.Flags().BoolVar(&normalizeWrite, "write", false, "Write changes back to the compose files")
+}
+
+func runNormalize(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	for _, file := range scanner.DiscoverComposeFiles(path) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		out, converted, err := rewrite.Normalize(data, file)
+		if err != nil {
+			return fmt.Errorf("failed to normalize %s: %w", file, err)
+		}
+		if converted == 0 {
+			continue
+		}
+
+		if !normalizeWrite {
+			fmt.Print(rewrite.Diff(file, data, out))
+			continue
+		}
+
+		if err := os.WriteFile(file, out, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Normalized %d port(s) in %s\n", converted, file)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/profiles"
+)
+
+var (
+	profilesFormat    string
+	profilesConflicts bool
+	profilesActive    []string
+)
+
+var profilesCmd = &cobra.Command{
+	Use:   "profiles [path]",
+	Short: "List compose profiles and the ports of their services",
+	Long: `List every compose profile in a directory with its services and
+their ports. Services without a profile belong to "default".
+
+With --conflicts, the host ports claimed by more than one service are
+reported for the default profile plus the --profile values given.
+
+Examples:
+  portcheck profiles
+  portcheck profiles ./myproject --format json
+  portcheck profiles --conflicts --profile dev --profile tools`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if profilesFormat != "text" && profilesFormat != "json" {
+			return fmt.Errorf("unknown profiles format %q (want text or json)", profilesFormat)
+		}
+		if len(profilesActive) > 0 && !profilesConflicts {
+			return fmt.Errorf("--profile requires --conflicts")
+		}
+
+		config, err := profiles.LoadProfiles(path)
+		if err != nil {
+			return fmt.Errorf("failed to load profiles: %w", err)
+		}
+
+		var conflicts []profiles.PortConflict
+		if profilesConflicts {
+			conflicts = append([]profiles.PortConflict{}, config.DetectPortConflicts(profilesActive)...)
+		}
+
+		if profilesFormat == "json" {
+			output, err := profiles.FormatProfilesJSON(config, conflicts)
+			if err != nil {
+				return err
+			}
+			fmt.Println(output)
+			return nil
+		}
+
+		fmt.Print(profiles.FormatProfiles(config))
+		if profilesConflicts {
+			fmt.Print(profiles.FormatConflicts(profilesActive, conflicts))
+		}
+		return nil
+	},
+}
+
+func init() {
+	profilesCmd.Flags().StringVar(&profilesFormat, "format", "text", "Output format: text, json")
+	profilesCmd.Flags().BoolVar(&profilesConflicts, "conflicts", false, "Report host ports claimed by more than one service of the active profiles")
+	profilesCmd.Flags().StringSliceVar(&profilesActive, "profile", nil, "Profile(s) active besides default, for --conflicts")
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
-var version = "dev"
+var (
+	version = "dev"
+	noColor bool
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "portcheck",
@@ -21,6 +25,11 @@ It identifies:
   - Potential conflicts with system services
 
 Fast, actionable, no guessing.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if noColor {
+			color.NoColor = true
+		}
+	},
 }
 
 func Execute() {
@@ -31,7 +40,18 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
 	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(normalizeCmd)
+	rootCmd.AddCommand(fixCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(profilesCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(capabilitiesCmd)
+	rootCmd.AddCommand(cacheCmd)
 	rootCmd.AddCommand(versionCmd)
 }
 
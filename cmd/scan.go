@@ -1,25 +1,84 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/baseline"
+	"github.com/stackgen-cli/portcheck/internal/gitdiff"
 	"github.com/stackgen-cli/portcheck/internal/profiles"
 	"github.com/stackgen-cli/portcheck/internal/reporter"
 	"github.com/stackgen-cli/portcheck/internal/runtime"
 	"github.com/stackgen-cli/portcheck/internal/scanner"
+	"github.com/stackgen-cli/portcheck/internal/watch"
 )
 
 var (
-	strictMode      bool
-	outputFormat    string
-	runtimeScan     bool
-	suggestPorts    bool
-	activeProfiles  []string
-	showHostIP      bool
+	strictMode          bool
+	failOn              string
+	outputFormat        string
+	runtimeScan         bool
+	suggestPorts        bool
+	activeProfiles      []string
+	showHostIP          bool
+	projectsIndependent bool
+	assumeCoLocated     bool
+	runtimeRetries      int
+	containerEngine     string
+	showHints           bool
+	composeEnv          string
+	baselineRatchet     string
+	checkHost           bool
+	fixPorts            bool
+	assumeYes           bool
+	dryRun              bool
+	strictProfiles      bool
+	heatmap             bool
+	treeLayout          bool
+	pathsFrom           string
+	datastores          []string
+	knownPortsFile      string
+	noCommonPorts       bool
+	projectOnly         bool
+	claimedPorts        string
+	oneline             bool
+	minSeverity         string
+	failPublicDatastore bool
+	paranoid            bool
+	runtimeBaseline     string
+	allProfiles         bool
+	collapseDuplicates  bool
+	sniffFiles          bool
+	extraOutputs        []string
+	sinceCommit         string
+	footer              bool
+	quiet               bool
+	summaryOnly         bool
+	junitWarnings       bool
+	composeFiles        []string
+	scanDepth           int
+	noSkipDirs          bool
+	k8sManifests        []string
+	watchMode           bool
+	readStdin           bool
+	verbose             bool
+	onlyTypes           []string
+	excludeTypes        []string
+	noCache             bool
+	runtimeProject      string
+	runtimeName         string
+	allowPrivileged     bool
+	baselineFile        string
+	writeBaseline       string
 )
 
 var scanCmd = &cobra.Command{
@@ -27,35 +86,129 @@ var scanCmd = &cobra.Command{
 	Short: "Scan for port collisions",
 	Long: `Scan Docker Compose files for port conflicts.
 
-By default, scans the current directory. Use --strict to fail 
-on any conflicts (useful in CI).
+By default, scans the current directory and exits 0. Use --fail-on
+to fail at a severity threshold (useful in CI); the exit code then names
+the most severe issue found:
+
+  0  clean, or nothing at or above the --fail-on threshold
+  1  errors present (also for --baseline-ratchet, --fail-on-public-datastore
+     and --out failures)
+  2  warnings, but no errors
+  3  info only
+
+--strict is an alias for --fail-on warning.
 
 Features:
   • Static compose file scanning
-  • Runtime container port detection (--runtime)
+  • Runtime container port detection, Docker or Podman (--runtime, --engine)
+  • Host port occupancy probing, TCP and UDP (--check-host)
   • Port suggestions for conflicts (--suggest)
+  • Automatic conflict fixing (--fix, -y, --dry-run)
   • Profile-aware scanning (--profile)
   • Host IP binding analysis (--show-host-ip)
+  • Per-project monorepo scanning (--projects-independent)
 
 Examples:
   portcheck scan
   portcheck scan ./myproject
   portcheck scan --strict
+  portcheck scan --fail-on error
+  portcheck scan --format markdown --footer
+  portcheck scan --quiet
+  portcheck scan --summary --format json
+  portcheck scan --since-commit origin/main
   portcheck scan --runtime
+  portcheck scan --runtime --engine podman
+  portcheck scan --runtime --project shop --container-name api
+  portcheck scan --runtime-baseline runtime.json
   portcheck scan --suggest
+  eval "$(portcheck scan --format env)"
+  portcheck scan --format sarif > portcheck.sarif
+  portcheck scan --format csv > ports.csv
+  portcheck scan --format html > portcheck.html
+  portcheck scan --format junit --junit-warnings > portcheck.xml
+  portcheck scan --fix --dry-run
+  portcheck scan --out json:report.json --out markdown:summary.md
   portcheck scan --profile dev --profile tools
-  portcheck scan --show-host-ip`,
+  portcheck scan -f config/stack.yml -f config/stack.dev.yml
+  portcheck scan --depth -1
+  portcheck scan --k8s deploy/k8s
+  portcheck scan --watch
+  portcheck scan --known-ports known-ports.yaml
+  portcheck scan --verbose
+  portcheck scan --allow-privileged --strict
+  portcheck scan --no-cache
+  portcheck scan --only collision,profile_collision --strict
+  portcheck scan --exclude privileged,common_port
+  generate-compose | portcheck scan -
+  portcheck scan --all-profiles
+  portcheck scan --show-host-ip
+  portcheck scan --projects-independent
+  portcheck scan --env prod
+  portcheck scan --write-baseline .portcheck-baseline.json
+  portcheck scan --baseline .portcheck-baseline.json --strict
+  portcheck scan --baseline-ratchet .portcheck-baseline.json`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runScan,
 }
 
 func init() {
-	scanCmd.Flags().BoolVar(&strictMode, "strict", false, "Exit with error code on any issues found")
-	scanCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format: text, json, markdown")
+	scanCmd.Flags().BoolVar(&strictMode, "strict", false, "Alias for --fail-on warning")
+	scanCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit nonzero when issues this severe are found: error, warning, info")
+	scanCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: "+strings.Join(reporter.Formats(), ", "))
+	scanCmd.Flags().StringArrayVarP(&composeFiles, "file", "f", nil, "Scan this compose file instead of discovering files; repeat to merge overrides in order, like docker compose -f")
+	scanCmd.Flags().BoolVar(&junitWarnings, "junit-warnings", false, "With --format junit or --out junit:path, report warnings as <error> elements that fail the suite")
+	scanCmd.Flags().StringArrayVar(&extraOutputs, "out", nil, "Also write a report as format:path, e.g. markdown:summary.md (repeatable)")
 	scanCmd.Flags().BoolVar(&runtimeScan, "runtime", false, "Also scan running containers for port usage")
+	scanCmd.Flags().StringVar(&runtimeProject, "project", "", "With --runtime, only list containers of this compose project (default: the scanned directory's name; \"\" for every container)")
+	scanCmd.Flags().StringVar(&runtimeName, "container-name", "", "With --runtime, only list containers whose name contains this")
+	scanCmd.Flags().BoolVar(&projectOnly, "project-only", false, "With --runtime, only consider containers of this compose project")
+	scanCmd.Flags().StringVar(&runtimeBaseline, "runtime-baseline", "", "Report runtime port changes since the snapshot in this file, then update it (implies --runtime)")
+	scanCmd.Flags().StringVar(&containerEngine, "engine", "", "Container engine for --runtime: docker, podman or docker-api (default: the Docker API socket, then the docker CLI, then podman)")
+	scanCmd.Flags().IntVar(&runtimeRetries, "runtime-retries", runtime.DefaultRetryPolicy.Attempts, "Attempts for transient docker command failures")
+	scanCmd.Flags().StringVar(&claimedPorts, "claimed-ports", "", "File of host ports claimed outside Docker (default: "+scanner.ReservedPortsFile+" in the scanned directory)")
+	scanCmd.Flags().BoolVar(&checkHost, "check-host", false, "Probe whether host ports are already bound outside Docker")
 	scanCmd.Flags().BoolVar(&suggestPorts, "suggest", false, "Suggest alternative ports for conflicts")
+	scanCmd.Flags().BoolVar(&fixPorts, "fix", false, "Rewrite colliding host ports to free ones (asks for confirmation)")
+	scanCmd.Flags().BoolVarP(&assumeYes, "assume-yes", "y", false, "Apply --fix without asking")
+	scanCmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --fix, only print the planned changes")
+	scanCmd.Flags().BoolVar(&footer, "footer", false, "End the output with a PORTCHECK_RESULT errors=… line for scripts")
+	scanCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print only the issue counts by severity; use the exit code and --out files")
+	scanCmd.Flags().BoolVar(&summaryOnly, "summary", false, "Print the issue counts and colliding ports, but no bindings")
+	scanCmd.Flags().BoolVar(&verbose, "verbose", false, "Log each scanned file and the bindings it declared to stderr")
+	scanCmd.Flags().BoolVar(&oneline, "oneline", false, "Print a single status line; exit 1 for errors, 2 for warnings, 3 for info")
+	scanCmd.Flags().BoolVar(&collapseDuplicates, "collapse-duplicate-issues", false, "Merge issues sharing type, port and protocol into one with a count")
+	scanCmd.Flags().StringSliceVar(&onlyTypes, "only", nil, "Only report issues of these types (see portcheck capabilities)")
+	scanCmd.Flags().StringSliceVar(&excludeTypes, "exclude", nil, "Do not report issues of these types")
+	scanCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Only report issues at least this severe: error, warning, info")
+	scanCmd.Flags().BoolVar(&treeLayout, "tree", false, "Nest text output by severity, type and port")
+	scanCmd.Flags().BoolVar(&heatmap, "heatmap", false, "Add a port occupancy heatmap to markdown output")
 	scanCmd.Flags().StringSliceVar(&activeProfiles, "profile", nil, "Compose profile(s) to consider")
+	scanCmd.Flags().BoolVar(&allProfiles, "all-profiles", false, "Also report collisions that only occur with every profile active")
+	scanCmd.Flags().BoolVar(&strictProfiles, "strict-profiles", false, "Warn about inconsistent profile usage across services")
 	scanCmd.Flags().BoolVar(&showHostIP, "show-host-ip", false, "Show host IP binding details")
+	scanCmd.Flags().StringVar(&composeEnv, "env", "", "Scan docker-compose.yml merged with docker-compose.<env>.yml only")
+	scanCmd.Flags().StringVar(&baselineFile, "baseline", "", "Leave out issues accepted in this baseline file, as written by --write-baseline")
+	scanCmd.Flags().StringVar(&writeBaseline, "write-baseline", "", "Write the issues found to this baseline file, accepting them for --baseline")
+	scanCmd.Flags().StringVar(&baselineRatchet, "baseline-ratchet", "", "Fail on issues not in the baseline file and drop resolved ones from it")
+	scanCmd.Flags().BoolVar(&failPublicDatastore, "fail-on-public-datastore", false, "Exit 1 when a datastore image is publicly exposed, regardless of other settings")
+	scanCmd.Flags().StringSliceVar(&datastores, "datastores", nil, "Image names treated as databases and caches (default: built-in list)")
+	scanCmd.Flags().StringVar(&knownPortsFile, "known-ports", "", "YAML file of port: name entries added to the common ports, overriding built-in names (an empty name drops a port)")
+	scanCmd.Flags().BoolVar(&allowPrivileged, "allow-privileged", false, "Report host ports below 1024 as info instead of warnings")
+	scanCmd.Flags().BoolVar(&noCommonPorts, "no-common-ports", false, "Disable the common_port check")
+	scanCmd.Flags().IntVar(&scanDepth, "depth", scanner.DefaultMaxDepth, "Subdirectory levels to search for compose files (0: the directory only, -1: unlimited)")
+	scanCmd.Flags().BoolVar(&noSkipDirs, "no-skip-dirs", false, "Also search node_modules, .git and vendor directories")
+	scanCmd.Flags().StringArrayVar(&k8sManifests, "k8s", nil, "Also check the nodePorts and hostPorts of this Kubernetes manifest or directory of manifests (repeatable)")
+	scanCmd.Flags().BoolVar(&sniffFiles, "sniff", false, "Also scan other .yml/.yaml files that look like compose files")
+	scanCmd.Flags().StringVar(&sinceCommit, "since-commit", "", "Only report issues involving compose files changed since this commit, or the ports they publish")
+	scanCmd.Flags().StringVar(&pathsFrom, "paths-from", "", "Scan the newline-separated paths listed in a file as one report")
+	scanCmd.Flags().BoolVar(&showHints, "hints", false, "Include low-confidence advisory hints")
+	scanCmd.Flags().BoolVar(&projectsIndependent, "projects-independent", false, "Treat each top-level directory as a separate project")
+	scanCmd.Flags().BoolVar(&assumeCoLocated, "assume-co-located", false, "Report cross-project collisions with --projects-independent")
+	scanCmd.Flags().BoolVar(&noCache, "no-cache", false, "Parse every compose file again instead of reusing unchanged ones from the cache")
+	scanCmd.Flags().BoolVar(&readStdin, "stdin", false, "Scan one compose document read from standard input, as file "+scanner.StdinLabel+" (same as path -)")
+	scanCmd.Flags().BoolVar(&watchMode, "watch", false, "Keep running and re-scan whenever a compose file changes")
+	scanCmd.Flags().BoolVar(&paranoid, "paranoid", false, "Warn about every reuse of a host port, regardless of IP or project")
 }
 
 func runScan(cmd *cobra.Command, args []string) error {
@@ -63,11 +216,179 @@ func runScan(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		path = args[0]
 	}
+	if !reporter.IsFormat(outputFormat) {
+		return fmt.Errorf("unknown format %q (want %s)", outputFormat, strings.Join(reporter.Formats(), ", "))
+	}
+	for _, f := range composeFiles {
+		// -f used to be short for --format
+		if _, err := os.Stat(f); err != nil && reporter.IsFormat(f) {
+			return fmt.Errorf("-f now selects a compose file (--file); use --format %s for the output format", f)
+		}
+	}
+	if failOn == "" && strictMode {
+		failOn = "warning"
+	}
+	if failOn != "" && !scanner.IsSeverity(failOn) {
+		return fmt.Errorf("unknown severity %q for --fail-on (want error, warning or info)", failOn)
+	}
+	if err := checkIssueTypes("--only", onlyTypes); err != nil {
+		return err
+	}
+	if err := checkIssueTypes("--exclude", excludeTypes); err != nil {
+		return err
+	}
+	if baselineFile != "" && baselineRatchet != "" {
+		return fmt.Errorf("--baseline cannot be combined with --baseline-ratchet")
+	}
+	if quiet && summaryOnly {
+		return fmt.Errorf("--quiet cannot be combined with --summary")
+	}
+	if summaryOnly && !reporter.HasCompactMode(outputFormat) {
+		return fmt.Errorf("--summary supports the text, json and markdown formats, not %q", outputFormat)
+	}
+	if path == "-" {
+		readStdin = true
+	}
+	if readStdin {
+		if err := checkStdinFlags(cmd); err != nil {
+			return err
+		}
+	}
+	outputs, err := reporter.ParseOutputs(extraOutputs)
+	if err != nil {
+		return err
+	}
 
 	// Standard compose file scan
-	result, err := scanner.Scan(path)
+	opts := scanner.Options{
+		ProjectsIndependent: projectsIndependent,
+		AssumeCoLocated:     assumeCoLocated,
+		Hints:               showHints,
+		Env:                 composeEnv,
+		Datastores:          datastores,
+		NoCommonPorts:       noCommonPorts,
+		AllowPrivileged:     allowPrivileged,
+		Paranoid:            paranoid,
+		Sniff:               sniffFiles,
+		Profiles:            activeProfiles,
+		MaxDepth:            &scanDepth,
+		NoSkipDirs:          noSkipDirs,
+		Kubernetes:          k8sManifests,
+	}
+	if !cmd.Flags().Changed("project") {
+		runtimeProject = defaultRuntimeProject(path)
+	}
+	if !noCache {
+		if dir, err := scanner.DefaultCacheDir(); err == nil {
+			opts.Cache = scanner.OpenCache(dir)
+		}
+	}
+	if knownPortsFile != "" {
+		if opts.KnownPorts, err = scanner.LoadKnownPorts(knownPortsFile); err != nil {
+			return fmt.Errorf("failed to read --known-ports: %w", err)
+		}
+	}
+	if watchMode {
+		if fixPorts {
+			return fmt.Errorf("--watch cannot be combined with --fix")
+		}
+		return watchScan(path, opts, outputs)
+	}
+
+	_, code, err := scanOnce(path, opts, outputs)
 	if err != nil {
-		return fmt.Errorf("scan failed: %w", err)
+		return err
+	}
+	if code != scanner.ExitClean {
+		os.Exit(code)
+	}
+	return nil
+}
+
+// checkStdinFlags rejects the flags that need compose files on disk when
+// the compose document comes from standard input
+func checkStdinFlags(cmd *cobra.Command) error {
+	for _, name := range []string{"watch", "fix", "file", "paths-from", "since-commit", "project-only", "profile", "all-profiles", "strict-profiles"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be combined with reading from standard input", name)
+		}
+	}
+	return nil
+}
+
+// defaultRuntimeProject returns the compose project name of the scanned
+// path, the one docker compose would give containers started there
+func defaultRuntimeProject(path string) string {
+	if readStdin {
+		return runtime.ProjectName(".")
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	return runtime.ProjectName(path)
+}
+
+// checkIssueTypes rejects names passed to flag that are not issue types,
+// which would otherwise match nothing
+func checkIssueTypes(flag string, types []string) error {
+	for _, t := range types {
+		if scanner.RuleDescription(t) == "" {
+			return fmt.Errorf("unknown issue type %q for %s (see portcheck capabilities for the list)", t, flag)
+		}
+	}
+	return nil
+}
+
+// logScannedFiles writes what each scanned file contributed to stderr
+func logScannedFiles(result *scanner.Result) {
+	for _, f := range result.ScannedFiles {
+		name := f.Path
+		if f.IncludedBy != "" {
+			name = fmt.Sprintf("%s (included by %s)", f.Path, f.IncludedBy)
+		}
+		switch {
+		case f.Unreadable:
+			fmt.Fprintf(os.Stderr, "portcheck: unreadable %s: %s\n", name, f.Error)
+		case !f.Parsed:
+			fmt.Fprintf(os.Stderr, "portcheck: failed to parse %s: %s\n", name, f.Error)
+		default:
+			fmt.Fprintf(os.Stderr, "portcheck: scanned %s: %d binding(s)\n", name, f.Bindings)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "portcheck: %d file(s) scanned\n", len(result.ScannedFiles))
+}
+
+// scanOnce scans path and prints the report, returning the result and the
+// exit code the scan calls for
+func scanOnce(path string, opts scanner.Options, outputs []reporter.Output) (*scanner.Result, int, error) {
+	var err error
+	var result *scanner.Result
+	if readStdin {
+		result, err = scanner.ScanReader(os.Stdin, scanner.StdinLabel, opts)
+	} else if len(composeFiles) > 0 {
+		if pathsFrom != "" {
+			return nil, 0, fmt.Errorf("--file and --paths-from cannot be combined")
+		}
+		result, err = scanner.ScanFiles(composeFiles, opts)
+		if err == nil {
+			// Report relative to the path argument, not the first file
+			result.Path = path
+		}
+	} else if pathsFrom != "" {
+		var paths []string
+		paths, err = scanner.ReadPathsFile(pathsFrom)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read --paths-from: %w", err)
+		}
+		result, err = scanner.ScanPaths(paths, opts)
+	} else {
+		result, err = scanner.ScanWithOptions(path, opts)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("scan failed: %w", err)
+	}
+	if verbose {
+		logScannedFiles(result)
 	}
 
 	// Profile-aware scanning
@@ -81,79 +402,352 @@ func runScan(cmd *cobra.Command, args []string) error {
 				result.Issues = append(result.Issues, scanner.Issue{
 					Severity:    "error",
 					Type:        "profile_collision",
-					Description: fmt.Sprintf("Profile conflict on port %s: multiple services", c.Port),
+					Description: fmt.Sprintf("Profile conflict on port %s/%s: multiple services", c.Port, c.Protocol),
 				})
 			}
 		}
 	}
 
+	// Worst case: every profile active at once
+	if allProfiles {
+		profileConfig, err := profiles.LoadProfiles(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load profiles: %v\n", err)
+		} else {
+			// result only holds the bindings of the active profiles
+			portMap := result.PortMap
+			if len(opts.Profiles) > 0 {
+				everyProfile := opts
+				everyProfile.Profiles = nil
+				if unfiltered, err := scanner.ScanWithOptions(path, everyProfile); err == nil {
+					portMap = unfiltered.PortMap
+				} else {
+					fmt.Fprintf(os.Stderr, "Warning: failed to scan every profile: %v\n", err)
+				}
+			}
+			for _, c := range profileConfig.AllProfilesConflicts(activeProfiles) {
+				port, _ := strconv.Atoi(c.Port)
+				var services []string
+				involved := make(map[string]bool)
+				for _, svc := range c.Services {
+					services = append(services, fmt.Sprintf("%s (%s)", svc.Service, svc.Profile))
+					involved[svc.Service] = true
+				}
+				var bindings []scanner.PortBinding
+				for _, b := range portMap[port] {
+					if involved[b.Service] && b.Protocol == c.Protocol {
+						bindings = append(bindings, b)
+					}
+				}
+				result.Issues = append(result.Issues, scanner.Issue{
+					Severity: "warning",
+					Type:     "all_profiles_collision",
+					Port:     port,
+					Description: fmt.Sprintf("Port %s/%s conflict only under all profiles: %s",
+						c.Port, c.Protocol, strings.Join(services, ", ")),
+					Remediation: "Keep these profiles mutually exclusive, or publish the services on different host ports",
+					Bindings:    bindings,
+				})
+			}
+		}
+	}
+
+	// Profile hygiene lint
+	if strictProfiles {
+		profileConfig, err := profiles.LoadProfiles(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load profiles: %v\n", err)
+		} else {
+			combos := profiles.DocumentedCombinations(path)
+			if len(activeProfiles) > 0 {
+				combos = append(combos, activeProfiles)
+			}
+			for _, h := range profileConfig.CheckHygiene(combos) {
+				result.Issues = append(result.Issues, scanner.Issue{
+					Severity:    "warning",
+					Type:        "profile_hygiene",
+					Description: h.Message,
+					Bindings:    []scanner.PortBinding{{Service: h.Service, File: h.File}},
+				})
+			}
+		}
+	}
+
+	// Ports claimed by other tooling
+	claimedFile := claimedPorts
+	if claimedFile == "" {
+		if reserved := filepath.Join(path, scanner.ReservedPortsFile); fileExists(reserved) {
+			claimedFile = reserved
+		}
+	}
+	if claimedFile != "" {
+		claimed, err := scanner.LoadClaimedPorts(claimedFile)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read claimed ports: %w", err)
+		}
+		result.Issues = append(result.Issues, result.ClaimedIssues(claimed)...)
+	}
+
+	// Host port probing
+	if checkHost {
+		result.Issues = append(result.Issues, hostIssues(result.PortBindings)...)
+	}
+
+	// Issues added above get their IDs here, and the ignore rules
+	scanner.AssignIDs(result.Issues)
+	result.ApplyIgnores()
+
+	// Focus on what changed since a commit. Every file is still scanned so
+	// collisions with untouched siblings on affected ports are kept.
+	if sinceCommit != "" {
+		changed, err := gitdiff.ChangedFiles(path, sinceCommit)
+		switch {
+		case errors.Is(err, gitdiff.ErrNotRepository):
+			fmt.Fprintf(os.Stderr, "Warning: %s is not in a git repository, reporting every issue\n", path)
+		case err != nil:
+			return nil, 0, fmt.Errorf("--since-commit failed: %w", err)
+		default:
+			result.Issues = result.IssuesAffecting(changed)
+		}
+	}
+
+	// Severity threshold
+	if minSeverity != "" {
+		if !scanner.IsSeverity(minSeverity) {
+			return nil, 0, fmt.Errorf("unknown severity %q (want error, warning or info)", minSeverity)
+		}
+		result.Issues = result.FilterBySeverity(minSeverity)
+	}
+
+	// Issue type filters
+	if len(onlyTypes) > 0 {
+		result.Issues = result.FilterByType(onlyTypes...)
+	}
+	if len(excludeTypes) > 0 {
+		result.Issues = result.IssuesExcluding(excludeTypes...)
+	}
+
+	// Merge repetitive findings
+	if collapseDuplicates {
+		result.Issues = scanner.CollapseDuplicates(result.Issues)
+	}
+
+	// Accept the current issues, then leave out accepted ones. Writing
+	// comes first so --baseline and --write-baseline can refresh a file.
+	if writeBaseline != "" {
+		if err := baseline.FromIssues(result.Issues).Save(writeBaseline); err != nil {
+			return nil, 0, fmt.Errorf("failed to write baseline: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Baseline: wrote %d issue(s) to %s\n", len(result.Issues), writeBaseline)
+	}
+	if baselineFile != "" {
+		if !fileExists(baselineFile) {
+			return nil, 0, fmt.Errorf("baseline %s does not exist; create it with --write-baseline", baselineFile)
+		}
+		accepted, err := baseline.Load(baselineFile)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read baseline: %w", err)
+		}
+		baselined, fresh := accepted.Split(result.Issues)
+		result.Issues = fresh
+		fmt.Fprintf(os.Stderr, "Baseline: %d issue(s) baselined, %d new\n", len(baselined), len(fresh))
+	}
+
+	// Baseline ratchet: only new issues are reported, resolved ones are
+	// dropped from the baseline
+	newIssues := false
+	if baselineRatchet != "" {
+		ratchet, err := baseline.Ratchet(baselineRatchet, result.Issues)
+		if err != nil {
+			return nil, 0, fmt.Errorf("baseline ratchet failed: %w", err)
+		}
+		result.Issues = ratchet.New
+		newIssues = len(ratchet.New) > 0
+		fmt.Fprintf(os.Stderr, "Baseline: %d accepted, %d new, %d resolved and removed\n",
+			len(ratchet.Baselined), len(ratchet.New), len(ratchet.Resolved))
+	}
+
 	// Runtime scan
 	var runtimeResult *runtime.RuntimeResult
-	if runtimeScan {
-		runtimeResult, err = runtime.ScanRuntime()
+	var runtimeChanges []runtime.PortChange
+	if runtimeScan || runtimeBaseline != "" {
+		policy := runtime.DefaultRetryPolicy
+		policy.Attempts = runtimeRetries
+		var engine runtime.ContainerEngine
+		if containerEngine != "" {
+			if engine, err = runtime.NewEngine(containerEngine, policy); err != nil {
+				return nil, 0, err
+			}
+		} else if projectOnly {
+			// docker compose ps has no Docker API equivalent
+			engine = runtime.DetectCLIEngine(policy)
+		} else {
+			engine = runtime.DetectEngine(policy)
+		}
+		if projectOnly {
+			runtimeResult, err = runtime.ScanProjectRuntimeWithEngine(engine, path)
+		} else {
+			filter := runtime.RuntimeFilter{Project: runtimeProject, Name: runtimeName}
+			runtimeResult, err = runtime.ScanRuntimeWithEngineFilter(engine, filter)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: runtime scan failed: %v\n", err)
 		} else if runtimeResult.DockerRunning {
-			// Check for conflicts between compose and runtime
+			// Check for conflicts between compose and runtime, once per
+			// port and container
+			type clash struct {
+				port      int
+				container string
+			}
+			reported := make(map[clash]bool)
 			for port, containers := range runtimeResult.UsedPorts {
 				if bindings, exists := result.PortMap[port]; exists {
 					for _, b := range bindings {
 						for _, c := range containers {
-							// Check if it's the same service (might be running from this compose)
-							if !isLikelyFromCompose(c, b.Service) {
-								runtimeResult.Conflicts = append(runtimeResult.Conflicts, runtime.RuntimeConflict{
-									Port:           port,
-									ComposeService: b.Service,
-									RuntimeInfo:    c.Name,
-									Type:           "already_in_use",
-									Message:        fmt.Sprintf("Port %d (for %s) is already used by container %s", port, b.Service, c.Name),
-								})
+							// Skip the service's own container, started from this compose
+							if runtime.MatchesService(c, runtime.ProjectName(filepath.Dir(b.File)), b.Service) {
+								continue
+							}
+							key := clash{port, c.ID + "/" + c.Name}
+							if !reported[key] {
+								reported[key] = true
+								runtimeResult.Conflicts = append(runtimeResult.Conflicts,
+									runtime.NewAlreadyInUseConflict(port, b.Service, c))
 							}
 						}
 					}
 				}
 			}
+
+			if runtimeBaseline != "" {
+				runtimeChanges, err = compareRuntimeBaseline(runtimeBaseline, runtimeResult)
+				if err != nil {
+					return nil, 0, fmt.Errorf("runtime baseline failed: %w", err)
+				}
+			}
 		}
 	}
 
 	// Suggest alternative ports
 	var suggestions map[int]int
-	if suggestPorts && len(result.Issues) > 0 {
+	if (suggestPorts || outputFormat == "env") && len(result.Issues) > 0 {
 		var conflictPorts []int
 		seen := make(map[int]bool)
-		for _, issue := range result.Issues {
-			if issue.Type == "collision" && !seen[issue.Port] {
+		for _, issue := range result.FilterByType("collision", "container_name_port_collision") {
+			if !seen[issue.Port] {
 				conflictPorts = append(conflictPorts, issue.Port)
 				seen[issue.Port] = true
 			}
 		}
 		if len(conflictPorts) > 0 {
-			suggestions = runtime.SuggestFreePorts(conflictPorts)
+			inUse := make(map[int]bool, len(result.PortMap))
+			for port := range result.PortMap {
+				inUse[port] = true
+			}
+			suggestions = runtime.SuggestFreePorts(conflictPorts, inUse)
+		}
+	}
+
+	// Security gate: no publicly exposed datastores
+	publicDatastores := false
+	if failPublicDatastore {
+		for _, b := range result.PublicDatastores() {
+			fmt.Fprintf(os.Stderr, "Public datastore: service %s (%s) publishes %s in %s\n",
+				b.Service, b.Image, b.String(), b.File)
+			publicDatastores = true
 		}
 	}
 
+	// Additional report files, each failing on its own
+	outputFailed := false
+	renderOpts := reporter.RenderOptions{
+		Suggestions:    suggestions,
+		JUnit:          reporter.JUnitOptions{WarningsAsErrors: junitWarnings},
+		Runtime:        runtimeResult,
+		RuntimeChanges: runtimeChanges,
+	}
+	if err := reporter.WriteOutputs(result, renderOpts, outputs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		outputFailed = true
+	}
+
 	// Generate output
-	switch outputFormat {
+	report := outputFormat
+	if quiet || summaryOnly {
+		report = ""
+	}
+	if oneline {
+		report = "oneline"
+	}
+	switch report {
+	case "oneline":
+		// Single status line for prompts and hooks
+		fmt.Println(reporter.FormatOneline(result))
+
+	case "":
+		// --quiet and --summary: counts only, in text for formats
+		// without a compact mode
+		mode, format := reporter.CompactQuiet, outputFormat
+		if summaryOnly {
+			mode = reporter.CompactSummary
+		}
+		if !reporter.HasCompactMode(format) {
+			format = "text"
+		}
+		output, err := reporter.FormatCompact(format, result, mode)
+		if err != nil {
+			return nil, 0, err
+		}
+		fmt.Println(output)
+
 	case "json":
-		output := map[string]interface{}{
-			"result": result,
+		// The same document as --out json:path
+		output, err := reporter.Render("json", result, renderOpts)
+		if err != nil {
+			return nil, 0, err
 		}
-		if runtimeResult != nil {
-			output["runtime"] = runtimeResult
+		fmt.Println(output)
+
+	case "env":
+		fmt.Print(reporter.FormatEnv(result, suggestions))
+
+	case "sarif":
+		output, err := reporter.FormatSARIF(result)
+		if err != nil {
+			return nil, 0, err
+		}
+		fmt.Println(output)
+
+	case "csv":
+		output, err := reporter.FormatCSV(result)
+		if err != nil {
+			return nil, 0, err
+		}
+		fmt.Print(output)
+
+	case "html":
+		output, err := reporter.FormatHTML(result)
+		if err != nil {
+			return nil, 0, err
 		}
-		if suggestions != nil {
-			output["suggestions"] = suggestions
+		fmt.Print(output)
+
+	case "junit":
+		output, err := reporter.FormatJUnitWithOptions(result, renderOpts.JUnit)
+		if err != nil {
+			return nil, 0, err
 		}
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(output)
+		fmt.Println(output)
 
 	case "markdown":
 		output, err := reporter.FormatMarkdown(result)
 		if err != nil {
-			return err
+			return nil, 0, err
 		}
 		fmt.Println(output)
+		if heatmap {
+			fmt.Println(reporter.FormatHeatmap(result))
+		}
 		if runtimeResult != nil && runtimeResult.DockerRunning {
 			fmt.Println(runtime.FormatRuntimeResult(runtimeResult))
 		}
@@ -165,9 +759,13 @@ func runScan(cmd *cobra.Command, args []string) error {
 		}
 
 	default:
-		output, err := reporter.FormatText(result)
+		format := reporter.FormatText
+		if treeLayout {
+			format = reporter.FormatTree
+		}
+		output, err := format(result)
 		if err != nil {
-			return err
+			return nil, 0, err
 		}
 		fmt.Println(output)
 
@@ -179,7 +777,13 @@ func runScan(cmd *cobra.Command, args []string) error {
 				if hostIP == "" {
 					hostIP = "0.0.0.0 (all interfaces)"
 				}
-				fmt.Printf("  %s: %s -> %d:%d\n", b.Service, hostIP, b.HostPort, b.ContainerPort)
+				hostPort := strconv.Itoa(b.HostPort)
+				if b.RandomHostPort {
+					hostPort = "random"
+				} else if b.Unresolved {
+					hostPort = "unresolved"
+				}
+				fmt.Printf("  %s: %s -> %s:%d\n", b.Service, hostIP, hostPort, b.ContainerPort)
 			}
 		}
 
@@ -190,6 +794,9 @@ func runScan(cmd *cobra.Command, args []string) error {
 				fmt.Println("Conflicts:")
 				for _, c := range runtimeResult.Conflicts {
 					fmt.Printf("  ⚠️  %s\n", c.Message)
+					if c.Remediation != "" {
+						fmt.Printf("      Fix: %s\n", c.Remediation)
+					}
 				}
 			}
 		}
@@ -202,30 +809,126 @@ func runScan(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Exit with error if strict mode and issues found
-	hasIssues := result.HasIssues()
-	if runtimeResult != nil && len(runtimeResult.Conflicts) > 0 {
-		hasIssues = true
+	if footer && !quiet && !oneline {
+		line := reporter.FormatFooter(result)
+		if report == "env" {
+			// Keep the output safe to eval
+			line = "# " + line
+		}
+		fmt.Println(line)
+	}
+
+	if fixPorts {
+		if err := applyFixes(result, dryRun, assumeYes); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	if strictMode && hasIssues {
-		os.Exit(1)
+	if newIssues || publicDatastores || outputFailed {
+		return result, scanner.ExitErrors, nil
 	}
 
+	// Exit with the most severe issue at or above --fail-on
+	summary := result.Summary()
+	if runtimeResult != nil {
+		summary.Errors += len(runtimeResult.Conflicts)
+	}
+	threshold := failOn
+	if oneline && threshold == "" {
+		// --oneline exits 1, 2 or 3 for errors, warnings or info
+		threshold = "info"
+	}
+	return result, summary.ExitCode(threshold), nil
+}
+
+// watchScan re-runs the scan whenever the compose files under path change,
+// reprinting the report, until interrupted. It then exits with the code of
+// the most recent scan.
+func watchScan(path string, opts scanner.Options, outputs []reporter.Output) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := watch.New(path, opts)
+	code := scanner.ExitClean
+	for w.WaitForChange(ctx) == nil {
+		if !color.NoColor {
+			// Clear the terminal so only the latest report is visible
+			fmt.Print("\033[H\033[2J")
+		}
+		result, scanCode, err := scanOnce(path, opts, outputs)
+		if err != nil {
+			// Keep watching: the next save may fix the file
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = scanner.ExitErrors
+			continue
+		}
+		code = scanCode
+		w.Files = result.ComposeFiles
+		fmt.Fprintf(os.Stderr, "[%s] Watching %s for changes (Ctrl+C to stop)\n", time.Now().Format(time.TimeOnly), path)
+	}
+
+	if code != scanner.ExitClean {
+		os.Exit(code)
+	}
 	return nil
 }
 
-// isLikelyFromCompose checks if a running container might be from the compose service
-func isLikelyFromCompose(container runtime.Container, serviceName string) bool {
-	// Check container name contains service name
-	if strings.Contains(strings.ToLower(container.Name), strings.ToLower(serviceName)) {
-		return true
+// compareRuntimeBaseline reports how published ports changed since the
+// snapshot at path, then replaces the snapshot with current. Without a
+// previous snapshot there is nothing to compare and it is only written.
+func compareRuntimeBaseline(path string, current *runtime.RuntimeResult) ([]runtime.PortChange, error) {
+	previous, err := runtime.LoadSnapshot(path)
+	if err != nil {
+		return nil, err
 	}
-	// Check com.docker.compose.service label
-	if label, ok := container.Labels["com.docker.compose.service"]; ok {
-		if strings.ToLower(label) == strings.ToLower(serviceName) {
-			return true
+
+	changes := []runtime.PortChange{}
+	if previous == nil {
+		fmt.Fprintf(os.Stderr, "Runtime baseline: saved first snapshot to %s\n", path)
+	} else {
+		changes = runtime.DiffSnapshots(previous, current)
+		fmt.Fprintf(os.Stderr, "Runtime baseline: %d port change(s) since %s\n",
+			len(changes), previous.ScanTime.Format(time.RFC3339))
+		fmt.Fprint(os.Stderr, runtime.FormatPortChanges(changes))
+	}
+	return changes, runtime.SaveSnapshot(path, current)
+}
+
+// hostIssues probes each distinct host port/protocol/IP of the bindings and
+// reports the ones already owned by something outside Docker
+func hostIssues(bindings []scanner.PortBinding) []scanner.Issue {
+	var issues []scanner.Issue
+	seen := make(map[string]bool)
+
+	for _, b := range bindings {
+		key := fmt.Sprintf("%s|%d/%s", b.HostIP, b.HostPort, b.Protocol)
+		if b.RandomHostPort || b.Unresolved || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if c := runtime.CheckHostPort(b.HostPort, b.Protocol, b.HostIP); c != nil {
+			issue := scanner.Issue{
+				Severity:    "error",
+				Type:        "host_in_use",
+				Port:        b.HostPort,
+				Description: c.Message,
+				Bindings:    []scanner.PortBinding{b},
+			}
+			if c.ProbeErr != nil {
+				// Nothing is known about other listeners on the port
+				issue.Severity = "info"
+				issue.Type = "host_not_probed"
+			}
+			issue.Remediation = scanner.Remediation(issue)
+			issues = append(issues, issue)
 		}
 	}
-	return false
+
+	return issues
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
 }
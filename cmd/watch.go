@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/portcheck/internal/reporter"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+	"github.com/stackgen-cli/portcheck/internal/watch"
+)
+
+var (
+	watchInterval time.Duration
+	watchFormat   string
+	watchEnv      string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [path]",
+	Short: "Re-scan compose files whenever they change",
+	Long: `Watch compose files and re-scan them whenever they change.
+
+With --format ndjson-events, every scan is written as one JSON line
+with a summary and the issues that appeared or were resolved since the
+previous scan, for dashboards and other live consumers.
+
+Examples:
+  portcheck watch
+  portcheck watch ./myproject --format ndjson-events`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", watch.DefaultInterval, "How often to check for changes")
+	watchCmd.Flags().StringVarP(&watchFormat, "format", "f", "text", "Output format: text, ndjson-events")
+	// -f is --file in portcheck scan
+	watchCmd.Flags().MarkShorthandDeprecated("format", "use --format instead")
+	watchCmd.Flags().StringVar(&watchEnv, "env", "", "Scan docker-compose.yml merged with docker-compose.<env>.yml only")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	var onScan func(curr, prev *scanner.Result) error
+	switch watchFormat {
+	case "ndjson-events":
+		onScan = func(curr, prev *scanner.Result) error {
+			return watch.WriteEvent(os.Stdout, watch.NewEvent(curr, prev, time.Now()))
+		}
+	case "text":
+		onScan = func(curr, prev *scanner.Result) error {
+			output, err := reporter.FormatText(curr)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("[%s]\n%s\n", time.Now().Format(time.TimeOnly), output)
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown watch format %q", watchFormat)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := watch.New(path, scanner.Options{Env: watchEnv})
+	w.Interval = watchInterval
+	return w.Run(ctx, onScan)
+}
@@ -0,0 +1,124 @@
+// Package baseline records accepted issues so only new ones fail a scan
+package baseline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// Version is the baseline file format version
+const Version = 1
+
+// DefaultFile is the conventional baseline file name
+const DefaultFile = ".portcheck-baseline.json"
+
+// Entry identifies an accepted issue. Matching ignores file and line so
+// moving a port declaration does not defeat the baseline.
+type Entry struct {
+	Type     string   `json:"type"`
+	Port     int      `json:"port"`
+	Services []string `json:"services"`
+}
+
+// Baseline is the set of accepted issues
+type Baseline struct {
+	Version int     `json:"version"`
+	Issues  []Entry `json:"issues"`
+}
+
+// EntryFor returns the baseline entry matching an issue
+func EntryFor(issue scanner.Issue) Entry {
+	seen := make(map[string]bool)
+	services := []string{}
+	for _, b := range issue.Bindings {
+		if !seen[b.Service] {
+			seen[b.Service] = true
+			services = append(services, b.Service)
+		}
+	}
+	sort.Strings(services)
+
+	return Entry{Type: issue.Type, Port: issue.Port, Services: services}
+}
+
+func (e Entry) key() string {
+	return fmt.Sprintf("%s|%d|%s", e.Type, e.Port, strings.Join(e.Services, ","))
+}
+
+// FromIssues builds a baseline accepting every given issue
+func FromIssues(issues []scanner.Issue) *Baseline {
+	b := &Baseline{Version: Version, Issues: []Entry{}}
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		e := EntryFor(issue)
+		if !seen[e.key()] {
+			seen[e.key()] = true
+			b.Issues = append(b.Issues, e)
+		}
+	}
+	b.sort()
+	return b
+}
+
+// Load reads a baseline file. A missing file is an empty baseline.
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Baseline{Version: Version, Issues: []Entry{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	if b.Issues == nil {
+		b.Issues = []Entry{}
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to path
+func (b *Baseline) Save(path string) error {
+	b.sort()
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Split separates issues already accepted by the baseline from new ones
+func (b *Baseline) Split(issues []scanner.Issue) (baselined, fresh []scanner.Issue) {
+	accepted := b.keys()
+	for _, issue := range issues {
+		if accepted[EntryFor(issue).key()] {
+			baselined = append(baselined, issue)
+		} else {
+			fresh = append(fresh, issue)
+		}
+	}
+	return baselined, fresh
+}
+
+func (b *Baseline) keys() map[string]bool {
+	keys := make(map[string]bool, len(b.Issues))
+	for _, e := range b.Issues {
+		keys[e.key()] = true
+	}
+	return keys
+}
+
+func (b *Baseline) sort() {
+	sort.Slice(b.Issues, func(i, j int) bool {
+		return b.Issues[i].key() < b.Issues[j].key()
+	})
+}
@@ -0,0 +1,170 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+func collision(port int, services ...string) scanner.Issue {
+	issue := scanner.Issue{Severity: "error", Type: "collision", Port: port}
+	for _, svc := range services {
+		issue.Bindings = append(issue.Bindings, scanner.PortBinding{HostPort: port, Service: svc})
+	}
+	return issue
+}
+
+func TestSplit_MatchesIgnoringOrder(t *testing.T) {
+	b := FromIssues([]scanner.Issue{collision(8080, "web", "api")})
+
+	baselined, fresh := b.Split([]scanner.Issue{
+		collision(8080, "api", "web"),
+		collision(3000, "api", "web"),
+	})
+
+	if len(baselined) != 1 || baselined[0].Port != 8080 {
+		t.Errorf("Expected 8080 to be baselined, got %+v", baselined)
+	}
+	if len(fresh) != 1 || fresh[0].Port != 3000 {
+		t.Errorf("Expected 3000 to be new, got %+v", fresh)
+	}
+}
+
+func TestSave_RoundTripIgnoresLineMoves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	written := collision(8080, "web", "api")
+	written.Bindings[0].File, written.Bindings[0].Line = "docker-compose.yml", 4
+	if err := FromIssues([]scanner.Issue{written}).Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	moved := collision(8080, "web", "api")
+	moved.Bindings[0].File, moved.Bindings[0].Line = "compose/docker-compose.yml", 12
+	baselined, fresh := b.Split([]scanner.Issue{moved, collision(8080, "web", "worker")})
+
+	if len(baselined) != 1 {
+		t.Errorf("Expected the moved declaration to stay baselined, got %+v", baselined)
+	}
+	if len(fresh) != 1 || fresh[0].Bindings[1].Service != "worker" {
+		t.Errorf("Expected a collision with other services to be new, got %+v", fresh)
+	}
+}
+
+func TestRatchet_Improve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".portcheck-baseline.json")
+	initial := FromIssues([]scanner.Issue{collision(8080, "web", "api"), collision(5432, "db", "replica")})
+	if err := initial.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 5432 was fixed
+	result, err := Ratchet(path, []scanner.Issue{collision(8080, "web", "api")})
+	if err != nil {
+		t.Fatalf("Ratchet failed: %v", err)
+	}
+
+	if len(result.New) != 0 {
+		t.Errorf("Expected no new issues, got %d", len(result.New))
+	}
+	if len(result.Resolved) != 1 || result.Resolved[0].Port != 5432 {
+		t.Errorf("Expected 5432 to be resolved, got %+v", result.Resolved)
+	}
+
+	shrunk, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shrunk.Issues) != 1 || shrunk.Issues[0].Port != 8080 {
+		t.Errorf("Baseline should shrink to 8080 only, got %+v", shrunk.Issues)
+	}
+}
+
+func TestRatchet_Regress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".portcheck-baseline.json")
+	if err := FromIssues([]scanner.Issue{collision(8080, "web", "api")}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Ratchet(path, []scanner.Issue{collision(8080, "web", "api"), collision(3000, "web", "admin")})
+	if err != nil {
+		t.Fatalf("Ratchet failed: %v", err)
+	}
+
+	if len(result.New) != 1 || result.New[0].Port != 3000 {
+		t.Errorf("Expected 3000 as new issue, got %+v", result.New)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("Baseline must not change on regression:\n%s", after)
+	}
+}
+
+func TestLoad_MissingFileIsEmpty(t *testing.T) {
+	b, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(b.Issues) != 0 {
+		t.Errorf("Expected empty baseline, got %d entries", len(b.Issues))
+	}
+}
+
+func TestCompare_AddedRemovedUnchanged(t *testing.T) {
+	privileged := scanner.Issue{Severity: "warning", Type: "privileged", Port: 80,
+		Bindings: []scanner.PortBinding{{HostPort: 80, Service: "web"}}}
+
+	d := Compare(
+		[]scanner.Issue{collision(8080, "web", "api"), collision(5432, "db", "replica")},
+		[]scanner.Issue{collision(8080, "api", "web"), collision(3000, "api", "worker"), privileged},
+	)
+
+	if len(d.Unchanged) != 1 || d.Unchanged[0].Port != 8080 {
+		t.Errorf("Expected reordered 8080 to be unchanged, got %+v", d.Unchanged)
+	}
+	if len(d.Added) != 2 {
+		t.Errorf("Expected 2 added issues, got %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Port != 5432 {
+		t.Errorf("Expected 5432 to be removed, got %+v", d.Removed)
+	}
+	if errors := d.AddedErrors(); len(errors) != 1 || errors[0].Port != 3000 {
+		t.Errorf("Expected only the 3000 collision as an added error, got %+v", errors)
+	}
+}
+
+func TestLoadIssues_ReportShapes(t *testing.T) {
+	dir := t.TempDir()
+	reports := map[string]string{
+		"scan.json": `{"schema_version": 1, "result": {"Issues": [{"Severity": "error", "Type": "collision", "Port": 8080, "Bindings": [{"Service": "web"}, {"Service": "api"}]}]}}`,
+		"out.json":  `{"schema_version": 1, "issues": [{"severity": "error", "type": "collision", "port": 8080, "bindings": [{"service": "api"}, {"service": "web"}]}]}`,
+	}
+
+	for name, content := range reports {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		issues, err := LoadIssues(path)
+		if err != nil {
+			t.Fatalf("LoadIssues(%s) failed: %v", name, err)
+		}
+		d := Compare(issues, []scanner.Issue{collision(8080, "web", "api")})
+		if len(d.Unchanged) != 1 || len(d.Added) != 0 || len(d.Removed) != 0 {
+			t.Errorf("%s: expected the collision to match, got %+v", name, d)
+		}
+	}
+}
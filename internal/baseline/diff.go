@@ -0,0 +1,62 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// Diff sorts the issues of two scans by whether they appear in the old
+// scan, the new one or both, matching them like baseline entries
+type Diff struct {
+	Added     []scanner.Issue // only in the new scan
+	Removed   []scanner.Issue // only in the old scan
+	Unchanged []scanner.Issue // in both, as reported by the new scan
+}
+
+// Compare diffs the issues of an old and a new scan by type, port and
+// sorted service list, so reordered services or moved declarations are
+// not a change
+func Compare(old, new []scanner.Issue) Diff {
+	var d Diff
+	d.Unchanged, d.Added = FromIssues(old).Split(new)
+	_, d.Removed = FromIssues(new).Split(old)
+	return d
+}
+
+// AddedErrors returns the added issues of error severity
+func (d Diff) AddedErrors() []scanner.Issue {
+	var errors []scanner.Issue
+	for _, issue := range d.Added {
+		if issue.Severity == "error" {
+			errors = append(errors, issue)
+		}
+	}
+	return errors
+}
+
+// LoadIssues reads the issues of a saved JSON report, as written by scan
+// --format json or --out json:path. Reports saved by older versions of
+// scan --format json, which nested the issues under result, still load.
+func LoadIssues(path string) ([]scanner.Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report struct {
+		Result *struct {
+			Issues []scanner.Issue
+		} `json:"result"`
+		Issues []scanner.Issue `json:"issues"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	if report.Result != nil {
+		return report.Result.Issues, nil
+	}
+	return report.Issues, nil
+}
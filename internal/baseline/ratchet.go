@@ -0,0 +1,46 @@
+package baseline
+
+import "github.com/stackgen-cli/portcheck/internal/scanner"
+
+// RatchetResult describes the outcome of a ratchet run
+type RatchetResult struct {
+	Baselined []scanner.Issue // issues still accepted by the baseline
+	New       []scanner.Issue // issues not in the baseline
+	Resolved  []Entry         // baseline entries that no longer occur
+}
+
+// Ratchet compares issues against the baseline at path and rewrites the
+// baseline without any resolved entries, so accepted debt only shrinks. New
+// issues are reported but never added to the baseline.
+func Ratchet(path string, issues []scanner.Issue) (*RatchetResult, error) {
+	b, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RatchetResult{}
+	result.Baselined, result.New = b.Split(issues)
+
+	current := make(map[string]bool)
+	for _, issue := range result.Baselined {
+		current[EntryFor(issue).key()] = true
+	}
+
+	kept := []Entry{}
+	for _, e := range b.Issues {
+		if current[e.key()] {
+			kept = append(kept, e)
+		} else {
+			result.Resolved = append(result.Resolved, e)
+		}
+	}
+
+	if len(result.Resolved) > 0 {
+		b.Issues = kept
+		if err := b.Save(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
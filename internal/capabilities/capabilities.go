@@ -0,0 +1,27 @@
+// Package capabilities reports what a portcheck build supports so editor
+// and CI integrations can feature-detect instead of parsing help text
+package capabilities
+
+import (
+	"github.com/stackgen-cli/portcheck/internal/reporter"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// Report lists the supported output formats and rule types. Both are read
+// from the registered formatters and rules, so they cannot drift.
+type Report struct {
+	Version       string   `json:"version"`
+	SchemaVersion int      `json:"schema_version"` // version of the scan JSON output
+	Formats       []string `json:"formats"`
+	Rules         []string `json:"rules"`
+}
+
+// Capabilities returns the capabilities of this build
+func Capabilities(version string) Report {
+	return Report{
+		Version:       version,
+		SchemaVersion: reporter.JSONSchemaVersion,
+		Formats:       reporter.Formats(),
+		Rules:         scanner.RuleTypes(),
+	}
+}
@@ -0,0 +1,32 @@
+package capabilities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackgen-cli/portcheck/internal/reporter"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+func TestCapabilities_FormatsMatchRenderers(t *testing.T) {
+	caps := Capabilities("test")
+
+	want := []string{"csv", "env", "html", "json", "junit", "markdown", "sarif", "text"}
+	if !reflect.DeepEqual(caps.Formats, want) {
+		t.Errorf("Formats = %v, want %v", caps.Formats, want)
+	}
+
+	result := &scanner.Result{PortMap: map[int][]scanner.PortBinding{}}
+	for _, format := range caps.Formats {
+		if _, err := reporter.Render(format, result, reporter.RenderOptions{}); err != nil {
+			t.Errorf("Listed format %s is not rendered: %v", format, err)
+		}
+	}
+	if _, err := reporter.Render("yaml", result, reporter.RenderOptions{}); err == nil {
+		t.Error("Expected an error for an unlisted format")
+	}
+
+	if caps.SchemaVersion != reporter.JSONSchemaVersion || len(caps.Rules) == 0 {
+		t.Errorf("Unexpected capabilities %+v", caps)
+	}
+}
@@ -0,0 +1,208 @@
+// Package doctor diagnoses the environment portcheck runs in
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/baseline"
+	"github.com/stackgen-cli/portcheck/internal/runtime"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// Check statuses. Only StatusFail is a hard problem.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Check is the outcome of one diagnostic
+type Check struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// Report is the outcome of every diagnostic
+type Report struct {
+	Checks []Check
+}
+
+// Failed reports whether any check found a hard problem
+func (r *Report) Failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Doctor runs the diagnostics for a directory. The probes are fields so
+// tests can replace them.
+type Doctor struct {
+	Dir               string
+	DockerVersion     func() (string, error)
+	CanBindPrivileged func() bool
+	ProbePort         func(port int) (bool, error)
+}
+
+// New returns a Doctor for dir that probes the real environment
+func New(dir string) *Doctor {
+	return &Doctor{
+		Dir: dir,
+		DockerVersion: func() (string, error) {
+			return runtime.DockerVersion(runtime.RetryPolicy{Attempts: 1})
+		},
+		CanBindPrivileged: runtime.CanBindPrivileged,
+		ProbePort: func(port int) (bool, error) {
+			return runtime.ProbePort(port, "tcp", "")
+		},
+	}
+}
+
+// Run performs every diagnostic
+func (d *Doctor) Run() *Report {
+	r := &Report{}
+	r.Checks = append(r.Checks, d.checkDocker(), d.checkPrivileged())
+	r.Checks = append(r.Checks, d.checkComposeFiles())
+	r.Checks = append(r.Checks, d.checkConfigFiles()...)
+	r.Checks = append(r.Checks, d.checkCommonPorts())
+	return r
+}
+
+func (d *Doctor) checkDocker() Check {
+	version, err := d.DockerVersion()
+	if err != nil {
+		return Check{"Docker", StatusWarn, "not available; --runtime checks will be skipped"}
+	}
+	return Check{"Docker", StatusOK, "engine " + version}
+}
+
+func (d *Doctor) checkPrivileged() Check {
+	if d.CanBindPrivileged() {
+		return Check{"Privileged ports", StatusOK, "this user can bind ports below 1024"}
+	}
+	return Check{"Privileged ports", StatusWarn, "this user cannot bind ports below 1024 without root"}
+}
+
+func (d *Doctor) checkComposeFiles() Check {
+	files := scanner.DiscoverComposeFiles(d.Dir)
+	if len(files) == 0 {
+		return Check{"Compose files", StatusWarn, "none found in " + d.Dir}
+	}
+
+	result, err := scanner.Scan(d.Dir)
+	if err != nil {
+		return Check{"Compose files", StatusWarn, err.Error()}
+	}
+	var broken []string
+	for _, issue := range result.FilterByType("parse_error") {
+		broken = append(broken, issue.Description)
+	}
+	if len(broken) > 0 {
+		return Check{"Compose files", StatusWarn,
+			fmt.Sprintf("%d found, %d failed to parse: %s", len(files), len(broken), strings.Join(broken, "; "))}
+	}
+	return Check{"Compose files", StatusOK, fmt.Sprintf("%d found (%s)", len(files), relList(d.Dir, files))}
+}
+
+// checkConfigFiles validates the portcheck files that may live next to the
+// compose files. Missing files are fine; unreadable ones are hard failures.
+func (d *Doctor) checkConfigFiles() []Check {
+	type configFile struct {
+		name  string
+		parse func(path string) error
+	}
+	files := []configFile{
+		{baseline.DefaultFile, func(path string) error {
+			b, err := baseline.Load(path)
+			if err == nil && b.Version != baseline.Version {
+				err = fmt.Errorf("unsupported baseline version %d (want %d)", b.Version, baseline.Version)
+			}
+			return err
+		}},
+		{scanner.ReservedPortsFile, func(path string) error {
+			_, err := scanner.LoadClaimedPorts(path)
+			return err
+		}},
+	}
+
+	var checks []Check
+	for _, f := range files {
+		path := filepath.Join(d.Dir, f.name)
+		if _, err := os.Stat(path); err != nil {
+			checks = append(checks, Check{f.name, StatusOK, "not present"})
+			continue
+		}
+		if err := f.parse(path); err != nil {
+			checks = append(checks, Check{f.name, StatusFail, err.Error()})
+			continue
+		}
+		checks = append(checks, Check{f.name, StatusOK, "valid"})
+	}
+	return checks
+}
+
+func (d *Doctor) checkCommonPorts() Check {
+	common := scanner.CommonPorts()
+	ports := make([]int, 0, len(common))
+	for port := range common {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	var busy, unprobed []string
+	for _, port := range ports {
+		inUse, err := d.ProbePort(port)
+		switch {
+		case err != nil:
+			unprobed = append(unprobed, fmt.Sprintf("%d (%s)", port, common[port]))
+		case inUse:
+			busy = append(busy, fmt.Sprintf("%d (%s)", port, common[port]))
+		}
+	}
+	var notProbed string
+	if len(unprobed) > 0 {
+		notProbed = "; could not probe " + strings.Join(unprobed, ", ")
+	}
+	if len(busy) > 0 {
+		return Check{"Common ports", StatusWarn, "already in use: " + strings.Join(busy, ", ") + notProbed}
+	}
+	if len(unprobed) > 0 {
+		return Check{"Common ports", StatusOK, "none in use" + notProbed}
+	}
+	return Check{"Common ports", StatusOK, "all free"}
+}
+
+func relList(dir string, files []string) string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		if rel, err := filepath.Rel(dir, f); err == nil {
+			f = rel
+		}
+		names[i] = f
+	}
+	return strings.Join(names, ", ")
+}
+
+// Format renders the report as text
+func Format(r *Report) string {
+	var sb strings.Builder
+	sb.WriteString("portcheck doctor\n================\n\n")
+	for _, c := range r.Checks {
+		icon := "✅"
+		switch c.Status {
+		case StatusWarn:
+			icon = "⚠️ "
+		case StatusFail:
+			icon = "❌"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s: %s\n", icon, c.Name, c.Detail))
+	}
+	return sb.String()
+}
@@ -0,0 +1,122 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func stubDoctor(dir string) *Doctor {
+	return &Doctor{
+		Dir:               dir,
+		DockerVersion:     func() (string, error) { return "", errors.New("not installed") },
+		CanBindPrivileged: func() bool { return false },
+		ProbePort: func(port int) (bool, error) {
+			if port < 1024 {
+				return false, errors.New("permission denied")
+			}
+			return port == 5432, nil
+		},
+	}
+}
+
+func findCheck(t *testing.T, r *Report, name string) Check {
+	t.Helper()
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("No %q check in %+v", name, r.Checks)
+	return Check{}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDoctor_ComposeDetection(t *testing.T) {
+	dir := t.TempDir()
+
+	report := stubDoctor(dir).Run()
+	if c := findCheck(t, report, "Compose files"); c.Status != StatusWarn {
+		t.Errorf("Expected a warning without compose files, got %+v", c)
+	}
+
+	writeFile(t, filepath.Join(dir, "docker-compose.yml"), "services:\n  web:\n    image: nginx\n")
+	writeFile(t, filepath.Join(dir, "api", "compose.yaml"), "services:\n  api:\n    image: node\n")
+
+	report = stubDoctor(dir).Run()
+	c := findCheck(t, report, "Compose files")
+	if c.Status != StatusOK || !strings.Contains(c.Detail, "2 found") || !strings.Contains(c.Detail, filepath.Join("api", "compose.yaml")) {
+		t.Errorf("Expected both compose files, got %+v", c)
+	}
+
+	writeFile(t, filepath.Join(dir, "docker-compose.yml"), "services:\n  web:\n\timage: nginx\n")
+	report = stubDoctor(dir).Run()
+	if c := findCheck(t, report, "Compose files"); c.Status != StatusWarn || !strings.Contains(c.Detail, "1 failed to parse") {
+		t.Errorf("Expected a parse warning, got %+v", c)
+	}
+	if report.Failed() {
+		t.Error("Compose problems should not be hard failures")
+	}
+}
+
+func TestDoctor_ConfigValidation(t *testing.T) {
+	dir := t.TempDir()
+
+	report := stubDoctor(dir).Run()
+	if report.Failed() {
+		t.Fatalf("Missing config files should pass, got %+v", report.Checks)
+	}
+	if c := findCheck(t, report, ".portcheck-baseline.json"); c.Detail != "not present" {
+		t.Errorf("Unexpected baseline check %+v", c)
+	}
+
+	writeFile(t, filepath.Join(dir, ".portcheck-baseline.json"), `{"version": 1, "issues": []}`)
+	writeFile(t, filepath.Join(dir, ".portcheck-reserved"), "3000 vite\n")
+	report = stubDoctor(dir).Run()
+	if report.Failed() {
+		t.Errorf("Valid config files should pass, got %+v", report.Checks)
+	}
+
+	writeFile(t, filepath.Join(dir, ".portcheck-baseline.json"), `{"version": 1, "issues": [`)
+	writeFile(t, filepath.Join(dir, ".portcheck-reserved"), "not-a-port\n")
+	report = stubDoctor(dir).Run()
+	if !report.Failed() {
+		t.Error("Invalid config files should be hard failures")
+	}
+	for _, name := range []string{".portcheck-baseline.json", ".portcheck-reserved"} {
+		if c := findCheck(t, report, name); c.Status != StatusFail {
+			t.Errorf("Expected %s to fail, got %+v", name, c)
+		}
+	}
+
+	writeFile(t, filepath.Join(dir, ".portcheck-baseline.json"), `{"version": 7, "issues": []}`)
+	if c := findCheck(t, stubDoctor(dir).Run(), ".portcheck-baseline.json"); c.Status != StatusFail {
+		t.Errorf("Expected an unsupported version to fail, got %+v", c)
+	}
+}
+
+func TestDoctor_EnvironmentWarnings(t *testing.T) {
+	report := stubDoctor(t.TempDir()).Run()
+
+	for _, name := range []string{"Docker", "Privileged ports"} {
+		if c := findCheck(t, report, name); c.Status != StatusWarn {
+			t.Errorf("Expected %s to warn, got %+v", name, c)
+		}
+	}
+	if c := findCheck(t, report, "Common ports"); !strings.Contains(c.Detail, "5432 (PostgreSQL)") {
+		t.Errorf("Expected the busy PostgreSQL port, got %+v", c)
+	} else if strings.Contains(c.Detail, "in use: 22") || !strings.Contains(c.Detail, "could not probe 22 (SSH)") {
+		t.Errorf("Expected ports that could not be probed apart from busy ones, got %+v", c)
+	}
+}
@@ -0,0 +1,118 @@
+// Package export turns scan results into configuration for other tools
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// Route sends requests for a hostname to a service's published port
+type Route struct {
+	Host     string
+	Service  string
+	Upstream string // host:port the proxy forwards to
+}
+
+// caddyTemplate is a Caddyfile with one site per route. Caddy serves
+// *.localhost names over HTTPS with its local CA.
+var caddyTemplate = template.Must(template.New("caddy").Parse(`# Generated by portcheck export --proxy caddy
+{{range .}}
+{{.Host}} {
+	reverse_proxy {{.Upstream}}
+}
+{{end}}`))
+
+// nginxTemplate is an nginx config with one server block per route
+var nginxTemplate = template.Must(template.New("nginx").Parse(`# Generated by portcheck export --proxy nginx
+{{range .}}
+server {
+    listen 80;
+    server_name {{.Host}};
+
+    location / {
+        proxy_pass http://{{.Upstream}};
+        proxy_set_header Host $host;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+    }
+}
+{{end}}`))
+
+// Routes derives one route per service from its lowest published TCP port.
+// Hostnames are <service>.<domain>, or <service>.<project>.<domain> when
+// the same service name appears in several projects.
+func Routes(r *scanner.Result, domain string) []Route {
+	first := make(map[string]scanner.PortBinding) // project/service -> binding
+	projects := make(map[string]map[string]bool)  // service -> projects
+	for _, b := range scanner.SortBindings(r.PortBindings) {
+		if b.Protocol != "tcp" || b.HostPort == 0 {
+			continue
+		}
+		key := b.Project + "/" + b.Service
+		if _, ok := first[key]; !ok {
+			first[key] = b
+		}
+		if projects[b.Service] == nil {
+			projects[b.Service] = make(map[string]bool)
+		}
+		projects[b.Service][b.Project] = true
+	}
+
+	var routes []Route
+	for _, b := range first {
+		host := hostLabel(b.Service)
+		if len(projects[b.Service]) > 1 {
+			host += "." + hostLabel(b.Project)
+		}
+		routes = append(routes, Route{
+			Host:     host + "." + domain,
+			Service:  b.Service,
+			Upstream: upstream(b),
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Host < routes[j].Host })
+	return routes
+}
+
+// Proxy renders a skeleton reverse proxy config of the given kind, caddy
+// or nginx, routing each service's hostname to its published port
+func Proxy(r *scanner.Result, kind, domain string) (string, error) {
+	var tmpl *template.Template
+	switch kind {
+	case "caddy":
+		tmpl = caddyTemplate
+	case "nginx":
+		tmpl = nginxTemplate
+	default:
+		return "", fmt.Errorf("unknown proxy %q (want caddy or nginx)", kind)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, Routes(r, domain)); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// upstream is the address a proxy on the host reaches a binding at
+func upstream(b scanner.PortBinding) string {
+	host := b.HostIP
+	switch host {
+	case "", "0.0.0.0", "::", "[::]":
+		host = "127.0.0.1"
+	}
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		host = "[" + host + "]"
+	}
+	return fmt.Sprintf("%s:%d", host, b.HostPort)
+}
+
+// hostLabel turns a compose name into a DNS label
+func hostLabel(name string) string {
+	label := strings.ToLower(name)
+	label = strings.NewReplacer("_", "-", ".", "-", " ", "-").Replace(label)
+	return strings.Trim(label, "-")
+}
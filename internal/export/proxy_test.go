@@ -0,0 +1,86 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+func scanFixture(t *testing.T) *scanner.Result {
+	t.Helper()
+	dir := t.TempDir()
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - "8443:443"
+  admin_ui:
+    image: node
+    ports:
+      - "127.0.0.1:3001:3000"
+  dns:
+    image: coredns
+    ports:
+      - "5353:53/udp"
+  worker:
+    image: worker
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	return result
+}
+
+func TestProxy_Caddy(t *testing.T) {
+	out, err := Proxy(scanFixture(t), "caddy", "localhost")
+	if err != nil {
+		t.Fatalf("Proxy failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"web.localhost {\n\treverse_proxy 127.0.0.1:8080\n}",
+		"admin-ui.localhost {\n\treverse_proxy 127.0.0.1:3001\n}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "8443") || strings.Contains(out, "dns") || strings.Contains(out, "worker") {
+		t.Errorf("Expected one TCP route per publishing service, got:\n%s", out)
+	}
+}
+
+func TestProxy_Nginx(t *testing.T) {
+	out, err := Proxy(scanFixture(t), "nginx", "dev.test")
+	if err != nil {
+		t.Fatalf("Proxy failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"server_name web.dev.test;",
+		"proxy_pass http://127.0.0.1:8080;",
+		"server_name admin-ui.dev.test;",
+		"proxy_pass http://127.0.0.1:3001;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "server {") != 2 {
+		t.Errorf("Expected 2 server blocks, got:\n%s", out)
+	}
+}
+
+func TestProxy_UnknownKind(t *testing.T) {
+	if _, err := Proxy(scanFixture(t), "traefik", "localhost"); err == nil {
+		t.Error("Expected an error for an unknown proxy")
+	}
+}
@@ -0,0 +1,67 @@
+// Package gitdiff finds the files changed since a commit so CI can focus a
+// scan on what a change touches
+package gitdiff
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepository is returned when the directory is not inside a git
+// work tree, or git is not installed
+var ErrNotRepository = errors.New("not a git repository")
+
+// runGit runs git in dir and returns its trimmed stdout
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ChangedFiles returns the absolute paths of files changed between since
+// and HEAD, as listed by git diff --name-only since...HEAD. Deleted files
+// are included; callers only match them against files that exist. since
+// must name a commit.
+func ChangedFiles(dir, since string) ([]string, error) {
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	top, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, ErrNotRepository
+	}
+
+	// Resolve since to a commit first, so a value such as --output=x is
+	// never read by git diff as an option
+	if strings.HasPrefix(since, "-") {
+		return nil, fmt.Errorf("invalid commit %q", since)
+	}
+	commit, err := runGit(dir, "rev-parse", "--verify", "--quiet", since+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown commit %q", since)
+	}
+
+	out, err := runGit(dir, "diff", "--name-only", commit+"...HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(out, "\n") {
+		if name != "" {
+			files = append(files, filepath.Join(top, filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,127 @@
+package gitdiff
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// git runs a git command in dir, failing the test on error
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGit(dir, args...)
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return out
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangedFiles_FocusesScan(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git(t, dir, "init", "-q")
+	git(t, dir, "config", "user.email", "test@example.com")
+	git(t, dir, "config", "user.name", "test")
+
+	writeFile(t, filepath.Join(dir, "shop", "docker-compose.yml"),
+		"services:\n  web:\n    image: nginx\n    ports:\n      - \"8080:80\"\n")
+	writeFile(t, filepath.Join(dir, "blog", "docker-compose.yml"),
+		"services:\n  db:\n    image: postgres\n    ports:\n      - \"5432:5432\"\n  cache:\n    image: redis\n    ports:\n      - \"5432:6379\"\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+	base := git(t, dir, "rev-parse", "HEAD")
+
+	// The change introduces a collision with the untouched shop project
+	writeFile(t, filepath.Join(dir, "api", "docker-compose.yml"),
+		"services:\n  api:\n    image: node\n    ports:\n      - \"8080:3000\"\n")
+	writeFile(t, filepath.Join(dir, "README.md"), "docs\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "add api")
+
+	changed, err := ChangedFiles(dir, base)
+	if err != nil {
+		t.Fatalf("ChangedFiles failed: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("Expected the compose file and README, got %v", changed)
+	}
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(result.FilterByType("collision")) != 2 {
+		t.Fatalf("Expected collisions on 8080 and 5432 in the full scan, got %+v", result.Issues)
+	}
+
+	focused := result.IssuesAffecting(changed)
+	var collisions []scanner.Issue
+	for _, issue := range focused {
+		if issue.Type == "collision" {
+			collisions = append(collisions, issue)
+		}
+	}
+	if len(collisions) != 1 || collisions[0].Port != 8080 || len(collisions[0].Bindings) != 2 {
+		t.Errorf("Expected only the 8080 collision with the sibling file, got %+v", focused)
+	}
+}
+
+func TestChangedFiles_RejectsNonCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git(t, dir, "init", "-q")
+	git(t, dir, "config", "user.email", "test@example.com")
+	git(t, dir, "config", "user.name", "test")
+	writeFile(t, filepath.Join(dir, "docker-compose.yml"), "services: {}\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+
+	output := filepath.Join(dir, "out.txt")
+	for _, since := range []string{"--output=" + output, "no-such-branch", "HEAD:docker-compose.yml"} {
+		if _, err := ChangedFiles(dir, since); err == nil {
+			t.Errorf("ChangedFiles(%q) should fail", since)
+		}
+	}
+	if _, err := os.Stat(output); err == nil {
+		t.Error("An option passed as the commit must not reach git")
+	}
+}
+
+func TestChangedFiles_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	// Keep git from finding a repository above the temp dir
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	_, err := ChangedFiles(dir, "HEAD~1")
+	if !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Expected ErrNotRepository, got %v", err)
+	}
+}
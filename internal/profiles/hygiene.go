@@ -0,0 +1,121 @@
+package profiles
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HygieneIssue describes an inconsistency in how services use profiles
+type HygieneIssue struct {
+	Service string
+	File    string
+	Message string
+}
+
+// CheckHygiene flags services without profiles in files where other
+// services declare them, and services whose profiles are never activated by
+// any of the documented combinations. A nil combinations slice skips the
+// second check.
+func (c *ProfilesConfig) CheckHygiene(combinations [][]string) []HygieneIssue {
+	var issues []HygieneIssue
+
+	// Which files use profiles at all
+	profiledFiles := make(map[string]bool)
+	for name, profile := range c.Profiles {
+		if name == "default" {
+			continue
+		}
+		for _, svc := range profile.Services {
+			profiledFiles[svc.File] = true
+		}
+	}
+
+	if def, ok := c.Profiles["default"]; ok {
+		for _, svc := range def.Services {
+			if profiledFiles[svc.File] {
+				issues = append(issues, HygieneIssue{
+					Service: svc.Name,
+					File:    svc.File,
+					Message: fmt.Sprintf("Service %s in %s has no profiles while other services in the file do",
+						svc.Name, filepath.Base(svc.File)),
+				})
+			}
+		}
+	}
+
+	if combinations != nil {
+		activated := make(map[string]bool)
+		for _, combo := range combinations {
+			for _, name := range combo {
+				activated[name] = true
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, name := range c.ListProfiles() {
+			if name == "default" || activated[name] {
+				continue
+			}
+			for _, svc := range c.Profiles[name].Services {
+				key := svc.File + "|" + svc.Name + "|" + name
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				issues = append(issues, HygieneIssue{
+					Service: svc.Name,
+					File:    svc.File,
+					Message: fmt.Sprintf("Service %s uses profile %q, which no documented profile combination activates",
+						svc.Name, name),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Service < issues[j].Service
+	})
+	return issues
+}
+
+// DocumentedCombinations returns the profile combinations declared through
+// COMPOSE_PROFILES in the environment and in basePath's .env file
+func DocumentedCombinations(basePath string) [][]string {
+	var combos [][]string
+
+	if v := os.Getenv("COMPOSE_PROFILES"); v != "" {
+		combos = append(combos, splitProfiles(v))
+	}
+
+	f, err := os.Open(filepath.Join(basePath, ".env"))
+	if err != nil {
+		return combos
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, "COMPOSE_PROFILES="); ok {
+			combos = append(combos, splitProfiles(strings.Trim(value, `"'`)))
+		}
+	}
+	return combos
+}
+
+func splitProfiles(v string) []string {
+	var names []string
+	for _, name := range strings.Split(v, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -2,11 +2,15 @@
 package profiles
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/stackgen-cli/portcheck/internal/scanner"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,16 +22,20 @@ type ProfilesConfig struct {
 
 // Profile represents a compose profile and its services
 type Profile struct {
-	Name     string
-	Services []ProfileService
+	Name     string           `json:"name"`
+	Services []ProfileService `json:"services"`
 }
 
 // ProfileService represents a service in a profile
 type ProfileService struct {
-	Name     string
-	Ports    []string
-	EnvFiles []string
-	File     string
+	Name     string   `json:"name"`
+	Ports    []string `json:"ports"`
+	EnvFiles []string `json:"env_files,omitempty"`
+	File     string   `json:"file"`
+
+	// entries holds the raw compose entry behind each of Ports, so
+	// long-syntax protocol and host_ip reach the scanner's parser
+	entries []interface{}
 }
 
 // composeWithProfiles is for parsing compose files with profiles
@@ -81,19 +89,21 @@ func parseComposeProfiles(path string, config *ProfilesConfig) error {
 	}
 
 	for serviceName, svc := range compose.Services {
-		// Collect ports as strings
+		// Collect ports as strings, keeping the raw entries for parsing
 		var ports []string
+		var entries []interface{}
 		for _, p := range svc.Ports {
 			switch v := p.(type) {
 			case string:
 				ports = append(ports, v)
+				entries = append(entries, p)
 			case int:
 				ports = append(ports, fmt.Sprintf("%d", v))
+				entries = append(entries, p)
 			case map[string]interface{}:
-				if pub, ok := v["published"]; ok {
-					if cont, ok := v["target"]; ok {
-						ports = append(ports, fmt.Sprintf("%v:%v", pub, cont))
-					}
+				if spec, ok := longSyntaxSpec(v); ok {
+					ports = append(ports, spec)
+					entries = append(entries, p)
 				}
 			}
 		}
@@ -116,6 +126,7 @@ func parseComposeProfiles(path string, config *ProfilesConfig) error {
 			Ports:    ports,
 			EnvFiles: envFiles,
 			File:     path,
+			entries:  entries,
 		}
 
 		// Add to appropriate profiles
@@ -135,6 +146,31 @@ func parseComposeProfiles(path string, config *ProfilesConfig) error {
 	return nil
 }
 
+// longSyntaxSpec renders a long-syntax ports entry in short syntax for
+// display, e.g. "127.0.0.1:53:53/udp". Entries without both published and
+// target are not reported.
+func longSyntaxSpec(entry map[string]interface{}) (string, bool) {
+	pub, ok := entry["published"]
+	if !ok {
+		return "", false
+	}
+	cont, ok := entry["target"]
+	if !ok {
+		return "", false
+	}
+	spec := fmt.Sprintf("%v:%v", pub, cont)
+	if ip, ok := entry["host_ip"].(string); ok && ip != "" {
+		if strings.Contains(ip, ":") && !strings.HasPrefix(ip, "[") {
+			ip = "[" + ip + "]"
+		}
+		spec = ip + ":" + spec
+	}
+	if proto, ok := entry["protocol"].(string); ok && proto != "" {
+		spec += "/" + proto
+	}
+	return spec, true
+}
+
 // GetActivePorts returns all ports that would be active for given profiles
 func (c *ProfilesConfig) GetActivePorts(activeProfiles []string) []string {
 	var ports []string
@@ -163,23 +199,35 @@ func (c *ProfilesConfig) GetActivePorts(activeProfiles []string) []string {
 func (c *ProfilesConfig) DetectPortConflicts(activeProfiles []string) []PortConflict {
 	var conflicts []PortConflict
 
-	// Track port -> services mapping
-	portServices := make(map[string][]ServiceInfo)
+	// Track port -> services mapping, with the host IP each binds
+	portServices := make(map[hostPort][]ServiceInfo)
+	hostIPs := make(map[hostPort][]string)
+	// A service in several active profiles must not collide with itself
+	seen := make(map[string]bool)
 
 	profiles := append([]string{"default"}, activeProfiles...)
 
 	for _, profileName := range profiles {
 		if profile, exists := c.Profiles[profileName]; exists {
 			for _, svc := range profile.Services {
-				for _, port := range svc.Ports {
-					// Extract host port
-					hostPort := extractHostPort(port)
-					if hostPort != "" {
+				for i, port := range svc.Ports {
+					key := svc.File + "|" + svc.Name + "|" + port
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					var entry interface{} = port
+					if i < len(svc.entries) {
+						entry = svc.entries[i]
+					}
+					for _, b := range publishedBindings(entry) {
+						hostPort := hostPort{b.HostPort, b.Protocol}
 						portServices[hostPort] = append(portServices[hostPort], ServiceInfo{
 							Service: svc.Name,
 							Profile: profileName,
 							Port:    port,
 						})
+						hostIPs[hostPort] = append(hostIPs[hostPort], b.HostIP)
 					}
 				}
 			}
@@ -187,51 +235,179 @@ func (c *ProfilesConfig) DetectPortConflicts(activeProfiles []string) []PortConf
 	}
 
 	// Find conflicts
-	for port, services := range portServices {
-		if len(services) > 1 {
+	for hostPort, services := range portServices {
+		if len(services) > 1 && hostIPsOverlap(hostIPs[hostPort]) {
 			conflicts = append(conflicts, PortConflict{
-				Port:     port,
+				Port:     strconv.Itoa(hostPort.port),
+				Protocol: hostPort.protocol,
 				Services: services,
 			})
 		}
 	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, _ := strconv.Atoi(conflicts[i].Port)
+		b, _ := strconv.Atoi(conflicts[j].Port)
+		if a != b {
+			return a < b
+		}
+		if conflicts[i].Protocol != conflicts[j].Protocol {
+			return conflicts[i].Protocol < conflicts[j].Protocol
+		}
+		return conflicts[i].Services[0].Port < conflicts[j].Services[0].Port
+	})
 
 	return conflicts
 }
 
+// AllProfilesConflicts returns the port conflicts that appear when every
+// discovered profile is active at once, as some tooling does, but not
+// under activeProfiles alone. These are the worst-case collisions between
+// services meant to be mutually exclusive.
+func (c *ProfilesConfig) AllProfilesConflicts(activeProfiles []string) []PortConflict {
+	existing := make(map[string]bool)
+	for _, conflict := range c.DetectPortConflicts(activeProfiles) {
+		existing[conflict.key()] = true
+	}
+
+	var conflicts []PortConflict
+	for _, conflict := range c.DetectPortConflicts(c.ListProfiles()) {
+		if !existing[conflict.key()] {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// ProfileIncompatibility lists the host ports two profiles would both bind
+// if they were enabled together
+type ProfileIncompatibility struct {
+	ProfileA string   `json:"profile_a"`
+	ProfileB string   `json:"profile_b"`
+	Ports    []string `json:"ports"`
+}
+
+// DetectProfileIncompatibilities returns every pair of profiles that
+// collide when both are active with the default profile. Ports that
+// already collide with either profile alone are not attributed to the
+// pair, and a service listed in both profiles never conflicts with itself.
+func (c *ProfilesConfig) DetectProfileIncompatibilities() []ProfileIncompatibility {
+	var names []string
+	for _, name := range c.ListProfiles() {
+		if name != "default" {
+			names = append(names, name)
+		}
+	}
+
+	alone := make(map[string]map[string]bool, len(names))
+	for _, name := range names {
+		alone[name] = make(map[string]bool)
+		for _, conflict := range c.DetectPortConflicts([]string{name}) {
+			alone[name][conflict.key()] = true
+		}
+	}
+
+	var incompatibilities []ProfileIncompatibility
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			var ports []string
+			for _, conflict := range c.DetectPortConflicts([]string{a, b}) {
+				if !alone[a][conflict.key()] && !alone[b][conflict.key()] {
+					ports = append(ports, conflict.Port)
+				}
+			}
+			if len(ports) > 0 {
+				incompatibilities = append(incompatibilities, ProfileIncompatibility{
+					ProfileA: a,
+					ProfileB: b,
+					Ports:    ports,
+				})
+			}
+		}
+	}
+	return incompatibilities
+}
+
 // ServiceInfo holds info about a service using a port
 type ServiceInfo struct {
-	Service string
-	Profile string
-	Port    string
+	Service string `json:"service"`
+	Profile string `json:"profile"`
+	Port    string `json:"port"`
 }
 
 // PortConflict represents a port conflict between services
 type PortConflict struct {
-	Port     string
-	Services []ServiceInfo
+	Port     string        `json:"port"`
+	Protocol string        `json:"protocol"`
+	Services []ServiceInfo `json:"services"`
+}
+
+// key identifies the host port a conflict is on; tcp and udp conflicts on
+// the same number are distinct
+func (pc PortConflict) key() string {
+	return pc.Port + "/" + pc.Protocol
+}
+
+// hostPort is a published host port; tcp and udp on the same number do
+// not conflict
+type hostPort struct {
+	port     int
+	protocol string
+}
+
+// extractHostPorts returns the host ports a ports entry publishes, parsed
+// as the scanner parses them: "127.0.0.1:8080:80" publishes 8080/tcp and
+// "8000-8002:8000-8002" publishes each port of the range. Invalid entries,
+// and bare container ports published on a random host port, publish
+// nothing.
+func extractHostPorts(entry interface{}) []hostPort {
+	var ports []hostPort
+	for _, b := range publishedBindings(entry) {
+		ports = append(ports, hostPort{b.HostPort, b.Protocol})
+	}
+	return ports
 }
 
-func extractHostPort(portSpec string) string {
-	// Handle formats like "8080:80", "127.0.0.1:8080:80", "8080"
-	parts := strings.Split(portSpec, ":")
-	switch len(parts) {
-	case 1:
-		return strings.Split(parts[0], "/")[0] // Remove /tcp, /udp
-	case 2:
-		return strings.Split(parts[0], "/")[0]
-	case 3:
-		return strings.Split(parts[1], "/")[0]
+// publishedBindings returns the bindings of a ports entry, short or long
+// syntax, that claim a fixed host port
+func publishedBindings(entry interface{}) []scanner.PortBinding {
+	bindings, _ := scanner.ParseEntry(entry, "", "")
+	var published []scanner.PortBinding
+	for _, b := range bindings {
+		if !b.RandomHostPort {
+			published = append(published, b)
+		}
 	}
-	return portSpec
+	return published
 }
 
-// ListProfiles returns all available profile names
+// hostIPsOverlap reports whether any two of the host IPs bound to one
+// port collide, as the scanner decides it: a wildcard collides with
+// everything, and distinct specific IPs do not collide
+func hostIPsOverlap(ips []string) bool {
+	if len(ips) < 2 {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, ip := range ips {
+		switch ip = scanner.NormalizeHostIP(ip); ip {
+		case "", "::", "[::]":
+			return true
+		}
+		if seen[ip] {
+			return true
+		}
+		seen[ip] = true
+	}
+	return false
+}
+
+// ListProfiles returns all available profile names, sorted
 func (c *ProfilesConfig) ListProfiles() []string {
 	var names []string
 	for name := range c.Profiles {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
@@ -241,7 +417,8 @@ func FormatProfiles(config *ProfilesConfig) string {
 
 	sb.WriteString("# Compose Profiles\n\n")
 
-	for name, profile := range config.Profiles {
+	for _, name := range config.ListProfiles() {
+		profile := config.Profiles[name]
 		sb.WriteString(fmt.Sprintf("## Profile: %s\n", name))
 		if len(profile.Services) == 0 {
 			sb.WriteString("  (no services)\n")
@@ -259,3 +436,52 @@ func FormatProfiles(config *ProfilesConfig) string {
 
 	return sb.String()
 }
+
+// FormatConflicts formats the port conflicts of a set of active profiles as
+// text, one port per line followed by the services that claim it
+func FormatConflicts(activeProfiles []string, conflicts []PortConflict) string {
+	var sb strings.Builder
+
+	active := append([]string{"default"}, activeProfiles...)
+	sb.WriteString(fmt.Sprintf("# Port Conflicts (%s)\n\n", strings.Join(active, ", ")))
+	if len(conflicts) == 0 {
+		sb.WriteString("No port conflicts\n")
+		return sb.String()
+	}
+
+	for _, conflict := range conflicts {
+		sb.WriteString(fmt.Sprintf("## Port %s/%s\n", conflict.Port, conflict.Protocol))
+		for _, svc := range conflict.Services {
+			sb.WriteString(fmt.Sprintf("  - **%s** (profile %s) [%s]\n", svc.Service, svc.Profile, svc.Port))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// FormatProfilesJSON formats the profiles, sorted by name, and the compose
+// files they were read from as JSON. Conflicts are included when non-nil,
+// so a check that found none should pass an empty slice.
+func FormatProfilesJSON(config *ProfilesConfig, conflicts []PortConflict) (string, error) {
+	output := struct {
+		Profiles  []*Profile      `json:"profiles"`
+		Files     []string        `json:"files"`
+		Conflicts *[]PortConflict `json:"conflicts,omitempty"`
+	}{Profiles: []*Profile{}, Files: config.Files}
+	for _, name := range config.ListProfiles() {
+		output.Profiles = append(output.Profiles, config.Profiles[name])
+	}
+	if output.Files == nil {
+		output.Files = []string{}
+	}
+	if conflicts != nil {
+		output.Conflicts = &conflicts
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
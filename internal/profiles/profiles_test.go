@@ -0,0 +1,307 @@
+package profiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func loadCompose(t *testing.T, compose string) (string, *ProfilesConfig) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadProfiles(dir)
+	if err != nil {
+		t.Fatalf("LoadProfiles failed: %v", err)
+	}
+	return dir, config
+}
+
+const mixedProfiles = `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  debug:
+    image: debug
+    profiles: ["debug"]
+  tools:
+    image: tools
+    profiles: ["tools"]
+`
+
+func TestCheckHygiene_MixedProfiles(t *testing.T) {
+	_, config := loadCompose(t, mixedProfiles)
+
+	issues := config.CheckHygiene(nil)
+	if len(issues) != 1 || issues[0].Service != "web" {
+		t.Fatalf("Expected one issue for unprofiled web, got %+v", issues)
+	}
+	if !strings.Contains(issues[0].Message, "has no profiles while other services in the file do") {
+		t.Errorf("Unexpected message %q", issues[0].Message)
+	}
+}
+
+func TestCheckHygiene_UnactivatedProfiles(t *testing.T) {
+	_, config := loadCompose(t, mixedProfiles)
+
+	issues := config.CheckHygiene([][]string{{"debug"}})
+
+	var unactivated []string
+	for _, issue := range issues {
+		if strings.Contains(issue.Message, "no documented profile combination activates") {
+			unactivated = append(unactivated, issue.Service)
+		}
+	}
+	if len(unactivated) != 1 || unactivated[0] != "tools" {
+		t.Errorf("Expected only tools to be unactivated, got %v", unactivated)
+	}
+}
+
+func TestCheckHygiene_ConsistentFile(t *testing.T) {
+	_, config := loadCompose(t, `services:
+  web:
+    image: nginx
+  db:
+    image: postgres
+`)
+
+	if issues := config.CheckHygiene(nil); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", issues)
+	}
+}
+
+func TestDocumentedCombinations(t *testing.T) {
+	t.Setenv("COMPOSE_PROFILES", "dev, tools")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("FOO=1\nCOMPOSE_PROFILES=\"debug\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	combos := DocumentedCombinations(dir)
+	if len(combos) != 2 || strings.Join(combos[0], ",") != "dev,tools" || strings.Join(combos[1], ",") != "debug" {
+		t.Errorf("DocumentedCombinations = %v", combos)
+	}
+}
+
+func TestAllProfilesConflicts_ExclusiveProfiles(t *testing.T) {
+	_, config := loadCompose(t, `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  mock-api:
+    image: wiremock
+    profiles: ["mock", "offline"]
+    ports:
+      - "3000:8080"
+  api:
+    image: node
+    profiles: ["live"]
+    ports:
+      - "3000:3000"
+`)
+
+	if conflicts := config.DetectPortConflicts([]string{"mock", "offline"}); len(conflicts) != 0 {
+		t.Errorf("Expected no conflict when one side is active, got %+v", conflicts)
+	}
+
+	conflicts := config.AllProfilesConflicts(nil)
+	if len(conflicts) != 1 || conflicts[0].Port != "3000" {
+		t.Fatalf("Expected one conflict on 3000 under all profiles, got %+v", conflicts)
+	}
+	if len(conflicts[0].Services) != 2 {
+		t.Errorf("Expected api and mock-api once each, got %+v", conflicts[0].Services)
+	}
+}
+
+func TestFormatProfilesJSON(t *testing.T) {
+	_, config := loadCompose(t, `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  debug:
+    image: debug
+    profiles: ["debug"]
+    env_file: debug.env
+    ports:
+      - "8080:9000"
+`)
+
+	conflicts := config.DetectPortConflicts([]string{"debug"})
+	output, err := FormatProfilesJSON(config, conflicts)
+	if err != nil {
+		t.Fatalf("FormatProfilesJSON failed: %v", err)
+	}
+
+	var parsed struct {
+		Profiles []struct {
+			Name     string `json:"name"`
+			Services []struct {
+				Name     string   `json:"name"`
+				EnvFiles []string `json:"env_files"`
+			} `json:"services"`
+		} `json:"profiles"`
+		Conflicts []PortConflict `json:"conflicts"`
+	}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, output)
+	}
+	if len(parsed.Profiles) != 2 || parsed.Profiles[0].Name != "debug" || parsed.Profiles[1].Name != "default" {
+		t.Errorf("Expected profiles sorted by name, got %+v", parsed.Profiles)
+	}
+	if svc := parsed.Profiles[0].Services; len(svc) != 1 || len(svc[0].EnvFiles) != 1 {
+		t.Errorf("Expected the debug service with its env file, got %+v", svc)
+	}
+	if len(parsed.Conflicts) != 1 || parsed.Conflicts[0].Port != "8080" {
+		t.Errorf("Expected the 8080 conflict, got %+v", parsed.Conflicts)
+	}
+
+	unchecked, err := FormatProfilesJSON(config, nil)
+	if err != nil {
+		t.Fatalf("FormatProfilesJSON failed: %v", err)
+	}
+	if strings.Contains(unchecked, `"conflicts"`) {
+		t.Errorf("Conflicts should be omitted when not checked:\n%s", unchecked)
+	}
+}
+
+func TestExtractHostPorts(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []hostPort
+	}{
+		{"127.0.0.1:8080:80", []hostPort{{8080, "tcp"}}},
+		{"8000-8002:8000-8002", []hostPort{{8000, "tcp"}, {8001, "tcp"}, {8002, "tcp"}}},
+		{"127.0.0.1:53:53/udp", []hostPort{{53, "udp"}}},
+		{"53/udp", nil}, // a random host port can't conflict
+		{"not-a-port", nil},
+	}
+
+	for _, tt := range tests {
+		got := extractHostPorts(tt.spec)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("extractHostPorts(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestDetectPortConflicts_ProtocolsAndRanges(t *testing.T) {
+	_, config := loadCompose(t, `services:
+  dns:
+    image: dns
+    ports:
+      - "53:53/udp"
+  web:
+    image: nginx
+    ports:
+      - "53:8053"
+      - "127.0.0.1:8001:80"
+  workers:
+    image: worker
+    profiles: ["batch"]
+    ports:
+      - "8000-8002:8000-8002"
+`)
+
+	conflicts := config.DetectPortConflicts([]string{"batch"})
+	if len(conflicts) != 1 || conflicts[0].Port != "8001" {
+		t.Errorf("Expected only the range to collide on 8001, not tcp with udp on 53, got %+v", conflicts)
+	}
+}
+
+func TestDetectPortConflicts_LongSyntax(t *testing.T) {
+	_, config := loadCompose(t, `services:
+  dns:
+    image: dns
+    ports:
+      - target: 53
+        published: 53
+        protocol: udp
+  web:
+    image: nginx
+    ports:
+      - "53:8053"
+      - target: 80
+        published: 8080
+        host_ip: 127.0.0.1
+  admin:
+    image: admin
+    ports:
+      - "127.0.0.2:8080:80"
+  syslog:
+    image: syslog
+    profiles: ["logs"]
+    ports:
+      - "53:53/udp"
+  proxy:
+    image: proxy
+    profiles: ["logs"]
+    ports:
+      - "53:53"
+`)
+
+	if conflicts := config.DetectPortConflicts(nil); len(conflicts) != 0 {
+		t.Errorf("Expected udp against tcp on 53 and distinct host IPs on 8080 not to conflict, got %+v", conflicts)
+	}
+
+	conflicts := config.AllProfilesConflicts(nil)
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected separate tcp and udp conflicts on 53, got %+v", conflicts)
+	}
+	for i, want := range []string{"53/tcp", "53/udp"} {
+		if got := conflicts[i].key(); got != want {
+			t.Errorf("conflicts[%d] on %s, want %s", i, got, want)
+		}
+	}
+	var udp []string
+	for _, svc := range conflicts[1].Services {
+		udp = append(udp, svc.Service+" "+svc.Port)
+	}
+	sort.Strings(udp)
+	if want := []string{"dns 53:53/udp", "syslog 53:53/udp"}; fmt.Sprint(udp) != fmt.Sprint(want) {
+		t.Errorf("Expected %v on 53/udp, got %v", want, udp)
+	}
+}
+
+func TestDetectProfileIncompatibilities(t *testing.T) {
+	_, config := loadCompose(t, `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  mock-api:
+    image: wiremock
+    profiles: ["mock", "offline"]
+    ports:
+      - "3000:8080"
+  api:
+    image: node
+    profiles: ["live"]
+    ports:
+      - "3000:3000"
+  debug:
+    image: debug
+    profiles: ["debug"]
+    ports:
+      - "8080:9000"
+`)
+
+	got := config.DetectProfileIncompatibilities()
+	want := []ProfileIncompatibility{
+		{ProfileA: "live", ProfileB: "mock", Ports: []string{"3000"}},
+		{ProfileA: "live", ProfileB: "offline", Ports: []string{"3000"}},
+	}
+	// mock and offline share mock-api, and debug collides with default on
+	// its own, so neither makes a pair incompatible
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("DetectProfileIncompatibilities() = %+v, want %+v", got, want)
+	}
+}
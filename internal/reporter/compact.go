@@ -0,0 +1,108 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// CompactMode selects how little of a scan result a compact report shows
+type CompactMode int
+
+const (
+	// CompactQuiet shows only the issue counts by severity
+	CompactQuiet CompactMode = iota
+	// CompactSummary adds the colliding host ports, but no bindings
+	CompactSummary
+)
+
+// compactFormats holds the formats with a compact mode
+var compactFormats = map[string]bool{"text": true, "json": true, "markdown": true}
+
+// HasCompactMode reports whether format can be rendered by FormatCompact
+func HasCompactMode(format string) bool {
+	return compactFormats[format]
+}
+
+// FormatCompact renders the verdict of a scan in the text, json or
+// markdown format, e.g. "portcheck: 2 errors, 3 warnings, 1 info"
+func FormatCompact(format string, r *scanner.Result, mode CompactMode) (string, error) {
+	s := r.Summary()
+	counts := fmt.Sprintf("%s, %s, %d info",
+		plural(s.Errors, "error", "errors"), plural(s.Warnings, "warning", "warnings"), s.Info)
+
+	var ports []int
+	if mode == CompactSummary {
+		ports = collidingPorts(r)
+	}
+	portList := func(quote string) string {
+		if len(ports) == 0 {
+			return "none"
+		}
+		names := make([]string, len(ports))
+		for i, port := range ports {
+			names[i] = fmt.Sprintf("%s%d%s", quote, port, quote)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	switch format {
+	case "text":
+		out := "portcheck: " + counts
+		if mode == CompactSummary {
+			out += "\nColliding ports: " + portList("")
+		}
+		return out, nil
+
+	case "markdown":
+		if mode == CompactQuiet {
+			return "**portcheck:** " + counts, nil
+		}
+		var sb strings.Builder
+		sb.WriteString("## Port Check Summary\n\n")
+		sb.WriteString("| Severity | Count |\n")
+		sb.WriteString("|----------|-------|\n")
+		sb.WriteString(fmt.Sprintf("| Errors | %d |\n", s.Errors))
+		sb.WriteString(fmt.Sprintf("| Warnings | %d |\n", s.Warnings))
+		sb.WriteString(fmt.Sprintf("| Info | %d |\n", s.Info))
+		sb.WriteString("\n**Colliding ports:** " + portList("`"))
+		return sb.String(), nil
+
+	case "json":
+		output := map[string]interface{}{
+			"schema_version": JSONSchemaVersion,
+			"errors":         s.Errors,
+			"warnings":       s.Warnings,
+			"info":           s.Info,
+		}
+		if mode == CompactSummary {
+			if ports == nil {
+				ports = []int{}
+			}
+			output["colliding_ports"] = ports
+		}
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("format %q has no compact mode", format)
+}
+
+// collidingPorts returns the sorted host ports with a collision issue
+func collidingPorts(r *scanner.Result) []int {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, issue := range r.FilterByType("collision", "container_name_port_collision") {
+		if !seen[issue.Port] {
+			seen[issue.Port] = true
+			ports = append(ports, issue.Port)
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
@@ -0,0 +1,51 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// csvHeader names the columns of FormatCSV
+var csvHeader = []string{"host_port", "container_port", "protocol", "host_ip", "service", "file", "has_issue"}
+
+// FormatCSV generates one row per effective port binding, for port
+// inventories. Bindings on all interfaces have an empty host_ip, as in
+// compose files that leave the address out; has_issue tells whether any
+// reported issue involves the binding.
+func FormatCSV(r *scanner.Result) (string, error) {
+	involved := make(map[scanner.PortBinding]bool)
+	for _, issue := range r.Issues {
+		for _, b := range issue.Bindings {
+			involved[b] = true
+		}
+	}
+
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	if err := w.Write(csvHeader); err != nil {
+		return "", err
+	}
+	for _, b := range scanner.SortBindings(r.PortBindings) {
+		hostIP := b.HostIP
+		if hostIP == "0.0.0.0" {
+			hostIP = ""
+		}
+		row := []string{
+			hostPortLabel(b),
+			strconv.Itoa(b.ContainerPort),
+			b.Protocol,
+			hostIP,
+			b.Service,
+			b.File,
+			strconv.FormatBool(involved[b]),
+		}
+		if err := w.Write(row); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return sb.String(), w.Error()
+}
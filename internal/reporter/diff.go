@@ -0,0 +1,87 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/portcheck/internal/baseline"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// FormatDiff lists the added and removed issues of a diff, followed by a
+// count of the unchanged ones
+func FormatDiff(d baseline.Diff) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Added: %d, removed: %d, unchanged: %d\n",
+		len(d.Added), len(d.Removed), len(d.Unchanged)))
+
+	write := func(title, marker string, paint func(format string, a ...interface{}) string, issues []scanner.Issue) {
+		if len(issues) == 0 {
+			return
+		}
+		sb.WriteString("\n" + title + "\n")
+		for _, issue := range issues {
+			sb.WriteString(paint("%s [%s] %s port %d: %s", marker, issue.Severity, issue.Type, issue.Port, issue.Description))
+			sb.WriteString("\n")
+		}
+	}
+	write("Added issues:", "+", color.RedString, d.Added)
+	write("Removed issues:", "-", color.GreenString, d.Removed)
+
+	return sb.String()
+}
+
+// FormatDiffJSON renders a diff as JSON, each issue with its comparison
+// key of type, port and services
+func FormatDiffJSON(d baseline.Diff) (string, error) {
+	type diffIssue struct {
+		ID          string   `json:"id"`
+		Severity    string   `json:"severity"`
+		Type        string   `json:"type"`
+		Port        int      `json:"port"`
+		Services    []string `json:"services"`
+		Description string   `json:"description"`
+	}
+	type diffOutput struct {
+		Summary struct {
+			Added     int `json:"added"`
+			Removed   int `json:"removed"`
+			Unchanged int `json:"unchanged"`
+		} `json:"summary"`
+		Added     []diffIssue `json:"added"`
+		Removed   []diffIssue `json:"removed"`
+		Unchanged []diffIssue `json:"unchanged"`
+	}
+
+	toJSON := func(issues []scanner.Issue) []diffIssue {
+		out := []diffIssue{}
+		for _, issue := range issues {
+			out = append(out, diffIssue{
+				ID:          issue.ID,
+				Severity:    issue.Severity,
+				Type:        issue.Type,
+				Port:        issue.Port,
+				Services:    baseline.EntryFor(issue).Services,
+				Description: issue.Description,
+			})
+		}
+		return out
+	}
+
+	var out diffOutput
+	out.Summary.Added = len(d.Added)
+	out.Summary.Removed = len(d.Removed)
+	out.Summary.Unchanged = len(d.Unchanged)
+	out.Added = toJSON(d.Added)
+	out.Removed = toJSON(d.Removed)
+	out.Unchanged = toJSON(d.Unchanged)
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
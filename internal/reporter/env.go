@@ -0,0 +1,64 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// FormatEnv generates `export WEB_PORT=8081` lines from port suggestions so
+// a stack can be started on free ports with eval. For each collision the
+// first binding keeps its port and the next one receives the suggestion.
+// A service moved off several ports gets one variable per port, such as
+// WEB_8080_PORT. Services without a suggestion are left out.
+func FormatEnv(r *scanner.Result, suggestions map[int]int) string {
+	moved := make(map[string][][2]int) // variable stem -> (old, new) ports
+	seen := make(map[int]bool)
+	for _, issue := range r.FilterByType("collision", "container_name_port_collision") {
+		to, ok := suggestions[issue.Port]
+		if !ok || seen[issue.Port] || len(issue.Bindings) < 2 {
+			continue
+		}
+		seen[issue.Port] = true
+		stem := envName(issue.Bindings[1].Service)
+		moved[stem] = append(moved[stem], [2]int{issue.Port, to})
+	}
+
+	var lines []string
+	for stem, ports := range moved {
+		if len(ports) == 1 {
+			lines = append(lines, fmt.Sprintf("export %s_PORT=%d", stem, ports[0][1]))
+			continue
+		}
+		for _, p := range ports {
+			lines = append(lines, fmt.Sprintf("export %s_%d_PORT=%d", stem, p[0], p[1]))
+		}
+	}
+	sort.Strings(lines)
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// envName turns a service name into a shell identifier: upper case, with
+// every other character replaced by an underscore and a leading digit
+// prefixed by one
+func envName(service string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToUpper(service) {
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	name := sb.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
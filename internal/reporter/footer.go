@@ -0,0 +1,35 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// FooterPrefix starts the machine-parseable footer line
+const FooterPrefix = "PORTCHECK_RESULT"
+
+// FormatFooter generates a single line scripts can grep from any output
+// format, e.g. "PORTCHECK_RESULT errors=2 warnings=3 info=1 bindings=8 files=4"
+func FormatFooter(r *scanner.Result) string {
+	s := r.Summary()
+	return fmt.Sprintf("%s errors=%d warnings=%d info=%d bindings=%d files=%d",
+		FooterPrefix, s.Errors, s.Warnings, s.Info, s.Bindings, s.Files)
+}
+
+// ParseFooter finds the footer line in output and returns its counts. The
+// line may be commented out with "# ", as it is after env output.
+func ParseFooter(output string) (scanner.Summary, bool) {
+	var s scanner.Summary
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimPrefix(line, "# ")
+		if !strings.HasPrefix(line, FooterPrefix+" ") {
+			continue
+		}
+		_, err := fmt.Sscanf(line, FooterPrefix+" errors=%d warnings=%d info=%d bindings=%d files=%d",
+			&s.Errors, &s.Warnings, &s.Info, &s.Bindings, &s.Files)
+		return s, err == nil
+	}
+	return s, false
+}
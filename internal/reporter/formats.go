@@ -0,0 +1,80 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stackgen-cli/portcheck/internal/runtime"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// JSONSchemaVersion is the version of the JSON output structure. It
+// changes only when fields are removed or change meaning.
+const JSONSchemaVersion = 1
+
+// RenderOptions carries the settings of formats that have any. Each
+// format reads only its own.
+type RenderOptions struct {
+	Suggestions map[int]int // printed by the env and json formats
+	JUnit       JUnitOptions
+
+	// Runtime and RuntimeChanges are included by the json format when set
+	Runtime        *runtime.RuntimeResult
+	RuntimeChanges []runtime.PortChange
+}
+
+// Renderer renders a scan result in one output format
+type Renderer func(r *scanner.Result, opts RenderOptions) (string, error)
+
+// renderers holds every scan output format
+var renderers = map[string]Renderer{
+	"text": func(r *scanner.Result, _ RenderOptions) (string, error) {
+		return FormatText(r)
+	},
+	"json": func(r *scanner.Result, opts RenderOptions) (string, error) {
+		return FormatJSONWithOptions(r, opts)
+	},
+	"markdown": func(r *scanner.Result, _ RenderOptions) (string, error) {
+		return FormatMarkdown(r)
+	},
+	"env": func(r *scanner.Result, opts RenderOptions) (string, error) {
+		return FormatEnv(r, opts.Suggestions), nil
+	},
+	"junit": func(r *scanner.Result, opts RenderOptions) (string, error) {
+		return FormatJUnitWithOptions(r, opts.JUnit)
+	},
+	"sarif": func(r *scanner.Result, _ RenderOptions) (string, error) {
+		return FormatSARIF(r)
+	},
+	"csv": func(r *scanner.Result, _ RenderOptions) (string, error) {
+		return FormatCSV(r)
+	},
+	"html": func(r *scanner.Result, _ RenderOptions) (string, error) {
+		return FormatHTML(r)
+	},
+}
+
+// Formats returns the sorted names of the scan output formats
+func Formats() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsFormat reports whether format is a scan output format
+func IsFormat(format string) bool {
+	_, ok := renderers[format]
+	return ok
+}
+
+// Render renders a scan result in the named format
+func Render(format string, r *scanner.Result, opts RenderOptions) (string, error) {
+	render, ok := renderers[format]
+	if !ok {
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+	return render(r, opts)
+}
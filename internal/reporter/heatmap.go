@@ -0,0 +1,76 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+const (
+	heatmapBlock = 100 // ports per rendered range
+	heatmapRow   = 10  // ports per table row
+
+	cellFree     = "⬜"
+	cellUsed     = "🟩"
+	cellConflict = "🟥"
+)
+
+// FormatHeatmap renders a markdown occupancy map of every 100-port range
+// that contains at least one host binding
+func FormatHeatmap(r *scanner.Result) string {
+	conflicts := make(map[int]bool)
+	for _, issue := range r.FilterByType("collision", "container_name_port_collision", "potential_collision") {
+		conflicts[issue.Port] = true
+	}
+
+	var blocks []int
+	seen := make(map[int]bool)
+	for port := range r.PortMap {
+		if port <= 0 {
+			continue
+		}
+		start := port / heatmapBlock * heatmapBlock
+		if !seen[start] {
+			seen[start] = true
+			blocks = append(blocks, start)
+		}
+	}
+	sort.Ints(blocks)
+
+	var sb strings.Builder
+	sb.WriteString("## Port Heatmap\n\n")
+	if len(blocks) == 0 {
+		sb.WriteString("No host ports are bound.\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("%s free · %s used · %s conflict\n\n", cellFree, cellUsed, cellConflict))
+
+	for _, start := range blocks {
+		sb.WriteString(fmt.Sprintf("**%d–%d**\n\n", start, start+heatmapBlock-1))
+
+		sb.WriteString("| |")
+		for i := 0; i < heatmapRow; i++ {
+			sb.WriteString(fmt.Sprintf(" %d |", i))
+		}
+		sb.WriteString("\n|---|" + strings.Repeat("---|", heatmapRow) + "\n")
+
+		for row := start; row < start+heatmapBlock; row += heatmapRow {
+			sb.WriteString(fmt.Sprintf("| %d |", row))
+			for port := row; port < row+heatmapRow; port++ {
+				cell := cellFree
+				if conflicts[port] {
+					cell = cellConflict
+				} else if len(r.PortMap[port]) > 0 {
+					cell = cellUsed
+				}
+				sb.WriteString(" " + cell + " |")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
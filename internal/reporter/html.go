@@ -0,0 +1,143 @@
+package reporter
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// htmlTemplate is a self-contained page: the styles are inline so the
+// report can be attached to a wiki page or mailed as one file. Severity
+// colors follow the terminal output: red errors, yellow warnings and grey
+// info.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Port Check Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #d0d7de; padding: 4px 10px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+code { font-family: SFMono-Regular, Consolas, monospace; font-size: 90%; }
+.error { background: #ffebe9; }
+.warning { background: #fff8c5; }
+.info { background: #f0f1f3; }
+.ok { color: #1a7f37; font-weight: bold; }
+a.anchor { color: inherit; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>Port Check Report</h1>
+<p><strong>Path:</strong> <code>{{.Path}}</code></p>
+
+<h2 id="summary">Summary</h2>
+<table>
+<tr><th>Metric</th><th>Value</th></tr>
+<tr><td>Compose files scanned</td><td>{{.Files}}</td></tr>
+<tr><td>Total port bindings</td><td>{{.Bindings}}</td></tr>
+<tr class="error"><td>Errors</td><td>{{.Summary.Errors}}</td></tr>
+<tr class="warning"><td>Warnings</td><td>{{.Summary.Warnings}}</td></tr>
+<tr class="info"><td>Info</td><td>{{.Summary.Info}}</td></tr>
+{{- if .Suppressed}}
+<tr><td>Suppressed by ignore rules</td><td>{{.Suppressed}}</td></tr>
+{{- end}}
+</table>
+
+<h2 id="issues">Issues</h2>
+{{- if not .Issues}}
+<p class="ok">No port conflicts detected!</p>
+{{- else}}
+<table>
+<tr><th>ID</th><th>Severity</th><th>Port</th><th>Type</th><th>Description</th><th>Bindings</th><th>Remediation</th></tr>
+{{- range .Issues}}
+<tr id="{{.Anchor}}" class="{{.Class}}">
+<td><a class="anchor" href="#{{.Anchor}}"><code>{{.ID}}</code></a></td>
+<td>{{.Severity}}</td>
+<td>{{.Port}}</td>
+<td>{{.Type}}</td>
+<td>{{.Description}}</td>
+<td>{{range .Bindings}}{{.Service}} <code>{{.Location}}</code><br>{{end}}</td>
+<td>{{.Remediation}}</td>
+</tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- if .PortBindings}}
+<h2 id="bindings">All Port Bindings</h2>
+<table>
+<tr><th>Host Port</th><th>Container Port</th><th>Protocol</th><th>Host IP</th><th>Service</th><th>File</th></tr>
+{{- range .PortBindings}}
+<tr><td>{{.Host}}</td><td>{{.ContainerPort}}</td><td>{{.Protocol}}</td><td>{{.HostIP}}</td><td>{{.Service}}</td><td><code>{{.Location}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// htmlBinding is a binding with its host port label and declaration
+// location
+type htmlBinding struct {
+	scanner.PortBinding
+	Host     string
+	Location string
+}
+
+// htmlIssue is an issue with the anchor and style class of its row
+type htmlIssue struct {
+	scanner.Issue
+	Anchor   string
+	Class    string
+	Bindings []htmlBinding
+}
+
+// FormatHTML generates a self-contained HTML page with a summary table,
+// the issues colored by severity and every port binding. Each issue row
+// is anchored as #issue-<ID> for deep links.
+func FormatHTML(r *scanner.Result) (string, error) {
+	data := struct {
+		Path         string
+		Files        int
+		Bindings     int
+		Suppressed   int
+		Summary      scanner.Summary
+		Issues       []htmlIssue
+		PortBindings []htmlBinding
+	}{
+		Path:       r.Path,
+		Files:      len(r.ComposeFiles),
+		Bindings:   len(r.PortBindings),
+		Suppressed: r.Suppressed,
+		Summary:    r.Summary(),
+	}
+
+	for i, issue := range r.Issues {
+		id := issue.ID
+		if id == "" {
+			id = fmt.Sprint(i + 1)
+		}
+		class := issue.Severity
+		if class != "error" && class != "warning" {
+			class = "info"
+		}
+		hi := htmlIssue{Issue: issue, Anchor: "issue-" + id, Class: class}
+		for _, b := range issue.Bindings {
+			hi.Bindings = append(hi.Bindings, htmlBinding{b, hostPortLabel(b), location(b)})
+		}
+		data.Issues = append(data.Issues, hi)
+	}
+	for _, b := range scanner.SortBindings(r.PortBindings) {
+		data.PortBindings = append(data.PortBindings, htmlBinding{b, hostPortLabel(b), location(b)})
+	}
+
+	var sb strings.Builder
+	if err := htmlTemplate.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
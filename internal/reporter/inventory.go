@@ -0,0 +1,141 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// inventoryColumns names the columns of every inventory format. The free
+// column is only present when availability was checked.
+var inventoryColumns = []string{"host_port", "container_port", "protocol", "host_ip", "service", "file"}
+
+// inventoryRow returns the cells of one binding, in inventoryColumns order.
+// A wildcard host IP is left empty, as in FormatCSV.
+func inventoryRow(b scanner.PortBinding) []string {
+	hostIP := b.HostIP
+	if hostIP == "0.0.0.0" {
+		hostIP = ""
+	}
+	return []string{
+		hostPortLabel(b),
+		strconv.Itoa(b.ContainerPort),
+		b.Protocol,
+		hostIP,
+		b.Service,
+		b.File,
+	}
+}
+
+// FormatInventoryText renders bindings as an aligned table, in the order
+// given. With a non-nil free map, a FREE column tells whether each host
+// port can currently be bound; bindings missing from the map could not be
+// probed and show as unknown.
+func FormatInventoryText(bindings []scanner.PortBinding, free map[scanner.PortBinding]bool) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	header := []string{"HOST PORT", "CONTAINER PORT", "PROTOCOL", "HOST IP", "SERVICE", "FILE"}
+	if free != nil {
+		header = append(header, "FREE")
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for _, b := range bindings {
+		row := inventoryRow(b)
+		if row[3] == "" {
+			row[3] = "*"
+		}
+		if free != nil {
+			availability := "unknown"
+			if isFree, probed := free[b]; probed {
+				availability = yesNo(isFree)
+			}
+			row = append(row, availability)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// FormatInventoryJSON renders bindings as a JSON array of objects
+func FormatInventoryJSON(bindings []scanner.PortBinding, free map[scanner.PortBinding]bool) (string, error) {
+	type inventoryEntry struct {
+		HostPort      int    `json:"host_port"`
+		ContainerPort int    `json:"container_port"`
+		Protocol      string `json:"protocol"`
+		HostIP        string `json:"host_ip"`
+		Service       string `json:"service"`
+		File          string `json:"file"`
+		Random        bool   `json:"random_host_port,omitempty"`
+		Unresolved    bool   `json:"unresolved,omitempty"`
+		Free          *bool  `json:"free,omitempty"` // omitted when not checked or not probed
+	}
+
+	entries := []inventoryEntry{}
+	for _, b := range bindings {
+		e := inventoryEntry{
+			HostPort:      b.HostPort,
+			ContainerPort: b.ContainerPort,
+			Protocol:      b.Protocol,
+			HostIP:        b.HostIP,
+			Service:       b.Service,
+			File:          b.File,
+			Random:        b.RandomHostPort,
+			Unresolved:    b.Unresolved,
+		}
+		if isFree, probed := free[b]; probed {
+			e.Free = &isFree
+		}
+		entries = append(entries, e)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// FormatInventoryCSV renders bindings as CSV with a header row
+func FormatInventoryCSV(bindings []scanner.PortBinding, free map[scanner.PortBinding]bool) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+
+	header := append([]string{}, inventoryColumns...)
+	if free != nil {
+		header = append(header, "free")
+	}
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+	for _, b := range bindings {
+		row := inventoryRow(b)
+		if free != nil {
+			// Empty when the binding could not be probed
+			availability := ""
+			if isFree, probed := free[b]; probed {
+				availability = strconv.FormatBool(isFree)
+			}
+			row = append(row, availability)
+		}
+		if err := w.Write(row); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return sb.String(), w.Error()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
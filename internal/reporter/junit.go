@@ -0,0 +1,149 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// JUnitOptions controls how issues map onto JUnit results
+type JUnitOptions struct {
+	// WarningsAsErrors reports warnings as <error> elements, failing the
+	// suite; otherwise they are only listed in the test case output
+	WarningsAsErrors bool
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// FormatJUnit generates JUnit XML with one test case per compose file,
+// skipping warnings
+func FormatJUnit(r *scanner.Result) (string, error) {
+	return FormatJUnitWithOptions(r, JUnitOptions{})
+}
+
+// FormatJUnitWithOptions generates JUnit XML with one test case per compose
+// file. Error issues fail the case of every file they involve; info issues
+// never fail it. Issues tied to no binding go to a separate scan case.
+func FormatJUnitWithOptions(r *scanner.Result, opts JUnitOptions) (string, error) {
+	var files []string
+	issuesByFile := make(map[string][]scanner.Issue)
+	for _, f := range r.ComposeFiles {
+		if _, ok := issuesByFile[f]; !ok {
+			files = append(files, f)
+			issuesByFile[f] = nil
+		}
+	}
+
+	var unattributed []scanner.Issue
+	for _, issue := range r.Issues {
+		seen := make(map[string]bool)
+		for _, b := range issue.Bindings {
+			if b.File == "" || seen[b.File] {
+				continue
+			}
+			seen[b.File] = true
+			if _, ok := issuesByFile[b.File]; !ok {
+				files = append(files, b.File)
+			}
+			issuesByFile[b.File] = append(issuesByFile[b.File], issue)
+		}
+		if len(seen) == 0 {
+			unattributed = append(unattributed, issue)
+		}
+	}
+
+	suite := junitSuite{Name: "portcheck"}
+	for _, f := range files {
+		suite.Cases = append(suite.Cases, junitTestCase(artifactURI(r.Path, f), issuesByFile[f], opts))
+	}
+	if len(unattributed) > 0 {
+		suite.Cases = append(suite.Cases, junitTestCase("scan", unattributed, opts))
+	}
+
+	suite.Tests = len(suite.Cases)
+	for _, c := range suite.Cases {
+		if c.Failure != nil {
+			suite.Failures++
+		}
+		if c.Error != nil {
+			suite.Errors++
+		}
+	}
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data), nil
+}
+
+// junitTestCase builds the test case of one compose file from its issues
+func junitTestCase(name string, issues []scanner.Issue, opts JUnitOptions) junitCase {
+	c := junitCase{Name: name, ClassName: "portcheck"}
+
+	var errors, warnings, other []scanner.Issue
+	for _, issue := range issues {
+		switch {
+		case issue.Severity == "error":
+			errors = append(errors, issue)
+		case issue.Severity == "warning" && opts.WarningsAsErrors:
+			warnings = append(warnings, issue)
+		default:
+			other = append(other, issue)
+		}
+	}
+
+	c.Failure = junitProblemOf(errors)
+	c.Error = junitProblemOf(warnings)
+	var out []string
+	for _, issue := range other {
+		out = append(out, fmt.Sprintf("[%s] %s: %s", issue.Severity, issue.Type, issue.Description))
+	}
+	c.SystemOut = strings.Join(out, "\n")
+	return c
+}
+
+// junitProblemOf summarizes issues as one failure or error element, since
+// a test case holds at most one of each
+func junitProblemOf(issues []scanner.Issue) *junitProblem {
+	if len(issues) == 0 {
+		return nil
+	}
+	p := &junitProblem{Message: issues[0].Description, Type: issues[0].Type}
+	if len(issues) > 1 {
+		p.Message = fmt.Sprintf("%d issues, first: %s", len(issues), issues[0].Description)
+	}
+	var lines []string
+	for _, issue := range issues {
+		line := fmt.Sprintf("%s: %s", issue.Type, issue.Description)
+		if issue.Remediation != "" {
+			line += "\n  Fix: " + issue.Remediation
+		}
+		lines = append(lines, line)
+	}
+	p.Text = strings.Join(lines, "\n")
+	return p
+}
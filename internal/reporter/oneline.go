@@ -0,0 +1,41 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// FormatOneline generates a single status line such as
+// "portcheck: 2 errors, 3 warnings (8 bindings, 4 files)"
+func FormatOneline(r *scanner.Result) string {
+	s := r.Summary()
+
+	var counts []string
+	if s.Errors > 0 {
+		counts = append(counts, color.RedString(plural(s.Errors, "error", "errors")))
+	}
+	if s.Warnings > 0 {
+		counts = append(counts, color.YellowString(plural(s.Warnings, "warning", "warnings")))
+	}
+	if s.Info > 0 {
+		counts = append(counts, fmt.Sprintf("%d info", s.Info))
+	}
+
+	status := color.GreenString("no issues")
+	if len(counts) > 0 {
+		status = strings.Join(counts, ", ")
+	}
+
+	return fmt.Sprintf("portcheck: %s (%s, %s)", status,
+		plural(s.Bindings, "binding", "bindings"), plural(s.Files, "file", "files"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,51 @@
+package reporter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// Output is one additional report destination, given as format:path
+type Output struct {
+	Format string
+	Path   string
+}
+
+// ParseOutputs parses format:path pairs such as "markdown:summary.md".
+// Formats are validated up front so a typo fails before scanning.
+func ParseOutputs(specs []string) ([]Output, error) {
+	var outputs []Output
+	for _, spec := range specs {
+		format, path, ok := strings.Cut(spec, ":")
+		if !ok || format == "" || path == "" {
+			return nil, fmt.Errorf("invalid output %q (want format:path)", spec)
+		}
+		if !IsFormat(format) {
+			return nil, fmt.Errorf("invalid output %q: unknown format %q (want %s)",
+				spec, format, strings.Join(Formats(), ", "))
+		}
+		outputs = append(outputs, Output{Format: format, Path: path})
+	}
+	return outputs, nil
+}
+
+// WriteOutputs renders the result once per output and writes it to the
+// output's path. A failing output does not stop the others; their errors
+// are joined.
+func WriteOutputs(r *scanner.Result, opts RenderOptions, outputs []Output) error {
+	var errs []error
+	for _, out := range outputs {
+		content, err := Render(out.Format, r, opts)
+		if err == nil {
+			err = os.WriteFile(out.Path, []byte(content), 0644)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s output to %s: %w", out.Format, out.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
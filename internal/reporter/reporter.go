@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/stackgen-cli/portcheck/internal/runtime"
 	"github.com/stackgen-cli/portcheck/internal/scanner"
 )
 
@@ -15,16 +16,7 @@ import (
 func FormatText(r *scanner.Result) (string, error) {
 	var sb strings.Builder
 
-	sb.WriteString(color.CyanString("Port Check Report\n"))
-	sb.WriteString(color.CyanString("=================\n\n"))
-
-	sb.WriteString(fmt.Sprintf("Scanned: %s\n", r.Path))
-	sb.WriteString(fmt.Sprintf("Compose files: %d\n", len(r.ComposeFiles)))
-	sb.WriteString(fmt.Sprintf("Port bindings: %d\n", len(r.PortBindings)))
-	sb.WriteString(fmt.Sprintf("Issues found: %d\n\n", len(r.Issues)))
-
-	if len(r.Issues) == 0 {
-		sb.WriteString(color.GreenString("✅ No port conflicts detected!\n"))
+	if !writeTextHeader(&sb, r) {
 		return sb.String(), nil
 	}
 
@@ -73,80 +65,199 @@ func FormatText(r *scanner.Result) (string, error) {
 	return sb.String(), nil
 }
 
+// writeTextHeader writes the report header and reports whether there are
+// issues to list
+func writeTextHeader(sb *strings.Builder, r *scanner.Result) bool {
+	sb.WriteString(color.CyanString("Port Check Report\n"))
+	sb.WriteString(color.CyanString("=================\n\n"))
+
+	sb.WriteString(fmt.Sprintf("Scanned: %s\n", r.Path))
+	sb.WriteString(fmt.Sprintf("Compose files: %d\n", len(r.ComposeFiles)))
+	sb.WriteString(fmt.Sprintf("Port bindings: %d\n", len(r.PortBindings)))
+	if len(r.PortBindings) > 0 {
+		exposure := r.ExposureCounts()
+		sb.WriteString(fmt.Sprintf("Exposure: %d public, %d private, %d local\n",
+			exposure[scanner.ExposurePublic], exposure[scanner.ExposurePrivate], exposure[scanner.ExposureLocal]))
+	}
+	sb.WriteString(fmt.Sprintf("Issues found: %d\n", len(r.Issues)))
+	if r.Suppressed > 0 {
+		sb.WriteString(fmt.Sprintf("Suppressed by ignore rules: %d\n", r.Suppressed))
+	}
+	sb.WriteString("\n")
+
+	if len(r.Issues) == 0 {
+		sb.WriteString(color.GreenString("✅ No port conflicts detected!\n"))
+		return false
+	}
+	return true
+}
+
 func formatIssue(sb *strings.Builder, issue scanner.Issue) {
-	sb.WriteString(fmt.Sprintf("\nPort %d: %s\n", issue.Port, issue.Description))
+	sb.WriteString(fmt.Sprintf("\nPort %d: %s%s\n", issue.Port, issue.Description, occurrences(issue)))
+	formatIssueDetails(sb, issue, "  ")
+}
 
+// occurrences returns a " (3 occurrences)" suffix for collapsed issues
+func occurrences(issue scanner.Issue) string {
+	if issue.Occurrences > 1 {
+		return fmt.Sprintf(" (%d occurrences)", issue.Occurrences)
+	}
+	return ""
+}
+
+// hostPortLabel returns the host port of a binding for tables, "random"
+// when Docker picks it, or "unresolved" when it depends on unset variables
+func hostPortLabel(b scanner.PortBinding) string {
+	if b.RandomHostPort {
+		return "random"
+	}
+	if b.Unresolved {
+		return "unresolved"
+	}
+	return fmt.Sprint(b.HostPort)
+}
+
+// location returns where a binding was declared as file:line:column,
+// relative to the working directory, or just the file when the line is
+// unknown
+func location(b scanner.PortBinding) string {
+	rel, _ := filepath.Rel(".", b.File)
+	if rel == "" {
+		rel = b.File
+	}
+	if b.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d", rel, b.Line, b.Column)
+	}
+	return rel
+}
+
+// formatIssueDetails writes the bindings and remediation of an issue
+func formatIssueDetails(sb *strings.Builder, issue scanner.Issue, indent string) {
 	for _, b := range issue.Bindings {
-		rel, _ := filepath.Rel(".", b.File)
-		if rel == "" {
-			rel = b.File
-		}
-		sb.WriteString(fmt.Sprintf("  → %s in %s (%s)\n", b.String(), rel, b.Service))
+		sb.WriteString(fmt.Sprintf("%s→ %s in %s (%s)\n", indent, b.String(), location(b), b.Service))
+	}
+
+	if issue.Remediation != "" {
+		sb.WriteString(fmt.Sprintf("%sFix: %s\n", indent, issue.Remediation))
+	}
+	if issue.ID != "" {
+		sb.WriteString(fmt.Sprintf("%sID: %s\n", indent, issue.ID))
 	}
 }
 
 // FormatJSON generates JSON output
 func FormatJSON(r *scanner.Result) (string, error) {
+	return FormatJSONWithOptions(r, RenderOptions{})
+}
+
+// FormatJSONWithOptions generates JSON output that also carries the
+// runtime scan, runtime changes and port suggestions set in opts. It is the
+// one document behind both --format json and --out json:path.
+func FormatJSONWithOptions(r *scanner.Result, opts RenderOptions) (string, error) {
 	type jsonBinding struct {
-		Port      int    `json:"host_port"`
-		Container int    `json:"container_port"`
-		Protocol  string `json:"protocol"`
-		HostIP    string `json:"host_ip,omitempty"`
-		Service   string `json:"service"`
-		File      string `json:"file"`
+		Port       int    `json:"host_port"`
+		Container  int    `json:"container_port"`
+		Protocol   string `json:"protocol"`
+		HostIP     string `json:"host_ip,omitempty"`
+		Mode       string `json:"mode,omitempty"`
+		Source     string `json:"source,omitempty"`
+		Exposure   string `json:"exposure"`
+		Service    string `json:"service"`
+		File       string `json:"file"`
+		Line       int    `json:"line,omitempty"`
+		Column     int    `json:"column,omitempty"`
+		Random     bool   `json:"random_host_port,omitempty"`
+		Unresolved bool   `json:"unresolved,omitempty"`
 	}
 
 	type jsonIssue struct {
+		ID          string        `json:"id"`
 		Severity    string        `json:"severity"`
 		Type        string        `json:"type"`
 		Port        int           `json:"port"`
 		Description string        `json:"description"`
+		Remediation string        `json:"remediation,omitempty"`
+		Occurrences int           `json:"occurrences,omitempty"`
 		Bindings    []jsonBinding `json:"bindings,omitempty"`
 	}
 
 	type jsonOutput struct {
-		Path         string        `json:"path"`
-		ComposeFiles []string      `json:"compose_files"`
-		TotalPorts   int           `json:"total_ports"`
-		Issues       []jsonIssue   `json:"issues"`
-		Bindings     []jsonBinding `json:"bindings"`
+		SchemaVersion     int                  `json:"schema_version"`
+		Path              string               `json:"path"`
+		ComposeFiles      []string             `json:"compose_files"`
+		TotalPorts        int                  `json:"total_ports"`
+		Exposure          map[string]int       `json:"exposure"`
+		Issues            []jsonIssue          `json:"issues"`
+		Suppressed        int                  `json:"suppressed"`
+		Bindings          []jsonBinding        `json:"bindings"`
+		RawBindings       []jsonBinding        `json:"raw_bindings"`
+		EffectiveBindings []jsonBinding        `json:"effective_bindings"`
+		ScannedFiles      []scanner.FileReport `json:"scanned_files"`
+
+		Runtime        *runtime.RuntimeResult `json:"runtime,omitempty"`
+		RuntimeChanges *[]runtime.PortChange  `json:"runtime_changes,omitempty"` // set, even if empty, when compared
+		Suggestions    map[int]int            `json:"suggestions,omitempty"`
+	}
+
+	toJSON := func(b scanner.PortBinding) jsonBinding {
+		return jsonBinding{
+			Port:       b.HostPort,
+			Container:  b.ContainerPort,
+			Protocol:   b.Protocol,
+			HostIP:     b.HostIP,
+			Mode:       b.Mode,
+			Source:     b.Source,
+			Exposure:   b.Exposure,
+			Service:    b.Service,
+			File:       b.File,
+			Line:       b.Line,
+			Column:     b.Column,
+			Random:     b.RandomHostPort,
+			Unresolved: b.Unresolved,
+		}
 	}
 
 	out := jsonOutput{
-		Path:         r.Path,
-		ComposeFiles: r.ComposeFiles,
-		TotalPorts:   len(r.PortBindings),
+		SchemaVersion: JSONSchemaVersion,
+		Path:          r.Path,
+		ComposeFiles:  r.ComposeFiles,
+		TotalPorts:    len(r.PortBindings),
+		Exposure:      r.ExposureCounts(),
+		Suppressed:    r.Suppressed,
+		ScannedFiles:  r.ScannedFiles,
+		Runtime:       opts.Runtime,
+		Suggestions:   opts.Suggestions,
+	}
+	if opts.RuntimeChanges != nil {
+		out.RuntimeChanges = &opts.RuntimeChanges
 	}
 
 	for _, issue := range r.Issues {
 		ji := jsonIssue{
+			ID:          issue.ID,
 			Severity:    issue.Severity,
 			Type:        issue.Type,
 			Port:        issue.Port,
 			Description: issue.Description,
+			Remediation: issue.Remediation,
+			Occurrences: issue.Occurrences,
 		}
 		for _, b := range issue.Bindings {
-			ji.Bindings = append(ji.Bindings, jsonBinding{
-				Port:      b.HostPort,
-				Container: b.ContainerPort,
-				Protocol:  b.Protocol,
-				HostIP:    b.HostIP,
-				Service:   b.Service,
-				File:      b.File,
-			})
+			ji.Bindings = append(ji.Bindings, toJSON(b))
 		}
 		out.Issues = append(out.Issues, ji)
 	}
 
 	for _, b := range r.PortBindings {
-		out.Bindings = append(out.Bindings, jsonBinding{
-			Port:      b.HostPort,
-			Container: b.ContainerPort,
-			Protocol:  b.Protocol,
-			HostIP:    b.HostIP,
-			Service:   b.Service,
-			File:      b.File,
-		})
+		out.Bindings = append(out.Bindings, toJSON(b))
+	}
+	out.RawBindings = []jsonBinding{}
+	for _, b := range scanner.SortBindings(r.RawBindings) {
+		out.RawBindings = append(out.RawBindings, toJSON(b))
+	}
+	out.EffectiveBindings = []jsonBinding{}
+	for _, b := range scanner.SortBindings(r.PortBindings) {
+		out.EffectiveBindings = append(out.EffectiveBindings, toJSON(b))
 	}
 
 	data, err := json.MarshalIndent(out, "", "  ")
@@ -170,14 +281,17 @@ func FormatMarkdown(r *scanner.Result) (string, error) {
 	sb.WriteString(fmt.Sprintf("| Compose files scanned | %d |\n", len(r.ComposeFiles)))
 	sb.WriteString(fmt.Sprintf("| Total port bindings | %d |\n", len(r.PortBindings)))
 	sb.WriteString(fmt.Sprintf("| Issues found | %d |\n", len(r.Issues)))
+	if r.Suppressed > 0 {
+		sb.WriteString(fmt.Sprintf("| Suppressed by ignore rules | %d |\n", r.Suppressed))
+	}
 	sb.WriteString("\n")
 
 	if len(r.Issues) == 0 {
 		sb.WriteString("✅ **No port conflicts detected!**\n\n")
 	} else {
 		sb.WriteString("## Issues\n\n")
-		sb.WriteString("| Severity | Port | Type | Description |\n")
-		sb.WriteString("|----------|------|------|-------------|\n")
+		sb.WriteString("| Severity | Port | Type | Description | Remediation | ID |\n")
+		sb.WriteString("|----------|------|------|-------------|-------------|----|\n")
 
 		for _, issue := range r.Issues {
 			sevIcon := ""
@@ -189,8 +303,8 @@ func FormatMarkdown(r *scanner.Result) (string, error) {
 			default:
 				sevIcon = "🔵"
 			}
-			sb.WriteString(fmt.Sprintf("| %s %s | %d | %s | %s |\n",
-				sevIcon, issue.Severity, issue.Port, issue.Type, issue.Description))
+			sb.WriteString(fmt.Sprintf("| %s %s | %d | %s | %s | %s | `%s` |\n",
+				sevIcon, issue.Severity, issue.Port, issue.Type, issue.Description, issue.Remediation, issue.ID))
 		}
 		sb.WriteString("\n")
 	}
@@ -202,12 +316,8 @@ func FormatMarkdown(r *scanner.Result) (string, error) {
 		sb.WriteString("|-----------|----------------|---------|------|\n")
 
 		for _, b := range r.PortBindings {
-			rel, _ := filepath.Rel(".", b.File)
-			if rel == "" {
-				rel = b.File
-			}
-			sb.WriteString(fmt.Sprintf("| %d | %d | %s | `%s` |\n",
-				b.HostPort, b.ContainerPort, b.Service, rel))
+			sb.WriteString(fmt.Sprintf("| %s | %d | %s | `%s` |\n",
+				hostPortLabel(b), b.ContainerPort, b.Service, location(b)))
 		}
 	}
 
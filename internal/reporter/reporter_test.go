@@ -0,0 +1,754 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/portcheck/internal/runtime"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+func writeCompose(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFormatJSONWithOptions_RuntimeAndSuggestions(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", "services:\n  web:\n    image: nginx\n    ports:\n      - \"8080:80\"\n")
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	out, err := FormatJSON(result)
+	if err != nil {
+		t.Fatalf("FormatJSON failed: %v", err)
+	}
+	for _, key := range []string{`"runtime"`, `"runtime_changes"`, `"suggestions"`} {
+		if strings.Contains(out, key) {
+			t.Errorf("Expected %s to be omitted without options:\n%s", key, out)
+		}
+	}
+
+	opts := RenderOptions{
+		Suggestions:    map[int]int{8080: 8081},
+		Runtime:        &runtime.RuntimeResult{DockerRunning: true},
+		RuntimeChanges: []runtime.PortChange{},
+	}
+	out, err = Render("json", result, opts)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	var parsed struct {
+		SchemaVersion  int                    `json:"schema_version"`
+		Runtime        *runtime.RuntimeResult `json:"runtime"`
+		RuntimeChanges []runtime.PortChange   `json:"runtime_changes"`
+		Suggestions    map[string]int         `json:"suggestions"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if parsed.SchemaVersion != JSONSchemaVersion || parsed.Runtime == nil || !parsed.Runtime.DockerRunning {
+		t.Errorf("Expected the runtime scan in the report, got %+v", parsed)
+	}
+	if parsed.RuntimeChanges == nil {
+		t.Errorf("Expected runtime_changes to be present even when empty:\n%s", out)
+	}
+	if parsed.Suggestions["8080"] != 8081 {
+		t.Errorf("Expected the suggestions, got %v", parsed.Suggestions)
+	}
+}
+
+func TestFormatJSON_RawAndEffectiveBindings(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`)
+	writeCompose(t, dir, "docker-compose.prod.yml", `services:
+  web:
+    ports:
+      - "9080:80"
+`)
+
+	result, err := scanner.ScanWithOptions(dir, scanner.Options{Env: "prod"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	out, err := FormatJSON(result)
+	if err != nil {
+		t.Fatalf("FormatJSON failed: %v", err)
+	}
+
+	var parsed struct {
+		Raw []struct {
+			Port    int    `json:"host_port"`
+			Service string `json:"service"`
+		} `json:"raw_bindings"`
+		Effective []struct {
+			Port    int    `json:"host_port"`
+			Service string `json:"service"`
+		} `json:"effective_bindings"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	var raw, effective []int
+	for _, b := range parsed.Raw {
+		raw = append(raw, b.Port)
+	}
+	for _, b := range parsed.Effective {
+		effective = append(effective, b.Port)
+	}
+
+	// Sorted by host port; the base web port only appears in raw
+	if len(raw) != 3 || raw[0] != 5432 || raw[1] != 8080 || raw[2] != 9080 {
+		t.Errorf("raw_bindings ports = %v, want [5432 8080 9080]", raw)
+	}
+	if len(effective) != 2 || effective[0] != 5432 || effective[1] != 9080 {
+		t.Errorf("effective_bindings ports = %v, want [5432 9080]", effective)
+	}
+}
+
+func TestFormatHeatmap(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: api
+    ports:
+      - "8080:3000"
+      - "8082:3001"
+`)
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	out := FormatHeatmap(result)
+
+	if !strings.Contains(out, "**8000–8099**") {
+		t.Errorf("Expected the 8000–8099 range, got:\n%s", out)
+	}
+	if strings.Count(out, "**") != 2 {
+		t.Errorf("Expected only one range to be rendered, got:\n%s", out)
+	}
+	want := "| 8080 | 🟥 | ⬜ | 🟩 | ⬜ | ⬜ | ⬜ | ⬜ | ⬜ | ⬜ | ⬜ |"
+	if !strings.Contains(out, want) {
+		t.Errorf("Expected row %q, got:\n%s", want, out)
+	}
+	if strings.Count(out, "🟥") != 2 || strings.Count(out, "🟩") != 2 {
+		t.Errorf("Expected one conflict and one used cell plus legend, got:\n%s", out)
+	}
+}
+
+func TestFormatTree(t *testing.T) {
+	color.NoColor = true
+	result := &scanner.Result{
+		Path: "/srv",
+		Issues: []scanner.Issue{
+			{Severity: "info", Type: "common_port", Port: 8080, Description: "common 8080"},
+			{Severity: "error", Type: "collision", Port: 8080, Description: "collision 8080",
+				Bindings: []scanner.PortBinding{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp", Service: "web", File: "docker-compose.yml"}}},
+			{Severity: "warning", Type: "privileged", Port: 80, Description: "privileged 80"},
+			{Severity: "error", Type: "collision", Port: 3000, Description: "collision 3000"},
+			{Severity: "error", Type: "collision", Port: 3000, Description: "collision 3000 udp"},
+			{Severity: "error", Type: "invalid_range", Port: 0, Description: "bad range"},
+		},
+	}
+
+	out, err := FormatTree(result)
+	if err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+
+	want := []string{
+		"❌ ERRORS (4)",
+		"  collision (3)",
+		"    Port 3000 (2)",
+		"      collision 3000",
+		"      collision 3000 udp",
+		"    Port 8080 (1)",
+		"      collision 8080",
+		"        → 8080:80 in docker-compose.yml (web)",
+		"  invalid_range (1)",
+		"    Port 0 (1)",
+		"      bad range",
+		"⚠️  WARNINGS (1)",
+		"  privileged (1)",
+		"    Port 80 (1)",
+		"ℹ️  INFO (1)",
+		"  common_port (1)",
+		"    Port 8080 (1)",
+	}
+	lines := strings.Split(out, "\n")
+	pos := 0
+	for _, line := range lines {
+		if pos < len(want) && line == want[pos] {
+			pos++
+		}
+	}
+	if pos != len(want) {
+		t.Errorf("Missing or misordered line %q in:\n%s", want[pos], out)
+	}
+}
+
+func TestFormatOneline(t *testing.T) {
+	color.NoColor = true
+
+	result := &scanner.Result{
+		ComposeFiles: []string{"a.yml", "b.yml", "c.yml", "d.yml"},
+		PortBindings: make([]scanner.PortBinding, 8),
+		Issues: []scanner.Issue{
+			{Severity: "error"}, {Severity: "error"},
+			{Severity: "warning"}, {Severity: "warning"}, {Severity: "warning"},
+		},
+	}
+	if got, want := FormatOneline(result), "portcheck: 2 errors, 3 warnings (8 bindings, 4 files)"; got != want {
+		t.Errorf("FormatOneline = %q, want %q", got, want)
+	}
+
+	result = &scanner.Result{
+		ComposeFiles: []string{"a.yml"},
+		PortBindings: make([]scanner.PortBinding, 1),
+		Issues:       []scanner.Issue{{Severity: "error"}, {Severity: "info"}},
+	}
+	if got, want := FormatOneline(result), "portcheck: 1 error, 1 info (1 binding, 1 file)"; got != want {
+		t.Errorf("FormatOneline = %q, want %q", got, want)
+	}
+
+	if got, want := FormatOneline(&scanner.Result{}), "portcheck: no issues (0 bindings, 0 files)"; got != want {
+		t.Errorf("FormatOneline = %q, want %q", got, want)
+	}
+}
+
+func TestFormatEnv(t *testing.T) {
+	collision := func(port int, services ...string) scanner.Issue {
+		issue := scanner.Issue{Severity: "error", Type: "collision", Port: port}
+		for _, svc := range services {
+			issue.Bindings = append(issue.Bindings, scanner.PortBinding{HostPort: port, Service: svc})
+		}
+		return issue
+	}
+
+	result := &scanner.Result{
+		Issues: []scanner.Issue{
+			collision(8080, "api", "web"),
+			collision(5432, "db", "my-db.replica"),
+			collision(3000, "api", "2fa"),
+			collision(9000, "api", "queue"),
+		},
+	}
+	suggestions := map[int]int{8080: 8081, 5432: 5433, 3000: 3001}
+
+	got := FormatEnv(result, suggestions)
+	want := "export MY_DB_REPLICA_PORT=5433\nexport WEB_PORT=8081\nexport _2FA_PORT=3001\n"
+	if got != want {
+		t.Errorf("FormatEnv =\n%s\nwant\n%s", got, want)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !strings.HasPrefix(line, "export ") || !ok || value == "" || strings.ContainsAny(name, "-. ") {
+			t.Errorf("Malformed export line %q", line)
+		}
+	}
+}
+
+func TestWriteOutputs_MultipleFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+`)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonPath := filepath.Join(dir, "report.json")
+	mdPath := filepath.Join(dir, "summary.md")
+	outputs, err := ParseOutputs([]string{"json:" + jsonPath, "markdown:" + mdPath})
+	if err != nil {
+		t.Fatalf("ParseOutputs failed: %v", err)
+	}
+	if err := WriteOutputs(result, RenderOptions{}, outputs); err != nil {
+		t.Fatalf("WriteOutputs failed: %v", err)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal(data, &report); err != nil || report["issues"] == nil {
+		t.Errorf("JSON output is not a JSON report (%v):\n%s", err, data)
+	}
+
+	data, err = os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# Port Check Report") {
+		t.Errorf("Markdown output has unexpected content:\n%s", data)
+	}
+
+	// A failing destination does not prevent the others
+	okPath := filepath.Join(dir, "ok.json")
+	err = WriteOutputs(result, RenderOptions{}, []Output{
+		{Format: "markdown", Path: filepath.Join(dir, "missing", "summary.md")},
+		{Format: "json", Path: okPath},
+	})
+	if err == nil || !strings.Contains(err.Error(), "markdown output") {
+		t.Errorf("Expected the markdown output error, got %v", err)
+	}
+	if _, statErr := os.Stat(okPath); statErr != nil {
+		t.Errorf("The json output should still be written: %v", statErr)
+	}
+
+	for _, spec := range []string{"json", "yaml:out.yml", ":out.json", "json:"} {
+		if _, err := ParseOutputs([]string{spec}); err == nil {
+			t.Errorf("ParseOutputs(%q) should fail", spec)
+		}
+	}
+}
+
+func TestFormatFooter_MatchesSummary(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+      - "80:8000"
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+`)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"text", "markdown"} {
+		out, err := Render(format, result, RenderOptions{})
+		if err != nil {
+			t.Fatalf("Render %s failed: %v", format, err)
+		}
+		out += "\n" + FormatFooter(result) + "\n"
+
+		if n := strings.Count(out, FooterPrefix); n != 1 {
+			t.Errorf("%s: footer appears %d times", format, n)
+		}
+		got, ok := ParseFooter(out)
+		if !ok {
+			t.Fatalf("%s: no footer in:\n%s", format, out)
+		}
+		if got != result.Summary() {
+			t.Errorf("%s: footer = %+v, want %+v", format, got, result.Summary())
+		}
+	}
+
+	want := "PORTCHECK_RESULT errors=1 warnings=1 info=1 bindings=3 files=1"
+	if got := FormatFooter(result); got != want {
+		t.Errorf("FormatFooter = %q, want %q", got, want)
+	}
+}
+
+func TestFormatSARIF(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: node
+    ports:
+      - target: 3000
+        published: 8080
+`)
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	output, err := FormatSARIF(result)
+	if err != nil {
+		t.Fatalf("FormatSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(output), &log); err != nil {
+		t.Fatalf("Invalid SARIF JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected a single SARIF 2.1.0 run, got %+v", log)
+	}
+
+	var collision *sarifResult
+	for i, r := range log.Runs[0].Results {
+		if r.RuleID == "collision" {
+			collision = &log.Runs[0].Results[i]
+		}
+	}
+	if collision == nil {
+		t.Fatalf("Expected a collision result, got %+v", log.Runs[0].Results)
+	}
+	if collision.Level != "error" || collision.PartialFingerprints["portcheck/v1"] == "" {
+		t.Errorf("Unexpected collision result %+v", collision)
+	}
+
+	location := collision.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "docker-compose.yml" {
+		t.Errorf("Expected a path relative to the scan, got %q", location.ArtifactLocation.URI)
+	}
+	// Services are visited in name order, so the first binding is api's
+	// long syntax entry on line 9
+	if location.Region == nil || location.Region.StartLine != 9 {
+		t.Errorf("Expected the line of a ports entry, got %+v", location.Region)
+	}
+
+	var ruled bool
+	for _, rule := range log.Runs[0].Tool.Driver.Rules {
+		ruled = ruled || rule.ID == "collision"
+	}
+	if !ruled {
+		t.Errorf("Expected a rule for every reported type, got %+v", log.Runs[0].Tool.Driver.Rules)
+	}
+}
+
+func TestFormatJUnit_CountsFilesAndFailures(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - "80:80"
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+`)
+	if err := os.MkdirAll(filepath.Join(dir, "tools"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeCompose(t, dir, "tools/compose.yml", `services:
+  admin:
+    image: admin
+    ports:
+      - "9000:9000"
+`)
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		opts   JUnitOptions
+		errors int
+	}{
+		{JUnitOptions{}, 0},
+		{JUnitOptions{WarningsAsErrors: true}, 1},
+	} {
+		output, err := FormatJUnitWithOptions(result, tt.opts)
+		if err != nil {
+			t.Fatalf("FormatJUnit failed: %v", err)
+		}
+
+		var suite junitSuite
+		if err := xml.Unmarshal([]byte(output), &suite); err != nil {
+			t.Fatalf("Invalid JUnit XML: %v", err)
+		}
+		if suite.Tests != 2 || len(suite.Cases) != 2 {
+			t.Errorf("Expected a test case per compose file, got %d tests: %+v", suite.Tests, suite.Cases)
+		}
+		if suite.Failures != 1 {
+			t.Errorf("Expected only docker-compose.yml to fail, got %d failures", suite.Failures)
+		}
+		if suite.Errors != tt.errors {
+			t.Errorf("WarningsAsErrors=%v: expected %d errors for the privileged port warning, got %d",
+				tt.opts.WarningsAsErrors, tt.errors, suite.Errors)
+		}
+		for _, c := range suite.Cases {
+			if c.Name == "tools/compose.yml" && (c.Failure != nil || c.Error != nil) {
+				t.Errorf("Clean file should pass, got %+v", c)
+			}
+		}
+
+		// --out junit:path renders the same as --format junit
+		rendered, err := Render("junit", result, RenderOptions{JUnit: tt.opts})
+		if err != nil || rendered != output {
+			t.Errorf("Render(junit) with %+v differs from FormatJUnitWithOptions (%v)", tt.opts, err)
+		}
+	}
+}
+
+func TestFormatCSV(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "stack,prod")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "0.0.0.0:8080:80"
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+      - "127.0.0.1:9000:9000/udp"
+`)
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	output, err := FormatCSV(result)
+	if err != nil {
+		t.Fatalf("FormatCSV failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v\n%s", err, output)
+	}
+	if strings.Join(rows[0], ",") != "host_port,container_port,protocol,host_ip,service,file,has_issue" {
+		t.Errorf("Unexpected header %v", rows[0])
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected a row per binding, got %v", rows)
+	}
+
+	file := filepath.Join(dir, "docker-compose.yml")
+	byService := make(map[string][]string)
+	for _, row := range rows[1:] {
+		if row[5] != file {
+			t.Errorf("File column %q should survive the comma in its path", row[5])
+		}
+		byService[row[4]+"/"+row[0]] = row
+	}
+	if web := byService["web/8080"]; web[3] != "" || web[6] != "true" {
+		t.Errorf("Wildcard binding should have an empty host_ip and an issue, got %v", web)
+	}
+	if udp := byService["api/9000"]; udp[2] != "udp" || udp[3] != "127.0.0.1" || udp[6] != "false" {
+		t.Errorf("Unexpected row for the udp binding %v", udp)
+	}
+}
+
+func TestFormatInventory(t *testing.T) {
+	bindings := []scanner.PortBinding{
+		{HostPort: 53, ContainerPort: 53, Protocol: "udp", HostIP: "0.0.0.0", Service: "dns", File: "docker-compose.yml"},
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp", HostIP: "127.0.0.1", Service: "web", File: "docker-compose.yml"},
+	}
+
+	text := FormatInventoryText(bindings, nil)
+	if strings.Contains(text, "FREE") {
+		t.Errorf("FREE column should only appear when availability was checked:\n%s", text)
+	}
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "53 ") || !strings.Contains(lines[1], " * ") {
+		t.Errorf("Unexpected inventory table:\n%s", text)
+	}
+
+	free := map[scanner.PortBinding]bool{bindings[0]: true, bindings[1]: false}
+	output, err := FormatInventoryCSV(bindings, free)
+	if err != nil {
+		t.Fatalf("FormatInventoryCSV failed: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v\n%s", err, output)
+	}
+	if strings.Join(rows[0], ",") != "host_port,container_port,protocol,host_ip,service,file,free" {
+		t.Errorf("Unexpected header %v", rows[0])
+	}
+	if rows[1][3] != "" || rows[1][6] != "true" || rows[2][6] != "false" {
+		t.Errorf("Unexpected rows %v", rows[1:])
+	}
+
+	data, err := FormatInventoryJSON(bindings, nil)
+	if err != nil {
+		t.Fatalf("FormatInventoryJSON failed: %v", err)
+	}
+	if strings.Contains(data, `"free"`) {
+		t.Errorf("free should be omitted when availability was not checked:\n%s", data)
+	}
+	// Bindings missing from free could not be probed
+	unprobed := map[scanner.PortBinding]bool{bindings[0]: true}
+	if text := FormatInventoryText(bindings, unprobed); !strings.Contains(strings.Split(text, "\n")[2], "unknown") {
+		t.Errorf("Expected an unprobed binding to show as unknown:\n%s", text)
+	}
+	if output, _ := FormatInventoryCSV(bindings, unprobed); !strings.HasSuffix(strings.TrimSpace(output), ",") {
+		t.Errorf("Expected an empty free cell for an unprobed binding:\n%s", output)
+	}
+}
+
+func TestFormatCompact(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+      - "9000:9000"
+`)
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	s := result.Summary()
+
+	quiet, err := FormatCompact("text", result, CompactQuiet)
+	if err != nil {
+		t.Fatalf("FormatCompact failed: %v", err)
+	}
+	want := fmt.Sprintf("portcheck: %s, %s, %d info",
+		plural(s.Errors, "error", "errors"), plural(s.Warnings, "warning", "warnings"), s.Info)
+	if quiet != want {
+		t.Errorf("Expected %q, got %q", want, quiet)
+	}
+
+	summary, err := FormatCompact("text", result, CompactSummary)
+	if err != nil {
+		t.Fatalf("FormatCompact failed: %v", err)
+	}
+	if !strings.HasSuffix(summary, "\nColliding ports: 8080") || strings.Contains(summary, "9000") {
+		t.Errorf("Summary should list colliding ports but no bindings, got:\n%s", summary)
+	}
+
+	data, err := FormatCompact("json", result, CompactSummary)
+	if err != nil {
+		t.Fatalf("FormatCompact failed: %v", err)
+	}
+	var parsed struct {
+		Errors         int   `json:"errors"`
+		CollidingPorts []int `json:"colliding_ports"`
+	}
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if parsed.Errors != s.Errors || len(parsed.CollidingPorts) != 1 || parsed.CollidingPorts[0] != 8080 {
+		t.Errorf("Unexpected compact JSON %s", data)
+	}
+
+	if _, err := FormatCompact("sarif", result, CompactQuiet); err == nil {
+		t.Error("Expected an error for a format without a compact mode")
+	}
+}
+
+func TestFormatHTML(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  "<web>":
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+`)
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	output, err := FormatHTML(result)
+	if err != nil {
+		t.Fatalf("FormatHTML failed: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "<!DOCTYPE html>") || !strings.Contains(output, "<style>") {
+		t.Error("Expected a self-contained page with inline styles")
+	}
+	if strings.Contains(output, "<web>") || !strings.Contains(output, "&lt;web&gt;") {
+		t.Error("Service names should be escaped")
+	}
+
+	collisions := result.FilterByType("collision")
+	if len(collisions) != 1 {
+		t.Fatalf("Expected a collision, got %+v", result.Issues)
+	}
+	anchor := fmt.Sprintf(`<tr id="issue-%s" class="error">`, collisions[0].ID)
+	if !strings.Contains(output, anchor) || !strings.Contains(output, `href="#issue-`+collisions[0].ID+`"`) {
+		t.Errorf("Expected the collision row anchored as %s:\n%s", anchor, output)
+	}
+}
+
+func TestFormat_RandomHostPort(t *testing.T) {
+	dir := t.TempDir()
+	writeCompose(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "80"
+`)
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	md, err := FormatMarkdown(result)
+	if err != nil {
+		t.Fatalf("FormatMarkdown failed: %v", err)
+	}
+	if !strings.Contains(md, "| random | 80 | web |") {
+		t.Errorf("Expected the binding listed with a random host port:\n%s", md)
+	}
+
+	data, err := FormatJSON(result)
+	if err != nil {
+		t.Fatalf("FormatJSON failed: %v", err)
+	}
+	if !strings.Contains(data, `"random_host_port": true`) {
+		t.Errorf("Expected random_host_port in JSON bindings:\n%s", data)
+	}
+
+	var sb strings.Builder
+	formatIssueDetails(&sb, scanner.Issue{Bindings: result.PortBindings}, "  ")
+	if !strings.Contains(sb.String(), "→ random:80 in ") {
+		t.Errorf("Expected the text reporter to show random:80, got %q", sb.String())
+	}
+}
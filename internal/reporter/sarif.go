@@ -0,0 +1,154 @@
+package reporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// SARIF 2.1.0 document structure, limited to the fields portcheck fills
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// FormatSARIF generates a SARIF 2.1.0 log for code scanning tools such as
+// GitHub, with one result per issue located at the compose line of its
+// first binding when it is known
+func FormatSARIF(r *scanner.Result) (string, error) {
+	var results []sarifResult
+	types := make(map[string]bool)
+	for _, issue := range r.Issues {
+		types[issue.Type] = true
+		result := sarifResult{
+			RuleID:  issue.Type,
+			Level:   sarifLevel(issue.Severity),
+			Message: sarifMessage{Text: issue.Description},
+		}
+		if issue.ID != "" {
+			result.PartialFingerprints = map[string]string{"portcheck/v1": issue.ID}
+		}
+		if len(issue.Bindings) > 0 && issue.Bindings[0].File != "" {
+			b := issue.Bindings[0]
+			location := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: artifactURI(r.Path, b.File)}}
+			if b.Line > 0 {
+				location.Region = &sarifRegion{StartLine: b.Line, StartColumn: b.Column}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+		results = append(results, result)
+	}
+
+	rules := make([]sarifRule, 0, len(types))
+	for t := range types {
+		description := scanner.RuleDescription(t)
+		if description == "" {
+			description = t
+		}
+		rules = append(rules, sarifRule{ID: t, ShortDescription: sarifMessage{Text: description}})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "portcheck",
+				InformationURI: "https://github.com/ecent1119/portcheck",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	if log.Runs[0].Results == nil {
+		// SARIF requires the array even for a clean scan
+		log.Runs[0].Results = []sarifResult{}
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// sarifLevel maps an issue severity to a SARIF result level
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// artifactURI returns file relative to the scanned directory with forward
+// slashes, as code scanning expects paths relative to the checkout
+func artifactURI(scanPath, file string) string {
+	base := scanPath
+	if info, err := os.Stat(scanPath); err == nil && !info.IsDir() {
+		base = filepath.Dir(scanPath)
+	}
+	if rel, err := filepath.Rel(base, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(file)
+}
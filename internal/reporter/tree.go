@@ -0,0 +1,88 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// treeSeverities is the order severity branches are rendered in
+var treeSeverities = []struct {
+	name  string
+	label string
+	paint func(format string, a ...interface{}) string
+}{
+	{"error", "❌ ERRORS", color.RedString},
+	{"warning", "⚠️  WARNINGS", color.YellowString},
+	{"info", "ℹ️  INFO", color.HiBlackString},
+}
+
+// FormatTree generates text output with issues nested by severity, then
+// type, then port
+func FormatTree(r *scanner.Result) (string, error) {
+	var sb strings.Builder
+
+	if !writeTextHeader(&sb, r) {
+		return sb.String(), nil
+	}
+
+	// severity -> type -> port -> issues
+	tree := make(map[string]map[string]map[int][]scanner.Issue)
+	for _, issue := range r.Issues {
+		sev := issue.Severity
+		if sev != "error" && sev != "warning" {
+			sev = "info"
+		}
+		if tree[sev] == nil {
+			tree[sev] = make(map[string]map[int][]scanner.Issue)
+		}
+		if tree[sev][issue.Type] == nil {
+			tree[sev][issue.Type] = make(map[int][]scanner.Issue)
+		}
+		tree[sev][issue.Type][issue.Port] = append(tree[sev][issue.Type][issue.Port], issue)
+	}
+
+	for _, sev := range treeSeverities {
+		types := tree[sev.name]
+		if len(types) == 0 {
+			continue
+		}
+
+		names := make([]string, 0, len(types))
+		total := 0
+		for name, ports := range types {
+			names = append(names, name)
+			for _, issues := range ports {
+				total += len(issues)
+			}
+		}
+		sort.Strings(names)
+
+		sb.WriteString(sev.paint("%s (%d)\n", sev.label, total))
+		for _, name := range names {
+			ports := make([]int, 0, len(types[name]))
+			count := 0
+			for port, issues := range types[name] {
+				ports = append(ports, port)
+				count += len(issues)
+			}
+			sort.Ints(ports)
+
+			sb.WriteString(fmt.Sprintf("  %s (%d)\n", name, count))
+			for _, port := range ports {
+				issues := types[name][port]
+				sb.WriteString(fmt.Sprintf("    Port %d (%d)\n", port, len(issues)))
+				for _, issue := range issues {
+					sb.WriteString(fmt.Sprintf("      %s%s\n", issue.Description, occurrences(issue)))
+					formatIssueDetails(&sb, issue, "        ")
+				}
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,303 @@
+package rewrite
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrAborted is returned when the user declines the planned changes
+	ErrAborted = errors.New("aborted: no files were changed")
+	// ErrConfirmationRequired is returned when confirmation is needed but
+	// there is no terminal to ask on
+	ErrConfirmationRequired = errors.New("refusing to edit files without confirmation: pass -y to apply or --dry-run to preview")
+)
+
+// Change is a planned host port reassignment for one binding of a service
+type Change struct {
+	File     string
+	Service  string
+	Protocol string // tcp when empty
+	HostIP   string // every interface when empty
+	From     int
+	To       int
+}
+
+// moves reports whether b is a binding the change applies to: the same
+// host port, protocol and host IP, an omitted IP being the same as 0.0.0.0
+func (c Change) moves(b *scanner.PortBinding) bool {
+	protocol := c.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return b != nil && !b.RandomHostPort && b.HostPort == c.From &&
+		strings.EqualFold(b.Protocol, protocol) &&
+		scanner.NormalizeHostIP(b.HostIP) == scanner.NormalizeHostIP(c.HostIP)
+}
+
+// FileEdit is the before and after content of one rewritten file
+type FileEdit struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// FixOptions controls how Fix confirms and applies edits
+type FixOptions struct {
+	DryRun      bool // only print the diff
+	AssumeYes   bool // apply without asking
+	Interactive bool // In is a terminal
+	Backup      bool // copy each file to <file>.bak before writing it
+	In          io.Reader
+	Out         io.Writer
+}
+
+// Keeper returns the index of the binding of a collision that keeps its
+// host port; every other binding is moved
+type Keeper func(issue scanner.Issue) int
+
+// PlanFixes keeps the first binding of every collision and moves the others
+// to the port returned by next, which receives the host ports already taken
+func PlanFixes(result *scanner.Result, next func(port int, taken map[int]bool) int) []Change {
+	return PlanFixesKeeping(result, next, func(scanner.Issue) int { return 0 })
+}
+
+// PlanFixesKeeping is PlanFixes with keep choosing which binding of each
+// collision stays
+func PlanFixesKeeping(result *scanner.Result, next func(port int, taken map[int]bool) int, keep Keeper) []Change {
+	taken := make(map[int]bool)
+	for port := range result.PortMap {
+		taken[port] = true
+	}
+
+	var changes []Change
+	for _, issue := range result.FilterByType("collision", "container_name_port_collision") {
+		if len(issue.Bindings) < 2 {
+			continue
+		}
+		kept := keep(issue)
+		for i, b := range issue.Bindings {
+			// One port of a range cannot be moved on its own
+			if i == kept || b.HostRange != "" {
+				continue
+			}
+			to := next(b.HostPort, taken)
+			if to == 0 {
+				continue
+			}
+			taken[to] = true
+			changes = append(changes, Change{
+				File:     b.File,
+				Service:  b.Service,
+				Protocol: b.Protocol,
+				HostIP:   b.HostIP,
+				From:     b.HostPort,
+				To:       to,
+			})
+		}
+	}
+	return changes
+}
+
+// Edit computes the rewritten content of every file touched by changes
+func Edit(changes []Change) ([]FileEdit, error) {
+	byFile := make(map[string][]Change)
+	for _, c := range changes {
+		byFile[c.File] = append(byFile[c.File], c)
+	}
+
+	paths := make([]string, 0, len(byFile))
+	for path := range byFile {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var edits []FileEdit
+	for _, path := range paths {
+		before, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		after := before
+		for _, c := range byFile[path] {
+			after, err = SetHostPort(after, c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to edit %s: %w", path, err)
+			}
+		}
+		edits = append(edits, FileEdit{Path: path, Before: before, After: after})
+	}
+	return edits, nil
+}
+
+// SetHostPort rewrites every ports entry of the change's service that
+// publishes its host port, protocol and host IP so that it publishes the
+// new port instead. Only the affected tokens change; comments, quoting and
+// blank lines elsewhere are preserved byte for byte.
+func SetHostPort(data []byte, c Change) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var edits []tokenEdit
+	for _, entry := range portEntries(&doc) {
+		if entry.Service != c.Service {
+			continue
+		}
+		node := entry.Node()
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			continue
+		}
+
+		switch node.Kind {
+		case yaml.ScalarNode:
+			b := scanner.ParsePort(value, c.Service, "")
+			if !c.moves(b) {
+				continue
+			}
+			quote := `"`
+			if node.Style == yaml.SingleQuotedStyle {
+				quote = "'"
+			}
+			text := shortSyntax(b, c.To, strings.Contains(node.Value, "/"))
+			edits = append(edits, tokenEdit{node: node, text: quote + text + quote})
+
+		case yaml.MappingNode:
+			published := mappingValue(node, "published")
+			if published == nil || published.Value != strconv.Itoa(c.From) {
+				continue
+			}
+			if bindings, _ := scanner.ParseEntry(value, c.Service, ""); len(bindings) != 1 || !c.moves(&bindings[0]) {
+				continue
+			}
+			text := strconv.Itoa(c.To)
+			if published.Style == yaml.DoubleQuotedStyle {
+				text = `"` + text + `"`
+			} else if published.Style == yaml.SingleQuotedStyle {
+				text = "'" + text + "'"
+			}
+			edits = append(edits, tokenEdit{node: published, text: text})
+		}
+	}
+
+	if len(edits) == 0 {
+		return nil, fmt.Errorf("no port %d found for service %s", c.From, c.Service)
+	}
+	return replaceTokens(data, edits)
+}
+
+// tokenEdit replaces the source text of a scalar node
+type tokenEdit struct {
+	node *yaml.Node
+	text string
+}
+
+// rawToken returns the scalar as written in the source, including quotes
+func rawToken(node *yaml.Node) string {
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		return `"` + node.Value + `"`
+	case yaml.SingleQuotedStyle:
+		return "'" + node.Value + "'"
+	}
+	return node.Value
+}
+
+// replaceTokens applies edits at their node positions, last first so
+// earlier positions stay valid
+func replaceTokens(data []byte, edits []tokenEdit) ([]byte, error) {
+	lines := strings.Split(string(data), "\n")
+
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].node.Line != edits[j].node.Line {
+			return edits[i].node.Line > edits[j].node.Line
+		}
+		return edits[i].node.Column > edits[j].node.Column
+	})
+
+	for _, e := range edits {
+		line, col := e.node.Line-1, e.node.Column-1
+		if line < 0 || line >= len(lines) {
+			return nil, fmt.Errorf("line %d out of range", e.node.Line)
+		}
+		runes := []rune(lines[line])
+		raw := []rune(rawToken(e.node))
+		if col < 0 || col+len(raw) > len(runes) || string(runes[col:col+len(raw)]) != string(raw) {
+			return nil, fmt.Errorf("line %d: cannot locate %q", e.node.Line, string(raw))
+		}
+		lines[line] = string(runes[:col]) + e.text + string(runes[col+len(raw):])
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// shortSyntax renders a binding in short syntax with a new host port
+func shortSyntax(b *scanner.PortBinding, hostPort int, withProtocol bool) string {
+	s := fmt.Sprintf("%d:%d", hostPort, b.ContainerPort)
+	if b.HostIP != "" {
+		s = b.HostIP + ":" + s
+	}
+	if withProtocol {
+		s += "/" + b.Protocol
+	}
+	return s
+}
+
+// Fix lists the planned changes, confirms them according to opts and writes
+// the edits. It reports whether any file was written.
+func Fix(changes []Change, edits []FileEdit, opts FixOptions) (bool, error) {
+	if len(changes) == 0 {
+		fmt.Fprintln(opts.Out, "No port changes to apply.")
+		return false, nil
+	}
+
+	fmt.Fprintln(opts.Out, "Planned port changes:")
+	for _, c := range changes {
+		fmt.Fprintf(opts.Out, "  %s (%s): %d → %d\n", c.Service, filepath.Base(c.File), c.From, c.To)
+	}
+
+	if opts.DryRun {
+		for _, e := range edits {
+			fmt.Fprint(opts.Out, Diff(e.Path, e.Before, e.After))
+		}
+		return false, nil
+	}
+
+	if !opts.AssumeYes {
+		if !opts.Interactive {
+			return false, ErrConfirmationRequired
+		}
+		fmt.Fprint(opts.Out, "Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(opts.In).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return false, ErrAborted
+		}
+	}
+
+	for _, e := range edits {
+		if opts.Backup {
+			if err := os.WriteFile(e.Path+".bak", e.Before, 0644); err != nil {
+				return false, fmt.Errorf("failed to back up %s: %w", e.Path, err)
+			}
+		}
+		if err := os.WriteFile(e.Path, e.After, 0644); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(opts.Out, "Updated %s\n", e.Path)
+	}
+	return true, nil
+}
@@ -0,0 +1,255 @@
+package rewrite
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+const collidingCompose = `services:
+  api:
+    image: node
+    ports:
+      - "8080:3000" # api
+
+  web:
+    image: nginx
+    ports:
+      - '8080:80'
+      - target: 443
+        published: 8443
+`
+
+func writeFixture(t *testing.T) (string, []Change) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(path, []byte(collidingCompose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, []Change{{File: path, Service: "web", From: 8080, To: 8081}}
+}
+
+func TestSetHostPort_PreservesLayout(t *testing.T) {
+	out, err := SetHostPort([]byte(collidingCompose), Change{Service: "web", From: 8080, To: 8081})
+	if err != nil {
+		t.Fatalf("SetHostPort failed: %v", err)
+	}
+	want := strings.Replace(collidingCompose, "'8080:80'", "'8081:80'", 1)
+	if string(out) != want {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+
+	out, err = SetHostPort([]byte(collidingCompose), Change{Service: "web", From: 8443, To: 9443})
+	if err != nil {
+		t.Fatalf("SetHostPort failed: %v", err)
+	}
+	if !strings.Contains(string(out), "published: 9443") {
+		t.Errorf("Long syntax published port not updated:\n%s", out)
+	}
+
+	if _, err := SetHostPort([]byte(collidingCompose), Change{Service: "web", From: 3000, To: 3001}); err == nil {
+		t.Error("Expected error for a port the service does not publish")
+	}
+}
+
+func TestEdit_OnlyMovesTheCollidingProtocolAndHostIP(t *testing.T) {
+	dir := t.TempDir()
+	compose := `services:
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - "8080:80/udp"
+      - "127.0.0.1:8080:81"
+      - target: 82
+        published: 8080
+        protocol: udp
+`
+	path := filepath.Join(dir, "docker-compose.yml")
+	if err := os.WriteFile(path, []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	next := func(port int, taken map[int]bool) int {
+		for taken[port] {
+			port++
+		}
+		return port
+	}
+	changes := PlanFixesKeeping(result, next, func(issue scanner.Issue) int {
+		for i, b := range issue.Bindings {
+			if b.Service == "api" {
+				return i
+			}
+		}
+		return 0
+	})
+	if len(changes) != 2 {
+		t.Fatalf("Expected the two tcp entries of web to move, got %+v", changes)
+	}
+	for _, c := range changes {
+		if c.Protocol != "tcp" {
+			t.Errorf("Change = %+v, want tcp", c)
+		}
+	}
+
+	edits, err := Edit(changes)
+	if err != nil {
+		t.Fatalf("Edit failed: %v", err)
+	}
+	after := string(edits[0].After)
+	for _, kept := range []string{`"8080:80/udp"`, "published: 8080", `"8080:3000"`} {
+		if !strings.Contains(after, kept) {
+			t.Errorf("Expected %s to be left alone, got:\n%s", kept, after)
+		}
+	}
+	if strings.Contains(after, `"8080:80"`) || strings.Contains(after, `"127.0.0.1:8080:81"`) {
+		t.Errorf("Expected the colliding tcp entries to move, got:\n%s", after)
+	}
+
+	// A change on one host IP leaves the other entries of the port alone
+	out, err := SetHostPort([]byte(compose), Change{Service: "web", HostIP: "127.0.0.1", From: 8080, To: 9000})
+	if err != nil {
+		t.Fatalf("SetHostPort failed: %v", err)
+	}
+	if want := strings.Replace(compose, "127.0.0.1:8080:81", "127.0.0.1:9000:81", 1); string(out) != want {
+		t.Errorf("Expected only the 127.0.0.1 entry to move, got:\n%s", out)
+	}
+}
+
+func TestFix_DryRun(t *testing.T) {
+	path, changes := writeFixture(t)
+	edits, err := Edit(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	written, err := Fix(changes, edits, FixOptions{DryRun: true, Out: &out})
+	if err != nil || written {
+		t.Fatalf("Dry run should not write: written=%v err=%v", written, err)
+	}
+
+	if !strings.Contains(out.String(), "web (docker-compose.yml): 8080 → 8081") {
+		t.Errorf("Planned change not listed:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "+      - '8081:80'") {
+		t.Errorf("Diff not printed:\n%s", out.String())
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != collidingCompose {
+		t.Error("Dry run modified the file")
+	}
+}
+
+func TestFix_AssumeYes(t *testing.T) {
+	path, changes := writeFixture(t)
+	edits, err := Edit(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	written, err := Fix(changes, edits, FixOptions{AssumeYes: true, Out: &out})
+	if err != nil || !written {
+		t.Fatalf("Expected files to be written: written=%v err=%v", written, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "'8081:80'") {
+		t.Errorf("File not updated:\n%s", data)
+	}
+}
+
+func TestFix_Confirmation(t *testing.T) {
+	path, changes := writeFixture(t)
+	edits, err := Edit(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a terminal and without -y, nothing is written
+	if _, err := Fix(changes, edits, FixOptions{Out: &bytes.Buffer{}}); err != ErrConfirmationRequired {
+		t.Errorf("Expected ErrConfirmationRequired, got %v", err)
+	}
+
+	opts := FixOptions{Interactive: true, In: strings.NewReader("n\n"), Out: &bytes.Buffer{}}
+	if _, err := Fix(changes, edits, opts); err != ErrAborted {
+		t.Errorf("Expected ErrAborted, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != collidingCompose {
+		t.Error("Declined fix modified the file")
+	}
+
+	opts.In = strings.NewReader("yes\n")
+	if written, err := Fix(changes, edits, opts); err != nil || !written {
+		t.Errorf("Confirmed fix should write: written=%v err=%v", written, err)
+	}
+}
+
+func TestPlanFixesKeeping(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"docker-compose.yml": "services:\n  web:\n    image: nginx\n    ports:\n      - \"8080:80\"\n",
+		"tools/compose.yml":  "services:\n  admin:\n    image: admin\n    ports:\n      - \"8080:9000\"\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	next := func(port int, taken map[int]bool) int { return port + 1 }
+
+	changes := PlanFixes(result, next)
+	if len(changes) != 1 {
+		t.Fatalf("Expected one change, got %+v", changes)
+	}
+	moved := changes[0].Service
+
+	changes = PlanFixesKeeping(result, next, func(scanner.Issue) int { return 1 })
+	if len(changes) != 1 || changes[0].Service == moved {
+		t.Errorf("Keeping the second binding should move the other service, got %+v", changes)
+	}
+}
+
+func TestFix_Backup(t *testing.T) {
+	path, changes := writeFixture(t)
+	edits, err := Edit(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Fix(changes, edits, FixOptions{AssumeYes: true, Backup: true, Out: &bytes.Buffer{}}); err != nil {
+		t.Fatalf("Fix failed: %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatalf("Expected a backup: %v", err)
+	}
+	if string(backup) != collidingCompose {
+		t.Errorf("Backup should hold the original content, got:\n%s", backup)
+	}
+}
@@ -0,0 +1,146 @@
+// Package rewrite edits compose files in place while preserving comments
+// and key order
+package rewrite
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+	"gopkg.in/yaml.v3"
+)
+
+// portEntry locates one item of a service's ports sequence
+type portEntry struct {
+	Service string
+	Seq     *yaml.Node
+	Index   int
+}
+
+// Node returns the entry's YAML node
+func (e portEntry) Node() *yaml.Node {
+	return e.Seq.Content[e.Index]
+}
+
+// Normalize rewrites every service's short-syntax ports in a compose
+// document into the canonical long syntax. It returns the rewritten document
+// and the number of entries converted; when nothing is converted the input is
+// returned untouched so repeated runs are no-ops.
+func Normalize(data []byte, file string) ([]byte, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+
+	converted := 0
+	for _, entry := range portEntries(&doc) {
+		node := entry.Node()
+		if node.Kind != yaml.ScalarNode || strings.Contains(node.Value, "${") {
+			// Already long syntax, or depends on an unresolved variable
+			continue
+		}
+
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			continue
+		}
+		binding := scanner.ParsePort(value, entry.Service, file)
+		if binding == nil {
+			continue
+		}
+
+		long := longSyntax(binding)
+		long.HeadComment = node.HeadComment
+		long.Content[1].LineComment = node.LineComment
+		long.FootComment = node.FootComment
+		entry.Seq.Content[entry.Index] = long
+		converted++
+	}
+
+	if converted == 0 {
+		return data, 0, nil
+	}
+
+	out, err := encode(&doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, converted, nil
+}
+
+// longSyntax builds the canonical long-syntax mapping for a binding
+func longSyntax(b *scanner.PortBinding) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	add := func(key, tag, value string) {
+		m.Content = append(m.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+	}
+
+	add("target", "!!int", strconv.Itoa(b.ContainerPort))
+	if !b.RandomHostPort {
+		add("published", "!!int", strconv.Itoa(b.HostPort))
+	}
+	add("protocol", "!!str", b.Protocol)
+	if b.HostIP != "" {
+		add("host_ip", "!!str", b.HostIP)
+	}
+	return m
+}
+
+// portEntries returns every item of every service's ports sequence
+func portEntries(doc *yaml.Node) []portEntry {
+	var entries []portEntry
+
+	services := mappingValue(documentRoot(doc), "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+		ports := mappingValue(services.Content[i+1], "ports")
+		if ports == nil || ports.Kind != yaml.SequenceNode {
+			continue
+		}
+		for j := range ports.Content {
+			entries = append(entries, portEntry{Service: name, Seq: ports, Index: j})
+		}
+	}
+
+	return entries
+}
+
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func encode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package rewrite
+
+import (
+	"strings"
+	"testing"
+)
+
+const shortCompose = `services:
+  web:
+    image: nginx
+    ports:
+      # public entrypoint
+      - "8080:80"
+      - "127.0.0.1:5353:53/udp"
+      - "${API_PORT}:3000"
+`
+
+func TestNormalize_ShortToLong(t *testing.T) {
+	out, converted, err := Normalize([]byte(shortCompose), "docker-compose.yml")
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	if converted != 2 {
+		t.Errorf("Expected 2 converted ports, got %d", converted)
+	}
+
+	want := `services:
+  web:
+    image: nginx
+    ports:
+      # public entrypoint
+      - target: 80
+        published: 8080
+        protocol: tcp
+      - target: 53
+        published: 5353
+        protocol: udp
+        host_ip: 127.0.0.1
+      - "${API_PORT}:3000"
+`
+	if string(out) != want {
+		t.Errorf("Normalize output mismatch:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestNormalize_Idempotent(t *testing.T) {
+	once, _, err := Normalize([]byte(shortCompose), "docker-compose.yml")
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	twice, converted, err := Normalize(once, "docker-compose.yml")
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	if converted != 0 {
+		t.Errorf("Second run converted %d ports, want 0", converted)
+	}
+	if string(once) != string(twice) {
+		t.Errorf("Second run changed output:\n%s", Diff("docker-compose.yml", once, twice))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if d := Diff("a.yml", []byte("x\n"), []byte("x\n")); d != "" {
+		t.Errorf("Diff of identical input should be empty, got %q", d)
+	}
+
+	d := Diff("a.yml", []byte("a\nb\nc\n"), []byte("a\nB\nc\n"))
+	for _, want := range []string{"--- a.yml", "+++ a.yml", "@@ -1,3 +1,3 @@", "-b", "+B", " a", " c"} {
+		if !strings.Contains(d, want) {
+			t.Errorf("Diff missing %q:\n%s", want, d)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultDockerHost is the daemon socket used when DOCKER_HOST is unset
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// apiTimeout bounds each request to the Docker API
+const apiTimeout = 5 * time.Second
+
+// apiContainer is an entry of the Docker API's /containers/json response
+type apiContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+	Ports  []struct {
+		IP          string `json:"IP"`
+		PrivatePort int    `json:"PrivatePort"`
+		PublicPort  int    `json:"PublicPort"`
+		Type        string `json:"Type"`
+	} `json:"Ports"`
+	Created int64 `json:"Created"`
+}
+
+// apiEngine lists containers through the Docker Engine HTTP API
+type apiEngine struct {
+	client *http.Client
+	base   string // URL the API paths are appended to
+}
+
+// NewAPIEngine returns an engine talking to the Docker API at host, such as
+// unix:///var/run/docker.sock or tcp://10.0.0.5:2375. An empty host uses
+// DOCKER_HOST, then DefaultDockerHost.
+func NewAPIEngine(host string) (ContainerEngine, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = DefaultDockerHost
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &apiEngine{client: &http.Client{Transport: transport, Timeout: apiTimeout}, base: "http://docker"}, nil
+	case "tcp", "http":
+		return &apiEngine{client: &http.Client{Timeout: apiTimeout}, base: "http://" + u.Host}, nil
+	case "https":
+		return &apiEngine{client: &http.Client{Timeout: apiTimeout}, base: "https://" + u.Host}, nil
+	}
+	return nil, fmt.Errorf("unsupported docker host %q (want unix://, tcp:// or http(s)://)", host)
+}
+
+// ScanRuntimeWithClient scans the containers of the Docker API at baseURL
+// using client, e.g. an httptest server and its client
+func ScanRuntimeWithClient(client *http.Client, baseURL string) (*RuntimeResult, error) {
+	return ScanRuntimeWithEngine(&apiEngine{client: client, base: strings.TrimSuffix(baseURL, "/")})
+}
+
+func (e *apiEngine) Name() string {
+	return "docker-api"
+}
+
+func (e *apiEngine) Available() bool {
+	resp, err := e.client.Get(e.base + "/_ping")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func (e *apiEngine) ListContainers() ([]Container, error) {
+	return e.ListFilteredContainers(RuntimeFilter{})
+}
+
+// ListFilteredContainers lists the running containers passing filter,
+// through the filters query of /containers/json
+func (e *apiEngine) ListFilteredContainers(filter RuntimeFilter) ([]Container, error) {
+	resp, err := e.client.Get(e.base + "/containers/json" + filter.apiQuery())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("docker API returned %s", resp.Status)
+	}
+
+	var entries []apiContainer
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse docker API response: %w", err)
+	}
+
+	containers := make([]Container, 0, len(entries))
+	for _, ac := range entries {
+		c := Container{
+			ID:        ac.ID,
+			Image:     ac.Image,
+			State:     ac.State,
+			Labels:    ac.Labels,
+			CreatedAt: time.Unix(ac.Created, 0),
+		}
+		if len(c.ID) > 12 {
+			c.ID = c.ID[:12]
+		}
+		if len(ac.Names) > 0 {
+			c.Name = strings.TrimPrefix(ac.Names[0], "/")
+		}
+		if c.Labels == nil {
+			c.Labels = make(map[string]string)
+		}
+		for _, p := range ac.Ports {
+			protocol := p.Type
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			c.Ports = append(c.Ports, ContainerPort{
+				HostIP:        p.IP,
+				HostPort:      p.PublicPort,
+				ContainerPort: p.PrivatePort,
+				Protocol:      protocol,
+			})
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
@@ -0,0 +1,190 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// composeContainer is the JSON structure from docker compose ps
+type composeContainer struct {
+	ID         string `json:"ID"`
+	Name       string `json:"Name"`
+	Image      string `json:"Image"`
+	Service    string `json:"Service"`
+	State      string `json:"State"`
+	Labels     string `json:"Labels"`
+	Publishers []struct {
+		URL           string `json:"URL"`
+		TargetPort    int    `json:"TargetPort"`
+		PublishedPort int    `json:"PublishedPort"`
+		Protocol      string `json:"Protocol"`
+	} `json:"Publishers"`
+}
+
+// ScanProjectRuntime scans only the containers of the compose project in
+// dir, as reported by docker compose ps, so containers from unrelated
+// projects are ignored
+func ScanProjectRuntime(dir string, policy RetryPolicy) (*RuntimeResult, error) {
+	return scanProjectRuntime(execCommand, policy, dir)
+}
+
+// ScanProjectRuntimeWithEngine scans the compose project in dir through the
+// compose subcommand of engine, which must be one returned by NewEngine or
+// DetectEngine. A nil engine is reported as not running.
+func ScanProjectRuntimeWithEngine(engine ContainerEngine, dir string) (*RuntimeResult, error) {
+	if engine == nil {
+		return ScanRuntimeWithEngine(nil)
+	}
+	cli, ok := engine.(*cliEngine)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot list compose project containers", engine.Name())
+	}
+	return scanProjectEngine(cli, dir)
+}
+
+func scanProjectRuntime(run commandRunner, policy RetryPolicy, dir string) (*RuntimeResult, error) {
+	return scanProjectEngine(&cliEngine{name: "docker", run: run, policy: policy}, dir)
+}
+
+func scanProjectEngine(engine *cliEngine, dir string) (*RuntimeResult, error) {
+	result := &RuntimeResult{
+		UsedPorts: make(map[int][]Container),
+		ScanTime:  time.Now(),
+	}
+
+	// Check if the engine is available
+	if !engine.Available() {
+		result.DockerRunning = false
+		return result, nil
+	}
+	result.DockerRunning = true
+	result.Engine = engine.name
+
+	output, err := runWithRetry(engine.run, engine.policy, engine.name, "compose", "--project-directory", dir, "ps", "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project containers: %w", err)
+	}
+
+	containers, err := parseComposePs(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s compose ps output: %w", engine.name, err)
+	}
+	for _, cc := range containers {
+		container := Container{
+			ID:     cc.ID,
+			Name:   cc.Name,
+			Image:  cc.Image,
+			State:  cc.State,
+			Labels: parseLabels(cc.Labels),
+		}
+		if len(container.ID) > 12 {
+			container.ID = container.ID[:12]
+		}
+		for _, p := range cc.Publishers {
+			if p.PublishedPort == 0 {
+				continue
+			}
+			container.Ports = append(container.Ports, ContainerPort{
+				HostIP:        p.URL,
+				HostPort:      p.PublishedPort,
+				ContainerPort: p.TargetPort,
+				Protocol:      p.Protocol,
+			})
+		}
+		result.addContainer(container)
+	}
+
+	return result, nil
+}
+
+// parseComposePs accepts both output styles of docker compose ps --format
+// json: a single array (older releases) or one object per line
+func parseComposePs(output []byte) ([]composeContainer, error) {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	var containers []composeContainer
+	if strings.HasPrefix(trimmed, "[") {
+		err := json.Unmarshal([]byte(trimmed), &containers)
+		return containers, err
+	}
+
+	for _, line := range strings.Split(trimmed, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		var cc composeContainer
+		if err := json.Unmarshal([]byte(line), &cc); err != nil {
+			return nil, err
+		}
+		containers = append(containers, cc)
+	}
+	return containers, nil
+}
+
+// projectNameInvalid matches the characters docker compose drops from a
+// directory name when deriving the default project name
+var projectNameInvalid = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// ProjectName returns the project name docker compose uses by default for
+// compose files in dir: its base name, lowercased, without characters
+// outside [a-z0-9_-]
+func ProjectName(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return projectNameInvalid.ReplaceAllString(strings.ToLower(filepath.Base(dir)), "")
+}
+
+// MatchesService reports whether container c runs the given compose service
+// of project. Compose labels decide when present: the service label must
+// match exactly and, when both are known, so must the project label.
+// Unlabelled containers match by the names compose generates,
+// <project>-<service>-N or <project>_<service>_N, or by the service name
+// itself, so "api" never matches a container named "api-gateway".
+func MatchesService(c Container, project, service string) bool {
+	if label, ok := c.Labels["com.docker.compose.service"]; ok {
+		if !strings.EqualFold(label, service) {
+			return false
+		}
+		if label, ok := c.Labels["com.docker.compose.project"]; ok && project != "" {
+			return strings.EqualFold(label, project)
+		}
+		return true
+	}
+
+	name := strings.ToLower(c.Name)
+	service = strings.ToLower(service)
+	if name == service {
+		return true
+	}
+	if project == "" {
+		return false
+	}
+	for _, sep := range []string{"-", "_"} {
+		prefix := strings.ToLower(project) + sep + service + sep
+		if suffix, ok := strings.CutPrefix(name, prefix); ok && isReplica(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReplica reports whether s is a compose replica number
+func isReplica(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package runtime
+
+import (
+	"fmt"
+	"time"
+)
+
+// Engines lists the container engines DetectEngine tries, in order of
+// preference
+var Engines = []string{"docker", "podman"}
+
+// ContainerEngine is a container runtime that can list its running
+// containers, such as the docker or podman CLI
+type ContainerEngine interface {
+	// Name identifies the engine, e.g. "docker"
+	Name() string
+	// Available reports whether the engine answers at all
+	Available() bool
+	// ListContainers returns the running containers and their ports
+	ListContainers() ([]Container, error)
+}
+
+// cliEngine lists containers through a docker-compatible CLI
+type cliEngine struct {
+	name   string
+	run    commandRunner
+	policy RetryPolicy
+}
+
+// NewEngine returns the CLI engine of the given name, one of Engines, or the
+// Docker API engine for "docker-api"
+func NewEngine(name string, policy RetryPolicy) (ContainerEngine, error) {
+	if name == "docker-api" {
+		return NewAPIEngine("")
+	}
+	for _, engine := range Engines {
+		if name == engine {
+			return &cliEngine{name: name, run: execCommand, policy: policy}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown container engine %q (want docker, podman or docker-api)", name)
+}
+
+// DetectEngine returns the Docker API when its socket answers, since it
+// needs no CLI on PATH, and otherwise the first of Engines that is
+// available, preferring docker. It returns nil when nothing is.
+func DetectEngine(policy RetryPolicy) ContainerEngine {
+	if api, err := NewAPIEngine(""); err == nil && api.Available() {
+		return api
+	}
+	return DetectCLIEngine(policy)
+}
+
+// DetectCLIEngine returns the first of Engines whose CLI is available, for
+// commands such as compose ps that the Docker API has no equivalent of
+func DetectCLIEngine(policy RetryPolicy) ContainerEngine {
+	return detectEngine(execCommand, policy)
+}
+
+func detectEngine(run commandRunner, policy RetryPolicy) ContainerEngine {
+	for _, name := range Engines {
+		engine := &cliEngine{name: name, run: run, policy: policy}
+		if engine.Available() {
+			return engine
+		}
+	}
+	return nil
+}
+
+func (e *cliEngine) Name() string {
+	return e.name
+}
+
+func (e *cliEngine) Available() bool {
+	_, err := runWithRetry(e.run, e.policy, e.name, "version")
+	return err == nil
+}
+
+func (e *cliEngine) ListContainers() ([]Container, error) {
+	return e.ListFilteredContainers(RuntimeFilter{})
+}
+
+// ListFilteredContainers lists the running containers passing filter,
+// through ps --filter
+func (e *cliEngine) ListFilteredContainers(filter RuntimeFilter) ([]Container, error) {
+	args := append([]string{"ps", "--format", "{{json .}}"}, filter.psArgs()...)
+	output, err := runWithRetry(e.run, e.policy, e.name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parsePs(output), nil
+}
+
+// filteringEngine is a ContainerEngine that can filter the containers it
+// lists itself
+type filteringEngine interface {
+	ListFilteredContainers(filter RuntimeFilter) ([]Container, error)
+}
+
+// ScanRuntimeWithEngine scans the running containers of engine. A nil or
+// unavailable engine is reported with DockerRunning false rather than an
+// error, so runtime checks degrade gracefully.
+func ScanRuntimeWithEngine(engine ContainerEngine) (*RuntimeResult, error) {
+	return ScanRuntimeWithEngineFilter(engine, RuntimeFilter{})
+}
+
+// ScanRuntimeWithEngineFilter scans the running containers of engine that
+// pass filter, as ScanRuntimeWithEngine does
+func ScanRuntimeWithEngineFilter(engine ContainerEngine, filter RuntimeFilter) (*RuntimeResult, error) {
+	result := &RuntimeResult{
+		UsedPorts: make(map[int][]Container),
+		ScanTime:  time.Now(),
+	}
+
+	if engine == nil || !engine.Available() {
+		result.DockerRunning = false
+		return result, nil
+	}
+	result.DockerRunning = true
+	result.Engine = engine.Name()
+
+	var containers []Container
+	var err error
+	if fe, ok := engine.(filteringEngine); ok && !filter.IsZero() {
+		containers, err = fe.ListFilteredContainers(filter)
+	} else {
+		containers, err = engine.ListContainers()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, c := range containers {
+		if filter.Matches(c) {
+			result.addContainer(c)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// ProjectLabel is the label docker compose sets to a container's project
+const ProjectLabel = "com.docker.compose.project"
+
+// RuntimeFilter narrows a runtime scan to some of the running containers.
+// The zero RuntimeFilter matches every container.
+type RuntimeFilter struct {
+	Project string // compose project, matched against ProjectLabel
+	Name    string // substring of the container name
+}
+
+// IsZero reports whether f matches every container
+func (f RuntimeFilter) IsZero() bool {
+	return f.Project == "" && f.Name == ""
+}
+
+// Matches reports whether container c passes f. Engines filter on their
+// side too; this keeps engines without filter support consistent.
+func (f RuntimeFilter) Matches(c Container) bool {
+	if f.Project != "" && c.Labels[ProjectLabel] != f.Project {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(c.Name, f.Name) {
+		return false
+	}
+	return true
+}
+
+// psArgs returns the ps --filter arguments of f for docker and podman
+func (f RuntimeFilter) psArgs() []string {
+	var args []string
+	if f.Project != "" {
+		args = append(args, "--filter", "label="+ProjectLabel+"="+f.Project)
+	}
+	if f.Name != "" {
+		args = append(args, "--filter", "name="+f.Name)
+	}
+	return args
+}
+
+// apiQuery returns the filters query of f for the Docker API's
+// /containers/json, or "" for the zero filter
+func (f RuntimeFilter) apiQuery() string {
+	filters := make(map[string][]string)
+	if f.Project != "" {
+		filters["label"] = []string{ProjectLabel + "=" + f.Project}
+	}
+	if f.Name != "" {
+		filters["name"] = []string{f.Name}
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(filters)
+	return "?filters=" + url.QueryEscape(string(data))
+}
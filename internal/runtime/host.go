@@ -0,0 +1,160 @@
+package runtime
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// servicesFile is the services database used to name host ports
+var servicesFile = "/etc/services"
+
+// hostDaemons names host-level daemons that commonly own a port on Linux
+// desktops, which /etc/services alone does not reveal
+var hostDaemons = map[string]string{
+	"53/udp":   "systemd-resolved or dnsmasq",
+	"53/tcp":   "systemd-resolved or dnsmasq",
+	"67/udp":   "a DHCP server",
+	"123/udp":  "chrony or ntpd",
+	"631/tcp":  "CUPS",
+	"5353/udp": "avahi-daemon (mDNS)",
+}
+
+// HostConflict describes a host port that is already bound outside Docker
+type HostConflict struct {
+	Port     int
+	Protocol string
+	HostIP   string
+	Owner    string // likely host service, empty when unknown
+	Message  string
+	ProbeErr error // set when the port could not be probed at all
+}
+
+// probeReason describes why a port could not be probed
+func probeReason(err error) string {
+	switch {
+	case errors.Is(err, syscall.EACCES):
+		return "permission denied (binding it needs privileges)"
+	case errors.Is(err, syscall.EADDRNOTAVAIL):
+		return "the host IP is not an address of this machine"
+	}
+	return err.Error()
+}
+
+// PortInUse reports whether port/protocol is already bound on hostIP (all
+// interfaces when empty). A port that could not be probed, e.g. a
+// privileged port for an unprivileged user, does not count as in use; see
+// ProbePort.
+func PortInUse(port int, protocol, hostIP string) bool {
+	inUse, _ := ProbePort(port, protocol, hostIP)
+	return inUse
+}
+
+// ProbePort tries to bind port/protocol on hostIP (all interfaces when
+// empty). It reports the port in use only when the address is already
+// taken; any other failure, such as EACCES for a port below 1024 or
+// EADDRNOTAVAIL for an address that is not local, is returned as an error
+// since it says nothing about other listeners.
+func ProbePort(port int, protocol, hostIP string) (bool, error) {
+	// Compose writes IPv6 host IPs in brackets, as in "[::1]:8080:80"
+	addr := net.JoinHostPort(strings.Trim(hostIP, "[]"), strconv.Itoa(port))
+	var err error
+	if protocol == "udp" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", addr); err == nil {
+			conn.Close()
+		}
+	} else {
+		var listener net.Listener
+		if listener, err = net.Listen("tcp", addr); err == nil {
+			listener.Close()
+		}
+	}
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.Is(err, syscall.EADDRINUSE):
+		return true, nil
+	}
+	return false, err
+}
+
+// CanBindPrivileged reports whether the current user may bind ports below
+// 1024. It probes loopback port 1, which is almost never in use, so only a
+// permission error counts against it.
+func CanBindPrivileged() bool {
+	listener, err := net.Listen("tcp", "127.0.0.1:1")
+	if err != nil {
+		return !errors.Is(err, syscall.EACCES)
+	}
+	listener.Close()
+	return true
+}
+
+// CheckHostPort probes a port and describes the conflict when it is taken.
+// A port that could not be probed is described with ProbeErr set, and is
+// not a conflict.
+func CheckHostPort(port int, protocol, hostIP string) *HostConflict {
+	inUse, err := ProbePort(port, protocol, hostIP)
+	if err != nil {
+		return &HostConflict{
+			Port:     port,
+			Protocol: protocol,
+			HostIP:   hostIP,
+			ProbeErr: err,
+			Message:  fmt.Sprintf("Could not probe %s port %d on the host: %v", strings.ToUpper(protocol), port, probeReason(err)),
+		}
+	}
+	if !inUse {
+		return nil
+	}
+
+	c := &HostConflict{
+		Port:     port,
+		Protocol: protocol,
+		HostIP:   hostIP,
+		Owner:    PortOwner(port, protocol),
+	}
+	c.Message = fmt.Sprintf("%s port %d is already in use on the host", strings.ToUpper(protocol), port)
+	if c.Owner != "" {
+		c.Message += fmt.Sprintf(" (likely %s)", c.Owner)
+	}
+	return c
+}
+
+// PortOwner names the host service likely to own port/protocol, using
+// well-known daemons first and the services database second
+func PortOwner(port int, protocol string) string {
+	key := fmt.Sprintf("%d/%s", port, protocol)
+	if daemon, ok := hostDaemons[key]; ok {
+		return daemon
+	}
+	return lookupService(key)
+}
+
+// lookupService returns the /etc/services name for a "port/protocol" key
+func lookupService(key string) string {
+	f, err := os.Open(servicesFile)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == key {
+			return fields[0]
+		}
+	}
+	return ""
+}
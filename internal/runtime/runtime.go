@@ -3,9 +3,11 @@ package runtime
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 )
@@ -33,10 +35,11 @@ type ContainerPort struct {
 // RuntimeResult contains runtime scan results
 type RuntimeResult struct {
 	Containers    []Container
-	UsedPorts     map[int][]Container     // port -> containers using it
+	UsedPorts     map[int][]Container // port -> containers using it
 	Conflicts     []RuntimeConflict
 	ScanTime      time.Time
-	DockerRunning bool
+	DockerRunning bool   // a container engine answered, whichever it is
+	Engine        string `json:",omitempty"` // engine that listed the containers
 }
 
 // RuntimeConflict describes a conflict between compose definition and runtime
@@ -46,43 +49,151 @@ type RuntimeConflict struct {
 	RuntimeInfo    string
 	Type           string // "already_in_use", "not_running", "mismatch"
 	Message        string
+	Remediation    string
 }
 
-// dockerContainer is the JSON structure from docker ps
+// NewAlreadyInUseConflict describes a compose service whose host port is
+// already published by a running container
+func NewAlreadyInUseConflict(port int, service string, c Container) RuntimeConflict {
+	return RuntimeConflict{
+		Port:           port,
+		ComposeService: service,
+		RuntimeInfo:    c.Name,
+		Type:           "already_in_use",
+		Message:        fmt.Sprintf("Port %d (for %s) is already used by container %s", port, service, c.Name),
+		Remediation:    fmt.Sprintf("Run `docker stop %s` or change the host port of %s", c.Name, service),
+	}
+}
+
+// dockerContainer is the JSON structure from docker ps and podman ps.
+// Docker renders Names, Ports and Labels as strings; Podman uses a list of
+// names, a list of port mappings and a label object.
 type dockerContainer struct {
-	ID      string `json:"Id"`
-	Names   string `json:"Names"`
-	Image   string `json:"Image"`
-	State   string `json:"State"`
-	Ports   string `json:"Ports"`
-	Labels  string `json:"Labels"`
-	Created string `json:"CreatedAt"`
+	ID      string          `json:"Id"`
+	Names   json.RawMessage `json:"Names"`
+	Image   string          `json:"Image"`
+	State   string          `json:"State"`
+	Ports   json.RawMessage `json:"Ports"`
+	Labels  json.RawMessage `json:"Labels"`
+	Created string          `json:"CreatedAt"`
 }
 
-// ScanRuntime scans for currently running containers
-func ScanRuntime() (*RuntimeResult, error) {
-	result := &RuntimeResult{
-		UsedPorts: make(map[int][]Container),
-		ScanTime:  time.Now(),
+// podmanPort is a port mapping in podman ps output. Range is the number of
+// consecutive ports the mapping covers.
+type podmanPort struct {
+	HostIP        string `json:"host_ip"`
+	ContainerPort int    `json:"container_port"`
+	HostPort      int    `json:"host_port"`
+	Range         int    `json:"range"`
+	Protocol      string `json:"protocol"`
+}
+
+// RetryPolicy bounds how often transient docker command failures are retried
+type RetryPolicy struct {
+	Attempts int           // total attempts, including the first
+	Backoff  time.Duration // delay before the first retry, doubled after each
+}
+
+// DefaultRetryPolicy is used by ScanRuntime
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Backoff: 250 * time.Millisecond}
+
+// commandRunner executes an engine command and returns its stdout
+type commandRunner func(name string, args ...string) ([]byte, error)
+
+func execCommand(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// errDaemonNotRunning marks failures that retrying cannot fix
+var errDaemonNotRunning = errors.New("container engine not running")
+
+// classifyError separates a missing CLI or unreachable daemon from
+// transient exec failures worth retrying
+func classifyError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return errDaemonNotRunning
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr := string(exitErr.Stderr)
+		if strings.Contains(stderr, "Cannot connect to the Docker daemon") ||
+			strings.Contains(stderr, "Is the docker daemon running") ||
+			strings.Contains(stderr, "Cannot connect to Podman") ||
+			strings.Contains(stderr, "unable to connect to Podman socket") {
+			return errDaemonNotRunning
+		}
 	}
+	return err
+}
 
-	// Check if Docker is available
-	if err := exec.Command("docker", "version").Run(); err != nil {
-		result.DockerRunning = false
-		return result, nil
+// runWithRetry runs an engine command, retrying transient failures with
+// exponential backoff. Daemon-not-running errors are returned immediately.
+func runWithRetry(run commandRunner, policy RetryPolicy, name string, args ...string) ([]byte, error) {
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
 	}
-	result.DockerRunning = true
 
-	// Get running containers
-	cmd := exec.Command("docker", "ps", "--format", "{{json .}}")
-	output, err := cmd.Output()
+	delay := policy.Backoff
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		var output []byte
+		output, err = run(name, args...)
+		if err == nil {
+			return output, nil
+		}
+		if classifyError(err) == errDaemonNotRunning {
+			return nil, errDaemonNotRunning
+		}
+	}
+	return nil, err
+}
+
+// ScanRuntime scans for currently running containers
+func ScanRuntime() (*RuntimeResult, error) {
+	return ScanRuntimeWithRetry(DefaultRetryPolicy)
+}
+
+// ScanRuntimeWithFilter scans the running containers of the detected engine
+// that pass opts, such as those of one compose project
+func ScanRuntimeWithFilter(opts RuntimeFilter) (*RuntimeResult, error) {
+	return ScanRuntimeWithEngineFilter(DetectEngine(DefaultRetryPolicy), opts)
+}
+
+// ScanRuntimeWithRetry scans for running containers of the detected engine,
+// retrying transient failures according to policy
+func ScanRuntimeWithRetry(policy RetryPolicy) (*RuntimeResult, error) {
+	return ScanRuntimeWithEngine(DetectEngine(policy))
+}
+
+// DockerVersion returns the version of the Docker engine, or an error when
+// the CLI or daemon is unavailable
+func DockerVersion(policy RetryPolicy) (string, error) {
+	return dockerVersion(execCommand, policy)
+}
+
+func dockerVersion(run commandRunner, policy RetryPolicy) (string, error) {
+	output, err := runWithRetry(run, policy, "docker", "version", "--format", "{{.Server.Version}}")
 	if err != nil {
-		return nil, fmt.Errorf("failed to list containers: %w", err)
+		return "", err
 	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func scanRuntime(run commandRunner, policy RetryPolicy) (*RuntimeResult, error) {
+	return ScanRuntimeWithEngine(&cliEngine{name: "docker", run: run, policy: policy})
+}
 
-	// Parse JSON lines
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
+// parsePs parses the JSON lines of docker ps or podman ps. Lines that are
+// not a container object are skipped.
+func parsePs(output []byte) []Container {
+	var containers []Container
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
 		if line == "" {
 			continue
 		}
@@ -93,25 +204,101 @@ func ScanRuntime() (*RuntimeResult, error) {
 		}
 
 		container := Container{
-			ID:     dc.ID[:12],
-			Name:   strings.TrimPrefix(dc.Names, "/"),
+			ID:     dc.ID,
+			Name:   containerName(dc.Names),
 			Image:  dc.Image,
 			State:  dc.State,
-			Ports:  parsePorts(dc.Ports),
-			Labels: parseLabels(dc.Labels),
+			Ports:  containerPorts(dc.Ports),
+			Labels: containerLabels(dc.Labels),
+		}
+		if len(container.ID) > 12 {
+			container.ID = container.ID[:12]
 		}
+		containers = append(containers, container)
+	}
+	return containers
+}
+
+// containerName returns the first name of a docker string or podman list
+func containerName(raw json.RawMessage) string {
+	var name string
+	if json.Unmarshal(raw, &name) != nil {
+		var names []string
+		if json.Unmarshal(raw, &names) != nil || len(names) == 0 {
+			return ""
+		}
+		name = names[0]
+	}
+	return strings.TrimPrefix(strings.Split(name, ",")[0], "/")
+}
 
-		result.Containers = append(result.Containers, container)
+// containerPorts parses a docker ports string or a podman mapping list,
+// expanding podman ranges into one port each
+func containerPorts(raw json.RawMessage) []ContainerPort {
+	var spec string
+	if json.Unmarshal(raw, &spec) == nil {
+		return parsePorts(spec)
+	}
 
-		// Track used ports
-		for _, p := range container.Ports {
-			if p.HostPort > 0 {
-				result.UsedPorts[p.HostPort] = append(result.UsedPorts[p.HostPort], container)
+	var mappings []podmanPort
+	if json.Unmarshal(raw, &mappings) != nil {
+		return nil
+	}
+	var ports []ContainerPort
+	for _, m := range mappings {
+		count := m.Range
+		if count < 1 {
+			count = 1
+		}
+		protocol := m.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		for i := 0; i < count; i++ {
+			p := ContainerPort{HostIP: m.HostIP, ContainerPort: m.ContainerPort + i, Protocol: protocol}
+			if m.HostPort > 0 {
+				p.HostPort = m.HostPort + i
 			}
+			ports = append(ports, p)
 		}
 	}
+	return ports
+}
 
-	return result, nil
+// containerLabels parses a docker label string or a podman label object
+func containerLabels(raw json.RawMessage) map[string]string {
+	var spec string
+	if json.Unmarshal(raw, &spec) == nil {
+		return parseLabels(spec)
+	}
+	labels := make(map[string]string)
+	json.Unmarshal(raw, &labels)
+	return labels
+}
+
+// addContainer records a container and the host ports it publishes. A
+// port the container publishes more than once, such as on both 0.0.0.0
+// and ::, lists it once.
+func (r *RuntimeResult) addContainer(c Container) {
+	r.Containers = append(r.Containers, c)
+
+	// Track used ports
+	for _, p := range c.Ports {
+		if p.HostPort > 0 && !hasContainer(r.UsedPorts[p.HostPort], c) {
+			r.UsedPorts[p.HostPort] = append(r.UsedPorts[p.HostPort], c)
+		}
+	}
+}
+
+// hasContainer reports whether containers includes c, by ID or, for
+// containers without one, by name
+func hasContainer(containers []Container, c Container) bool {
+	for _, other := range containers {
+		if other.ID == c.ID && (c.ID != "" || other.Name == c.Name) {
+			return true
+		}
+	}
+	return false
 }
 
 // parsePorts parses the Ports field from Docker ps
@@ -135,7 +322,7 @@ func parsePorts(portsStr string) []ContainerPort {
 }
 
 func parsePortMapping(s string) *ContainerPort {
-	// Format: "0.0.0.0:8080->80/tcp" or ":::8080->80/tcp"
+	// Format: "0.0.0.0:8080->80/tcp", ":::8080->80/tcp" or "[::1]:8080->80/tcp"
 	p := &ContainerPort{Protocol: "tcp"}
 
 	// Split by ->
@@ -157,15 +344,33 @@ func parsePortMapping(s string) *ContainerPort {
 	}
 
 	// Parse host IP and port
-	colonIdx := strings.LastIndex(hostPart, ":")
-	if colonIdx >= 0 {
-		p.HostIP = hostPart[:colonIdx]
-		fmt.Sscanf(hostPart[colonIdx+1:], "%d", &p.HostPort)
+	if ip, port, ok := splitHostPort(hostPart); ok {
+		p.HostIP = ip
+		fmt.Sscanf(port, "%d", &p.HostPort)
 	}
 
 	return p
 }
 
+// splitHostPort splits the host side of a docker ps mapping into IP and
+// port. IPv6 addresses come either bracketed, "[::1]:8080", or bare with
+// the port after the last colon, ":::8080" for the wildcard "::".
+func splitHostPort(hostPart string) (ip, port string, ok bool) {
+	if strings.HasPrefix(hostPart, "[") {
+		end := strings.Index(hostPart, "]:")
+		if end < 0 {
+			return "", "", false
+		}
+		return hostPart[1:end], hostPart[end+2:], true
+	}
+
+	colonIdx := strings.LastIndex(hostPart, ":")
+	if colonIdx < 0 {
+		return "", "", false
+	}
+	return hostPart[:colonIdx], hostPart[colonIdx+1:], true
+}
+
 func parseLabels(labelsStr string) map[string]string {
 	labels := make(map[string]string)
 	if labelsStr == "" {
@@ -187,43 +392,67 @@ func CheckPortsInUse(ports []int) map[int]bool {
 	result := make(map[int]bool)
 
 	for _, port := range ports {
-		// Try to bind to the port
-		addr := fmt.Sprintf(":%d", port)
-		listener, err := net.Listen("tcp", addr)
-		if err != nil {
-			result[port] = true // Port is in use
-		} else {
-			listener.Close()
-			result[port] = false // Port is free
-		}
+		result[port] = PortInUse(port, "tcp", "")
 	}
 
 	return result
 }
 
-// FindFreePort finds a free port near the suggested one
-func FindFreePort(suggested int, maxAttempts int) int {
-	for i := 0; i < maxAttempts; i++ {
-		port := suggested + i
-		addr := fmt.Sprintf(":%d", port)
-		listener, err := net.Listen("tcp", addr)
-		if err == nil {
-			listener.Close()
+// FreePortOptions controls the search of FindFreePort
+type FreePortOptions struct {
+	Protocol        string       // tcp (default) or udp
+	AllowPrivileged bool         // also consider ports below 1024
+	Exclude         map[int]bool // ports never to return, e.g. already planned
+}
+
+// FindFreePort finds a free port at or above the suggested one, probing at
+// most maxAttempts ports. Excluded ports are skipped without counting as an
+// attempt. It returns 0 when no port up to 65535 is free.
+func FindFreePort(suggested int, maxAttempts int, opts FreePortOptions) int {
+	lowest := 1
+	if !opts.AllowPrivileged {
+		lowest = 1024
+	}
+	if suggested < lowest {
+		suggested = lowest
+	}
+
+	attempts := 0
+	for port := suggested; port <= 65535 && attempts < maxAttempts; port++ {
+		if opts.Exclude[port] {
+			continue
+		}
+		attempts++
+		// A port that cannot be probed cannot be promised free either
+		if inUse, err := ProbePort(port, opts.Protocol, ""); !inUse && err == nil {
 			return port
 		}
 	}
 	return 0
 }
 
-// SuggestFreePorts suggests alternative free ports for a list of conflicting ports
-func SuggestFreePorts(conflictPorts []int) map[int]int {
+// SuggestFreePorts suggests alternative free ports for a list of conflicting
+// ports. Suggestions are unique and never one of the inUse host ports, such
+// as those the scanned compose files already publish, so applying them all
+// cannot create a new collision. Conflicts are resolved in port order.
+func SuggestFreePorts(conflictPorts []int, inUse map[int]bool) map[int]int {
 	suggestions := make(map[int]int)
+	taken := make(map[int]bool, len(inUse))
+	for port := range inUse {
+		taken[port] = true
+	}
+
+	ports := append([]int{}, conflictPorts...)
+	sort.Ints(ports)
 
-	for _, port := range conflictPorts {
+	for _, port := range ports {
 		// Try common alternatives based on port type
 		alternatives := getPortAlternatives(port)
 
 		for _, alt := range alternatives {
+			if alt > 65535 || taken[alt] {
+				continue
+			}
 			addr := fmt.Sprintf(":%d", alt)
 			listener, err := net.Listen("tcp", addr)
 			if err == nil {
@@ -235,11 +464,14 @@ func SuggestFreePorts(conflictPorts []int) map[int]int {
 
 		// If no alternative found in common alternatives, search nearby
 		if _, found := suggestions[port]; !found {
-			free := FindFreePort(port+1, 100)
+			free := FindFreePort(port+1, 100, FreePortOptions{Exclude: taken})
 			if free > 0 {
 				suggestions[port] = free
 			}
 		}
+		if alt, found := suggestions[port]; found {
+			taken[alt] = true
+		}
 	}
 
 	return suggestions
@@ -279,10 +511,14 @@ func FormatRuntimeResult(result *RuntimeResult) string {
 	sb.WriteString("# Runtime Port Scan\n\n")
 
 	if !result.DockerRunning {
-		sb.WriteString("⚠️ Docker daemon is not running\n")
+		sb.WriteString("⚠️ No container engine is running (tried docker and podman)\n")
 		return sb.String()
 	}
 
+	if result.Engine != "" {
+		sb.WriteString(fmt.Sprintf("**Engine:** %s\n", result.Engine))
+	}
+
 	sb.WriteString(fmt.Sprintf("**Containers Found:** %d\n", len(result.Containers)))
 	sb.WriteString(fmt.Sprintf("**Scan Time:** %s\n\n", result.ScanTime.Format(time.RFC3339)))
 
@@ -311,6 +547,9 @@ func FormatRuntimeResult(result *RuntimeResult) string {
 		sb.WriteString("## Conflicts\n\n")
 		for _, c := range result.Conflicts {
 			sb.WriteString(fmt.Sprintf("- **Port %d**: %s\n", c.Port, c.Message))
+			if c.Remediation != "" {
+				sb.WriteString(fmt.Sprintf("  - Fix: %s\n", c.Remediation))
+			}
 		}
 	}
 
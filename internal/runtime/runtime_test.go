@@ -0,0 +1,633 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fakeContainer = `{"Id":"0123456789abcdef0123","Names":"web","Image":"nginx","State":"running","Ports":"0.0.0.0:8080->80/tcp","Labels":""}`
+
+// fakeComposeContainers is docker compose ps output for a project with one
+// publishing container
+const fakeComposeContainers = `{"ID":"abcdef0123456789abcd","Name":"shop-api-1","Image":"node","Service":"api","State":"running","Labels":"com.docker.compose.project=shop","Publishers":[{"URL":"0.0.0.0","TargetPort":3000,"PublishedPort":3000,"Protocol":"tcp"},{"URL":"","TargetPort":9229,"PublishedPort":0,"Protocol":"tcp"}]}`
+
+// fakeEngine answers docker commands, failing the first failures[cmd] calls
+type fakeEngine struct {
+	failures map[string]int
+	err      error
+	calls    map[string]int
+	args     map[string][]string // last arguments of each command
+}
+
+func (f *fakeEngine) run(name string, args ...string) ([]byte, error) {
+	cmd := args[0]
+	f.calls[cmd]++
+	f.args[cmd] = args
+	if f.calls[cmd] <= f.failures[cmd] {
+		return nil, f.err
+	}
+	switch cmd {
+	case "ps":
+		return []byte(fakeContainer + "\n"), nil
+	case "compose":
+		return []byte(fakeComposeContainers + "\n"), nil
+	}
+	return []byte("ok"), nil
+}
+
+func newFakeEngine(err error, failures map[string]int) *fakeEngine {
+	return &fakeEngine{failures: failures, err: err, calls: make(map[string]int), args: make(map[string][]string)}
+}
+
+var fastRetry = RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+func TestScanRuntime_RetriesTransientFailure(t *testing.T) {
+	engine := newFakeEngine(errors.New("signal: killed"), map[string]int{"ps": 1})
+
+	result, err := scanRuntime(engine.run, fastRetry)
+	if err != nil {
+		t.Fatalf("scanRuntime failed: %v", err)
+	}
+
+	if engine.calls["ps"] != 2 {
+		t.Errorf("Expected 2 ps attempts, got %d", engine.calls["ps"])
+	}
+	if !result.DockerRunning {
+		t.Error("Docker should be reported as running")
+	}
+	if len(result.UsedPorts[8080]) != 1 {
+		t.Errorf("Expected container on port 8080, got %d", len(result.UsedPorts[8080]))
+	}
+}
+
+func TestScanRuntime_GivesUpAfterAttempts(t *testing.T) {
+	engine := newFakeEngine(errors.New("signal: killed"), map[string]int{"ps": 10})
+
+	_, err := scanRuntime(engine.run, fastRetry)
+	if err == nil || !strings.Contains(err.Error(), "failed to list containers") {
+		t.Fatalf("Expected list failure, got %v", err)
+	}
+	if engine.calls["ps"] != fastRetry.Attempts {
+		t.Errorf("Expected %d ps attempts, got %d", fastRetry.Attempts, engine.calls["ps"])
+	}
+}
+
+func TestScanRuntime_DaemonNotRunningNoRetry(t *testing.T) {
+	engine := newFakeEngine(errDaemonNotRunning, map[string]int{"version": 10})
+
+	result, err := scanRuntime(engine.run, fastRetry)
+	if err != nil {
+		t.Fatalf("scanRuntime failed: %v", err)
+	}
+
+	if result.DockerRunning {
+		t.Error("Docker should be reported as not running")
+	}
+	if engine.calls["version"] != 1 {
+		t.Errorf("Daemon-not-running should not be retried, got %d attempts", engine.calls["version"])
+	}
+}
+
+// fakePodmanContainer is podman ps output, with a list of names, port
+// mapping objects and a label object
+const fakePodmanContainer = `{"Id":"fedcba9876543210fedc","Names":["cache"],"Image":"redis","State":"running","Ports":[{"host_ip":"127.0.0.1","container_port":6379,"host_port":6379,"range":2,"protocol":"tcp"}],"Labels":{"com.docker.compose.service":"cache"}}`
+
+func TestDetectEngine_FallsBackToPodman(t *testing.T) {
+	var called []string
+	run := func(name string, args ...string) ([]byte, error) {
+		called = append(called, name+" "+args[0])
+		if name == "docker" {
+			return nil, exec.ErrNotFound
+		}
+		if args[0] == "ps" {
+			return []byte(fakePodmanContainer + "\n"), nil
+		}
+		return []byte("ok"), nil
+	}
+
+	engine := detectEngine(run, fastRetry)
+	if engine == nil || engine.Name() != "podman" {
+		t.Fatalf("Expected podman, got %v", engine)
+	}
+
+	result, err := ScanRuntimeWithEngine(engine)
+	if err != nil {
+		t.Fatalf("ScanRuntimeWithEngine failed: %v", err)
+	}
+	if !result.DockerRunning || result.Engine != "podman" {
+		t.Errorf("Expected a running podman engine, got %+v", result)
+	}
+	if len(result.Containers) != 1 {
+		t.Fatalf("Expected one container, got %+v", result.Containers)
+	}
+	c := result.Containers[0]
+	if c.Name != "cache" || c.ID != "fedcba987654" || c.Labels["com.docker.compose.service"] != "cache" {
+		t.Errorf("Unexpected container %+v", c)
+	}
+	if len(result.UsedPorts[6379]) != 1 || len(result.UsedPorts[6380]) != 1 || c.Ports[1].ContainerPort != 6380 {
+		t.Errorf("Expected the podman range expanded to 6379 and 6380, got %+v", c.Ports)
+	}
+	if c.Ports[0].HostIP != "127.0.0.1" {
+		t.Errorf("Expected host IP 127.0.0.1, got %q", c.Ports[0].HostIP)
+	}
+}
+
+func TestDetectEngine_NoneAvailable(t *testing.T) {
+	run := func(name string, args ...string) ([]byte, error) {
+		return nil, exec.ErrNotFound
+	}
+
+	engine := detectEngine(run, fastRetry)
+	if engine != nil {
+		t.Fatalf("Expected no engine, got %s", engine.Name())
+	}
+	result, err := ScanRuntimeWithEngine(engine)
+	if err != nil {
+		t.Fatalf("ScanRuntimeWithEngine failed: %v", err)
+	}
+	if result.DockerRunning {
+		t.Error("No engine should be reported as not running")
+	}
+}
+
+func TestNewEngine_Unknown(t *testing.T) {
+	if _, err := NewEngine("lxc", fastRetry); err == nil {
+		t.Error("Expected an error for an unknown engine")
+	}
+}
+
+// fakeAPIContainers is a Docker API /containers/json response
+const fakeAPIContainers = `[{"Id":"0123456789abcdef0123","Names":["/web"],"Image":"nginx","State":"running","Labels":{"com.docker.compose.project":"shop"},"Ports":[{"IP":"0.0.0.0","PrivatePort":80,"PublicPort":8080,"Type":"tcp"},{"PrivatePort":443,"Type":"tcp"}],"Created":1700000000}]`
+
+func fakeDockerAPI() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	})
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, fakeAPIContainers)
+	})
+	return mux
+}
+
+func TestScanRuntimeWithClient(t *testing.T) {
+	server := httptest.NewServer(fakeDockerAPI())
+	defer server.Close()
+
+	result, err := ScanRuntimeWithClient(server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("ScanRuntimeWithClient failed: %v", err)
+	}
+	if !result.DockerRunning || result.Engine != "docker-api" {
+		t.Errorf("Expected the Docker API engine, got %+v", result)
+	}
+	if len(result.Containers) != 1 {
+		t.Fatalf("Expected one container, got %+v", result.Containers)
+	}
+	c := result.Containers[0]
+	if c.Name != "web" || c.ID != "0123456789ab" || c.Labels["com.docker.compose.project"] != "shop" {
+		t.Errorf("Unexpected container %+v", c)
+	}
+	if len(result.UsedPorts) != 1 || len(result.UsedPorts[8080]) != 1 {
+		t.Errorf("Expected only the published port 8080, got %v", result.UsedPorts)
+	}
+	if c.Ports[0].HostIP != "0.0.0.0" || c.Ports[0].ContainerPort != 80 {
+		t.Errorf("Unexpected port %+v", c.Ports[0])
+	}
+}
+
+func TestNewAPIEngine_DockerHostSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	server := &http.Server{Handler: fakeDockerAPI()}
+	go server.Serve(listener)
+	defer server.Close()
+
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+	engine, err := NewAPIEngine("")
+	if err != nil {
+		t.Fatalf("NewAPIEngine failed: %v", err)
+	}
+	result, err := ScanRuntimeWithEngine(engine)
+	if err != nil {
+		t.Fatalf("ScanRuntimeWithEngine failed: %v", err)
+	}
+	if !result.DockerRunning || len(result.UsedPorts[8080]) != 1 {
+		t.Errorf("Expected the container from the socket, got %+v", result)
+	}
+
+	t.Setenv("DOCKER_HOST", "unix://"+filepath.Join(t.TempDir(), "missing.sock"))
+	engine, err = NewAPIEngine("")
+	if err != nil {
+		t.Fatalf("NewAPIEngine failed: %v", err)
+	}
+	if engine.Available() {
+		t.Error("A missing socket should not be available")
+	}
+
+	if _, err := NewAPIEngine("ssh://user@host"); err == nil {
+		t.Error("Expected an error for an unsupported scheme")
+	}
+}
+
+func TestParsePortMapping(t *testing.T) {
+	tests := []struct {
+		spec     string
+		hostIP   string
+		hostPort int
+	}{
+		{"0.0.0.0:8080->80/tcp", "0.0.0.0", 8080},
+		{":::8080->80/tcp", "::", 8080},
+		{"[::]:8080->80/tcp", "::", 8080},
+		{"[::1]:8080->80/tcp", "::1", 8080},
+	}
+
+	for _, tt := range tests {
+		p := parsePortMapping(tt.spec)
+		if p == nil {
+			t.Errorf("parsePortMapping(%q) = nil", tt.spec)
+			continue
+		}
+		if p.HostIP != tt.hostIP || p.HostPort != tt.hostPort || p.ContainerPort != 80 || p.Protocol != "tcp" {
+			t.Errorf("parsePortMapping(%q) = %+v, want %s port %d -> 80/tcp", tt.spec, *p, tt.hostIP, tt.hostPort)
+		}
+	}
+}
+
+func TestAddContainer_DedupesDualStackPorts(t *testing.T) {
+	ps := `{"Id":"0123456789abcdef0123","Names":"web","Image":"nginx","State":"running","Ports":"0.0.0.0:8080->80/tcp, :::8080->80/tcp","Labels":""}`
+	result := &RuntimeResult{UsedPorts: make(map[int][]Container)}
+	for _, c := range parsePs([]byte(ps + "\n")) {
+		result.addContainer(c)
+	}
+
+	if len(result.Containers) != 1 || len(result.Containers[0].Ports) != 2 {
+		t.Fatalf("Expected one container with both mappings, got %+v", result.Containers)
+	}
+	if len(result.UsedPorts[8080]) != 1 {
+		t.Errorf("Expected a single container entry for port 8080, got %+v", result.UsedPorts[8080])
+	}
+}
+
+func TestNewAlreadyInUseConflict(t *testing.T) {
+	c := NewAlreadyInUseConflict(8080, "web", Container{Name: "legacy-web"})
+
+	if c.Type != "already_in_use" {
+		t.Errorf("Type = %s, want already_in_use", c.Type)
+	}
+	if c.Message != "Port 8080 (for web) is already used by container legacy-web" {
+		t.Errorf("Unexpected message %q", c.Message)
+	}
+	if c.Remediation != "Run `docker stop legacy-web` or change the host port of web" {
+		t.Errorf("Unexpected remediation %q", c.Remediation)
+	}
+}
+
+func TestCheckHostPort_OccupiedUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot open UDP socket: %v", err)
+	}
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	c := CheckHostPort(port, "udp", "127.0.0.1")
+	if c == nil {
+		t.Fatalf("UDP port %d should be reported in use", port)
+	}
+	if !strings.HasPrefix(c.Message, fmt.Sprintf("UDP port %d is already in use on the host", port)) {
+		t.Errorf("Unexpected message %q", c.Message)
+	}
+
+	conn.Close()
+	if c := CheckHostPort(port, "udp", "127.0.0.1"); c != nil {
+		t.Errorf("Freed UDP port should not conflict, got %q", c.Message)
+	}
+}
+
+func TestCheckHostPort_UnprobedIsNotInUse(t *testing.T) {
+	// 192.0.2.1 is a documentation address, never one of this host's
+	inUse, err := ProbePort(8080, "tcp", "192.0.2.1")
+	if inUse || err == nil {
+		t.Errorf("Expected a non-local host IP to fail the probe without counting as in use, got %v, %v", inUse, err)
+	}
+	c := CheckHostPort(8080, "tcp", "192.0.2.1")
+	if c == nil || c.ProbeErr == nil || !strings.HasPrefix(c.Message, "Could not probe TCP port 8080") {
+		t.Errorf("Expected a could not probe description, got %+v", c)
+	}
+
+	if CanBindPrivileged() {
+		t.Skip("running with privileges; cannot test binding a port below 1024 without them")
+	}
+	for _, protocol := range []string{"tcp", "udp"} {
+		if PortInUse(80, protocol, "127.0.0.1") {
+			t.Errorf("Expected %s port 80 not to count as in use when binding it needs privileges", protocol)
+		}
+		if c := CheckHostPort(80, protocol, "127.0.0.1"); c != nil && c.ProbeErr == nil && !strings.Contains(c.Message, "already in use") {
+			t.Errorf("Unexpected conflict for %s port 80: %+v", protocol, c)
+		}
+	}
+	if got := FindFreePort(80, 5, FreePortOptions{AllowPrivileged: true}); got != 0 && got < 1024 {
+		t.Errorf("FindFreePort should not return a port it could not probe, got %d", got)
+	}
+}
+
+func TestPortOwner(t *testing.T) {
+	dir := t.TempDir()
+	servicesFile = filepath.Join(dir, "services")
+	defer func() { servicesFile = "/etc/services" }()
+
+	services := "# comment\ndomain\t\t53/udp\nhttp-alt\t8080/tcp\twebcache # WWW caching\n"
+	if err := os.WriteFile(servicesFile, []byte(services), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"53/udp":   "systemd-resolved or dnsmasq",
+		"123/udp":  "chrony or ntpd",
+		"8080/tcp": "http-alt",
+		"9999/udp": "",
+	}
+	for key, want := range tests {
+		var port int
+		var proto string
+		fmt.Sscanf(strings.Replace(key, "/", " ", 1), "%d %s", &port, &proto)
+		if got := PortOwner(port, proto); got != want {
+			t.Errorf("PortOwner(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestScanProjectRuntime_OnlyProjectContainers(t *testing.T) {
+	engine := newFakeEngine(nil, nil)
+
+	result, err := scanProjectRuntime(engine.run, fastRetry, "/srv/shop")
+	if err != nil {
+		t.Fatalf("scanProjectRuntime failed: %v", err)
+	}
+
+	if engine.calls["ps"] != 0 {
+		t.Error("Global docker ps should not be used for a project scan")
+	}
+	if got := strings.Join(engine.args["compose"], " "); got != "compose --project-directory /srv/shop ps --format json" {
+		t.Errorf("Unexpected compose command %q", got)
+	}
+
+	if len(result.Containers) != 1 || result.Containers[0].Name != "shop-api-1" {
+		t.Fatalf("Expected only the project container, got %+v", result.Containers)
+	}
+	if len(result.UsedPorts[3000]) != 1 {
+		t.Errorf("Expected project container on port 3000, got %v", result.UsedPorts)
+	}
+	if len(result.UsedPorts[8080]) != 0 || len(result.UsedPorts) != 1 {
+		t.Errorf("Unrelated or unpublished ports should be ignored, got %v", result.UsedPorts)
+	}
+}
+
+func TestMatchesService(t *testing.T) {
+	labelled := func(project, service string) map[string]string {
+		return map[string]string{"com.docker.compose.project": project, "com.docker.compose.service": service}
+	}
+	tests := []struct {
+		name      string
+		container Container
+		want      bool
+	}{
+		{"exact name", Container{Name: "api"}, true},
+		{"generated name", Container{Name: "shop-api-1"}, true},
+		{"legacy generated name", Container{Name: "shop_api_2"}, true},
+		{"name prefix", Container{Name: "api-gateway"}, false},
+		{"generated name of another service", Container{Name: "shop-api-gateway-1"}, false},
+		{"generated name of another project", Container{Name: "blog-api-1"}, false},
+		{"labels", Container{Name: "whatever", Labels: labelled("shop", "api")}, true},
+		{"label prefix", Container{Name: "api", Labels: labelled("shop", "api-gateway")}, false},
+		{"label of another project", Container{Name: "shop-api-1", Labels: labelled("blog", "api")}, false},
+		{"service label only", Container{Name: "x", Labels: map[string]string{"com.docker.compose.service": "api"}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesService(tt.container, "shop", "api"); got != tt.want {
+			t.Errorf("%s: MatchesService(%+v) = %v, want %v", tt.name, tt.container, got, tt.want)
+		}
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	if got := ProjectName(filepath.Join(t.TempDir(), "My.Shop_v2")); got != "myshop_v2" {
+		t.Errorf("ProjectName = %q, want myshop_v2", got)
+	}
+}
+
+func TestParseComposePs_ArrayOutput(t *testing.T) {
+	containers, err := parseComposePs([]byte("[" + fakeComposeContainers + "]"))
+	if err != nil {
+		t.Fatalf("parseComposePs failed: %v", err)
+	}
+	if len(containers) != 1 || containers[0].Service != "api" {
+		t.Errorf("Unexpected containers %+v", containers)
+	}
+}
+
+func TestDiffSnapshots_PortMovedContainers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runtime.json")
+
+	web := Container{ID: "0123456789ab", Name: "web", Image: "nginx"}
+	api := Container{ID: "abcdef012345", Name: "api", Image: "node"}
+	db := Container{ID: "fedcba987654", Name: "db", Image: "postgres"}
+
+	before := &RuntimeResult{
+		Containers:    []Container{web, db},
+		UsedPorts:     map[int][]Container{8080: {web}, 5432: {db}},
+		DockerRunning: true,
+	}
+	if err := SaveSnapshot(path, before); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := &RuntimeResult{
+		Containers:    []Container{api},
+		UsedPorts:     map[int][]Container{8080: {api}, 3000: {api}},
+		DockerRunning: true,
+	}
+
+	got := DiffSnapshots(loaded, after)
+	want := []PortChange{
+		{Port: 3000, Change: "occupied", After: []string{"api"}},
+		{Port: 5432, Change: "freed", Before: []string{"db"}},
+		{Port: 8080, Change: "moved", Before: []string{"web"}, After: []string{"api"}},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("DiffSnapshots = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(FormatPortChanges(got), "Port 8080 moved from web to api") {
+		t.Errorf("Unexpected formatting:\n%s", FormatPortChanges(got))
+	}
+}
+
+// occupyBlock listens on n consecutive TCP ports and returns the first
+func occupyBlock(t *testing.T, n int) int {
+	t.Helper()
+	for try := 0; try < 20; try++ {
+		first, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Skipf("cannot open TCP socket: %v", err)
+		}
+		start := first.Addr().(*net.TCPAddr).Port
+		listeners := []net.Listener{first}
+		for port := start + 1; port < start+n; port++ {
+			l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+			if err != nil {
+				break
+			}
+			listeners = append(listeners, l)
+		}
+		if len(listeners) == n && start+n <= 65535 && !PortInUse(start+n, "tcp", "") {
+			t.Cleanup(func() {
+				for _, l := range listeners {
+					l.Close()
+				}
+			})
+			return start
+		}
+		for _, l := range listeners {
+			l.Close()
+		}
+	}
+	t.Skip("could not occupy a contiguous block of ports")
+	return 0
+}
+
+func TestFindFreePort_SkipsOccupiedBlock(t *testing.T) {
+	start := occupyBlock(t, 5)
+
+	if got := FindFreePort(start, 10, FreePortOptions{}); got != start+5 {
+		t.Errorf("FindFreePort(%d) = %d, want %d past the occupied block", start, got, start+5)
+	}
+	if got := FindFreePort(start, 5, FreePortOptions{}); got != 0 {
+		t.Errorf("Expected 0 when every attempt is occupied, got %d", got)
+	}
+
+	exclude := map[int]bool{start + 5: true}
+	if got := FindFreePort(start, 10, FreePortOptions{Exclude: exclude}); got == start+5 || (got != 0 && got < start+5) {
+		t.Errorf("FindFreePort should skip the excluded %d, got %d", start+5, got)
+	}
+}
+
+func TestFindFreePort_Bounds(t *testing.T) {
+	if got := FindFreePort(70000, 10, FreePortOptions{}); got != 0 {
+		t.Errorf("Expected no port above 65535, got %d", got)
+	}
+	if got := FindFreePort(80, 50, FreePortOptions{}); got != 0 && got < 1024 {
+		t.Errorf("Expected privileged ports to be skipped, got %d", got)
+	}
+	if got := FindFreePort(65535, 10, FreePortOptions{Exclude: map[int]bool{65535: true}}); got != 0 {
+		t.Errorf("Expected 0 when the only port in range is excluded, got %d", got)
+	}
+}
+
+func TestFindFreePort_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		t.Skipf("cannot open UDP socket: %v", err)
+	}
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	if got := FindFreePort(port, 1, FreePortOptions{Protocol: "udp"}); got != 0 {
+		t.Errorf("Expected the bound UDP port %d to be skipped, got %d", port, got)
+	}
+}
+
+func TestSuggestFreePorts_UniqueAndAvoidsComposePorts(t *testing.T) {
+	// 80 and 8080 share 8081 as an alternative once 8000 and 8080 are taken
+	inUse := map[int]bool{80: true, 8000: true, 8080: true}
+	suggestions := SuggestFreePorts([]int{8080, 80}, inUse)
+
+	if len(suggestions) != 2 {
+		t.Skipf("no free alternatives on this host: %v", suggestions)
+	}
+	if suggestions[80] == suggestions[8080] {
+		t.Errorf("Both conflicts were given %d", suggestions[80])
+	}
+	for port, alt := range suggestions {
+		if inUse[alt] {
+			t.Errorf("Suggestion %d for %d is already published by the compose files", alt, port)
+		}
+	}
+	if !PortInUse(8081, "tcp", "") && suggestions[80] != 8081 {
+		t.Errorf("Expected the lower conflict 80 to be resolved first and get 8081, got %d", suggestions[80])
+	}
+}
+
+func TestScanRuntimeWithEngineFilter(t *testing.T) {
+	fake := newFakeEngine(nil, nil)
+	engine := &cliEngine{name: "docker", run: fake.run, policy: fastRetry}
+
+	result, err := ScanRuntimeWithEngineFilter(engine, RuntimeFilter{Project: "shop", Name: "we"})
+	if err != nil {
+		t.Fatalf("ScanRuntimeWithEngineFilter failed: %v", err)
+	}
+	args := strings.Join(fake.args["ps"], " ")
+	if !strings.Contains(args, "--filter label=com.docker.compose.project=shop") || !strings.Contains(args, "--filter name=we") {
+		t.Errorf("Expected ps --filter arguments, got %q", args)
+	}
+	// The fake ignores the filters; the unlabelled container is still dropped
+	if len(result.Containers) != 0 {
+		t.Errorf("Expected no container outside project shop, got %+v", result.Containers)
+	}
+
+	result, err = ScanRuntimeWithEngineFilter(engine, RuntimeFilter{Name: "we"})
+	if err != nil {
+		t.Fatalf("ScanRuntimeWithEngineFilter failed: %v", err)
+	}
+	if len(result.Containers) != 1 {
+		t.Errorf("Expected the container named web, got %+v", result.Containers)
+	}
+
+	if _, err := ScanRuntimeWithEngine(engine); err != nil {
+		t.Fatalf("ScanRuntimeWithEngine failed: %v", err)
+	}
+	if args := strings.Join(fake.args["ps"], " "); strings.Contains(args, "--filter") {
+		t.Errorf("Expected no filters without a RuntimeFilter, got %q", args)
+	}
+}
+
+func TestScanRuntimeWithEngineFilter_API(t *testing.T) {
+	var filters string
+	mux := http.NewServeMux()
+	mux.Handle("/_ping", fakeDockerAPI())
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		filters = r.URL.Query().Get("filters")
+		fmt.Fprint(w, fakeAPIContainers)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	engine := &apiEngine{client: server.Client(), base: server.URL}
+	result, err := ScanRuntimeWithEngineFilter(engine, RuntimeFilter{Project: "shop"})
+	if err != nil {
+		t.Fatalf("ScanRuntimeWithEngineFilter failed: %v", err)
+	}
+	if filters != `{"label":["com.docker.compose.project=shop"]}` {
+		t.Errorf("Expected a label filter, got %q", filters)
+	}
+	if len(result.Containers) != 1 {
+		t.Errorf("Expected the container of project shop, got %+v", result.Containers)
+	}
+}
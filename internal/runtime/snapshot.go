@@ -0,0 +1,141 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PortChange describes how a published host port differs between two
+// runtime snapshots
+type PortChange struct {
+	Port   int      `json:"port"`
+	Change string   `json:"change"` // occupied, freed, moved
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// SaveSnapshot writes the containers and published ports of a runtime scan
+// to path. Containers are sorted by name so unchanged snapshots are
+// byte-identical. Compose conflicts are not part of a snapshot.
+func SaveSnapshot(path string, r *RuntimeResult) error {
+	snapshot := RuntimeResult{
+		Containers:    sortedContainers(r.Containers),
+		UsedPorts:     make(map[int][]Container, len(r.UsedPorts)),
+		ScanTime:      r.ScanTime,
+		DockerRunning: r.DockerRunning,
+	}
+	for port, containers := range r.UsedPorts {
+		snapshot.UsedPorts[port] = sortedContainers(containers)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot. A missing file
+// returns nil without error.
+func LoadSnapshot(path string) (*RuntimeResult, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r RuntimeResult
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid runtime snapshot %s: %w", path, err)
+	}
+	if r.UsedPorts == nil {
+		r.UsedPorts = make(map[int][]Container)
+	}
+	return &r, nil
+}
+
+// DiffSnapshots compares the published ports of two runtime scans, in port
+// order. A port is moved when it is published in both but by a different
+// set of containers.
+func DiffSnapshots(before, after *RuntimeResult) []PortChange {
+	ports := make(map[int]bool)
+	for port := range before.UsedPorts {
+		ports[port] = true
+	}
+	for port := range after.UsedPorts {
+		ports[port] = true
+	}
+
+	var changes []PortChange
+	for _, port := range sortedPorts(ports) {
+		was := containerNames(before.UsedPorts[port])
+		now := containerNames(after.UsedPorts[port])
+		change := PortChange{Port: port, Before: was, After: now}
+		switch {
+		case len(was) == 0:
+			change.Change = "occupied"
+		case len(now) == 0:
+			change.Change = "freed"
+		case strings.Join(was, ",") != strings.Join(now, ","):
+			change.Change = "moved"
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// FormatPortChanges renders snapshot changes one per line
+func FormatPortChanges(changes []PortChange) string {
+	var sb strings.Builder
+	for _, c := range changes {
+		switch c.Change {
+		case "occupied":
+			fmt.Fprintf(&sb, "Port %d newly occupied by %s\n", c.Port, strings.Join(c.After, ", "))
+		case "freed":
+			fmt.Fprintf(&sb, "Port %d freed (was %s)\n", c.Port, strings.Join(c.Before, ", "))
+		case "moved":
+			fmt.Fprintf(&sb, "Port %d moved from %s to %s\n", c.Port,
+				strings.Join(c.Before, ", "), strings.Join(c.After, ", "))
+		}
+	}
+	return sb.String()
+}
+
+func sortedContainers(containers []Container) []Container {
+	sorted := append([]Container{}, containers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// containerNames returns the sorted, distinct names of containers
+func containerNames(containers []Container) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range containers {
+		if !seen[c.Name] {
+			seen[c.Name] = true
+			names = append(names, c.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedPorts(ports map[int]bool) []int {
+	sorted := make([]int, 0, len(ports))
+	for port := range ports {
+		sorted = append(sorted, port)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
@@ -0,0 +1,239 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheFile is the file name of the parse cache inside its directory
+const CacheFile = "scan-cache.json"
+
+// cacheVersion changes whenever cached parse results would no longer
+// match what the current parser produces
+const cacheVersion = 5
+
+// Cache keeps parsed compose files on disk, keyed by the sha256 of their
+// contents, so unchanged files are not parsed again. An entry is used
+// only while the size and modification time of every file the parse read
+// still match. Files using variable substitution or interface names are
+// never cached, since their bindings depend on more than their contents.
+type Cache struct {
+	dir string
+
+	mu      sync.Mutex // files are parsed concurrently
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+type cacheEntry struct {
+	Sources  []cacheSource   `json:"sources"` // every file the parse read, the compose file first
+	Services []parsedService `json:"services"`
+	Includes []string        `json:"includes,omitempty"`
+}
+
+// cacheSource records the state of a file when it was parsed. Missing
+// files are recorded too, since creating one changes the parse.
+type cacheSource struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"sha256,omitempty"`
+	Missing bool      `json:"missing,omitempty"`
+}
+
+type cacheDocument struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+// DefaultCacheDir returns the directory portcheck caches parse results in,
+// under the user cache directory such as ~/.cache/portcheck
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "portcheck"), nil
+}
+
+// OpenCache loads the cache kept in dir. A missing, unreadable or
+// outdated cache file starts an empty cache rather than failing.
+func OpenCache(dir string) *Cache {
+	c := &Cache{dir: dir, entries: make(map[string]cacheEntry)}
+	data, err := os.ReadFile(filepath.Join(dir, CacheFile))
+	if err != nil {
+		return c
+	}
+	var doc cacheDocument
+	if json.Unmarshal(data, &doc) == nil && doc.Version == cacheVersion && doc.Entries != nil {
+		c.entries = doc.Entries
+	}
+	return c
+}
+
+// Save writes the cache back to its directory if it changed
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheDocument{Version: cacheVersion, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+	// Write then rename so a concurrent scan never reads half a file
+	tmp, err := os.CreateTemp(c.dir, CacheFile+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, CacheFile)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// ClearCache removes the cache file kept in dir. A cache that does not
+// exist is not an error.
+func ClearCache(dir string) error {
+	err := os.Remove(filepath.Join(dir, CacheFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// cacheKey identifies the parse of one file's contents. Bindings record
+// their file and project, so both are part of the key.
+func cacheKey(hash, path, project string) string {
+	return hash + " " + project + " " + path
+}
+
+// parseFile returns the parse of a compose file from the cache of opts
+// while the files it read are unchanged, parsing it otherwise
+func (opts Options) parseFile(path, project string) parsedFile {
+	c := opts.Cache
+	if c == nil {
+		return parseFile(path, project)
+	}
+
+	main := statSource(path)
+	if main.Missing {
+		return parseFile(path, project)
+	}
+	key := cacheKey(main.Hash, path, project)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.fresh(main) {
+		return parsedFile{Path: path, Project: project, Services: entry.Services, Includes: entry.Includes}
+	}
+
+	loader := newComposeLoader(project)
+	services, err := loader.parseComposeFile(path)
+	f := parsedFile{Path: path, Project: project, Services: services, Includes: loader.included, Err: err}
+	cacheable := err == nil && loader.cacheable()
+	var sources []cacheSource
+	if cacheable {
+		sources = []cacheSource{main}
+		for _, source := range loader.sources {
+			if source != path {
+				sources = append(sources, statSource(source))
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop entries for earlier contents of the file
+	for k, entry := range c.entries {
+		if len(entry.Sources) == 0 || entry.Sources[0].Path == path {
+			delete(c.entries, k)
+			c.dirty = true
+		}
+	}
+	if cacheable {
+		c.entries[key] = cacheEntry{Sources: sources, Services: services, Includes: loader.included}
+		c.dirty = true
+	}
+	return f
+}
+
+// fresh reports whether every file the entry was parsed from is unchanged,
+// given the current state of its compose file
+func (e cacheEntry) fresh(main cacheSource) bool {
+	if len(e.Sources) == 0 || e.Sources[0].Size != main.Size || !e.Sources[0].ModTime.Equal(main.ModTime) {
+		return false
+	}
+	for _, recorded := range e.Sources[1:] {
+		current := statSource(recorded.Path)
+		if current.Missing != recorded.Missing {
+			return false
+		}
+		if current.Missing {
+			continue
+		}
+		if current.Size != recorded.Size || !current.ModTime.Equal(recorded.ModTime) || current.Hash != recorded.Hash {
+			return false
+		}
+	}
+	return true
+}
+
+// statSource returns the current size, modification time and content
+// hash of a file
+func statSource(path string) cacheSource {
+	info, err := os.Stat(path)
+	if err != nil {
+		return cacheSource{Path: path, Missing: true}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheSource{Path: path, Missing: true}
+	}
+	sum := sha256.Sum256(data)
+	return cacheSource{Path: path, Size: info.Size(), ModTime: info.ModTime(), Hash: hex.EncodeToString(sum[:])}
+}
+
+// cacheable reports whether the files the loader parsed determine its
+// bindings on their own. Variable substitution depends on the environment
+// and interface names on the host's network configuration.
+func (l *composeLoader) cacheable() bool {
+	if l.variables {
+		return false
+	}
+	for _, compose := range l.decoded {
+		for _, svc := range compose.Services {
+			for _, port := range svc.Ports {
+				if spec, ok := port.(string); ok && interfacePortRegex.MatchString(spec) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReservedPortsFile is the claimed-ports file picked up from the scanned
+// directory when no file is given explicitly
+const ReservedPortsFile = ".portcheck-reserved"
+
+// ClaimedPort is a host port claimed by tooling outside Docker
+type ClaimedPort struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"` // empty claims both tcp and udp
+	Owner    string `json:"owner,omitempty"`
+}
+
+// LoadClaimedPorts reads a claimed-ports file. It accepts a JSON array of
+// port numbers or {"port", "protocol", "owner"} objects, or a plain list
+// with one "port[/protocol] [owner]" per line and # comments.
+func LoadClaimedPorts(path string) ([]ClaimedPort, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		return parseClaimedJSON(trimmed)
+	}
+
+	var claimed []ClaimedPort
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		c, err := parseClaim(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		c.Owner = strings.Join(fields[1:], " ")
+		claimed = append(claimed, c)
+	}
+	return claimed, s.Err()
+}
+
+func parseClaimedJSON(data []byte) ([]ClaimedPort, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	var claimed []ClaimedPort
+	for _, raw := range entries {
+		var port int
+		if err := json.Unmarshal(raw, &port); err == nil {
+			claimed = append(claimed, ClaimedPort{Port: port})
+			continue
+		}
+		var c ClaimedPort
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("invalid claimed port %s", raw)
+		}
+		claimed = append(claimed, c)
+	}
+
+	for i, c := range claimed {
+		if c.Port < 1 || c.Port > 65535 {
+			return nil, fmt.Errorf("invalid claimed port %d", c.Port)
+		}
+		claimed[i].Protocol = strings.ToLower(c.Protocol)
+	}
+	return claimed, nil
+}
+
+// parseClaim parses "port" or "port/protocol"
+func parseClaim(spec string) (ClaimedPort, error) {
+	var c ClaimedPort
+	if i := strings.Index(spec, "/"); i >= 0 {
+		spec, c.Protocol = spec[:i], strings.ToLower(spec[i+1:])
+	}
+	port, err := strconv.Atoi(spec)
+	if err != nil || port < 1 || port > 65535 {
+		return c, fmt.Errorf("invalid claimed port %q", spec)
+	}
+	c.Port = port
+	return c, nil
+}
+
+// ClaimedIssues reports every binding whose host port is claimed outside
+// Docker
+func (r *Result) ClaimedIssues(claimed []ClaimedPort) []Issue {
+	var issues []Issue
+	for _, c := range claimed {
+		for _, b := range r.PortMap[c.Port] {
+			if c.Protocol != "" && c.Protocol != b.Protocol {
+				continue
+			}
+			owner := c.Owner
+			if owner == "" {
+				owner = "another tool"
+			}
+			issue := Issue{
+				Severity:    "error",
+				Type:        "externally_claimed",
+				Port:        c.Port,
+				Description: fmt.Sprintf("Port %d (for %s) is claimed by %s", c.Port, b.Service, owner),
+				Bindings:    []PortBinding{b},
+			}
+			issue.Remediation = Remediation(issue)
+			issue.ID = IssueID(issue)
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
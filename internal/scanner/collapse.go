@@ -0,0 +1,58 @@
+package scanner
+
+// issueProtocol returns the protocol of an issue's first binding, or ""
+// when it has none
+func issueProtocol(issue Issue) string {
+	if len(issue.Bindings) == 0 {
+		return ""
+	}
+	return issue.Bindings[0].Protocol
+}
+
+// CollapseDuplicates merges issues sharing type, port and protocol into
+// the first of them, keeping its description, taking the most severe
+// severity and aggregating every contributing binding. Occurrences records
+// how many issues each result stands for. Order follows first appearance.
+// Issues without a port or bindings, such as parse errors, describe a
+// single file and are never merged.
+func CollapseDuplicates(issues []Issue) []Issue {
+	type key struct {
+		Type     string
+		Port     int
+		Protocol string
+	}
+
+	index := make(map[key]int)
+	var collapsed []Issue
+	for _, issue := range issues {
+		if issue.Port == 0 || len(issue.Bindings) == 0 {
+			issue.Occurrences = 1
+			collapsed = append(collapsed, issue)
+			continue
+		}
+		k := key{issue.Type, issue.Port, issueProtocol(issue)}
+		i, seen := index[k]
+		if !seen {
+			index[k] = len(collapsed)
+			issue.Bindings = append([]PortBinding(nil), issue.Bindings...)
+			issue.Occurrences = 1
+			collapsed = append(collapsed, issue)
+			continue
+		}
+
+		merged := &collapsed[i]
+		merged.Occurrences++
+		merged.Bindings = append(merged.Bindings, issue.Bindings...)
+		if severityRank(issue.Severity) < severityRank(merged.Severity) {
+			merged.Severity = issue.Severity
+		}
+	}
+
+	// The merged bindings define a new identity
+	for i := range collapsed {
+		if collapsed[i].Occurrences > 1 {
+			collapsed[i].ID = IssueID(collapsed[i])
+		}
+	}
+	return collapsed
+}
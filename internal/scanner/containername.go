@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// namedContainer is a container_name declared by a service
+type namedContainer struct {
+	Name    string
+	Service string
+	File    string
+	Project string
+}
+
+// containerNamePortIssue returns a combined issue when colliding bindings
+// come from services that also share a container_name. Either problem
+// alone stops the second service, so both must be fixed together.
+func containerNamePortIssue(port int, bindings []PortBinding) *Issue {
+	byName := make(map[string]map[string]bool)
+	for _, b := range bindings {
+		if b.ContainerName == "" {
+			continue
+		}
+		if byName[b.ContainerName] == nil {
+			byName[b.ContainerName] = make(map[string]bool)
+		}
+		byName[b.ContainerName][b.Project+"/"+b.Service] = true
+	}
+
+	var names []string
+	for name, services := range byName {
+		if len(services) > 1 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	return &Issue{
+		Severity: "error",
+		Type:     "container_name_port_collision",
+		Port:     port,
+		Description: fmt.Sprintf("Services %s share container_name %s and host port %d; changing only one still leaves the other conflict",
+			serviceList(bindings), strings.Join(names, ", "), port),
+		Bindings: bindings,
+	}
+}
+
+// containerNameIssues reports container names declared by more than one
+// service. Docker names are host-wide, so projects do not separate them.
+// Groups whose services also share a host port are already reported by a
+// combined container_name_port_collision and are skipped.
+func (r *Result) containerNameIssues() []Issue {
+	groups := make(map[string][]namedContainer)
+	for _, n := range r.names {
+		groups[n.Name] = append(groups[n.Name], n)
+	}
+
+	combined := make(map[string]map[string]bool) // name -> services in a combined issue
+	for _, issues := range r.derived {
+		for _, issue := range issues {
+			if issue.Type != "container_name_port_collision" {
+				continue
+			}
+			for _, b := range issue.Bindings {
+				if combined[b.ContainerName] == nil {
+					combined[b.ContainerName] = make(map[string]bool)
+				}
+				combined[b.ContainerName][b.Project+"/"+b.Service] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []Issue
+	for _, name := range names {
+		services := make(map[string]bool)
+		var bindings []PortBinding
+		for _, n := range groups[name] {
+			key := n.Project + "/" + n.Service
+			if !services[key] {
+				services[key] = true
+				bindings = append(bindings, PortBinding{Service: n.Service, File: n.File, Project: n.Project, ContainerName: name})
+			}
+		}
+		if len(services) < 2 {
+			continue
+		}
+
+		covered := true
+		for key := range services {
+			covered = covered && combined[name][key]
+		}
+		if covered {
+			continue
+		}
+
+		issue := Issue{
+			Severity:    "error",
+			Type:        "container_name_collision",
+			Description: fmt.Sprintf("Services %s share container_name %s", serviceList(bindings), name),
+			Bindings:    bindings,
+		}
+		issue.Remediation = Remediation(issue)
+		issues = append(issues, issue)
+	}
+	return issues
+}
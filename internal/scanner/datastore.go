@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDatastores are the image names exposed_datastore checks for
+var DefaultDatastores = []string{
+	"mysql",
+	"mariadb",
+	"postgres",
+	"redis",
+	"mongo",
+	"memcached",
+	"elasticsearch",
+	"cassandra",
+}
+
+// datastoreAliases maps other image names of a datastore, such as the
+// Bitnami ones, to the name it has in the datastore list
+var datastoreAliases = map[string]string{
+	"postgresql":         "postgres",
+	"mongodb":            "mongo",
+	"redis-stack":        "redis",
+	"redis-stack-server": "redis",
+	"mysql-server":       "mysql",
+}
+
+// datastoreIssue warns when a database or cache image is published on
+// every host interface
+func (r *Result) datastoreIssue(b PortBinding) *Issue {
+	if !isWildcardIP(b.HostIP) || !r.isDatastore(b.Image) {
+		return nil
+	}
+	return &Issue{
+		Severity: "warning",
+		Type:     "exposed_datastore",
+		Port:     b.HostPort,
+		Description: fmt.Sprintf("Datastore %s (%s) is published on all interfaces at port %d",
+			b.Service, b.Image, b.HostPort),
+		Bindings: []PortBinding{b},
+	}
+}
+
+// PublicDatastores returns the bindings of datastore images whose exposure
+// is public, whether on every interface or a routable address
+func (r *Result) PublicDatastores() []PortBinding {
+	var public []PortBinding
+	for _, b := range r.PortBindings {
+		if b.Exposure == ExposurePublic && r.isDatastore(b.Image) {
+			public = append(public, b)
+		}
+	}
+	return public
+}
+
+// isDatastore reports whether the base name of image, or the datastore it
+// is an alias of, is in the configured datastore list. Tools named after a
+// datastore, such as mongo-express or postgres-exporter, do not match.
+func (r *Result) isDatastore(image string) bool {
+	datastores := r.opts.Datastores
+	if datastores == nil {
+		datastores = DefaultDatastores
+	}
+
+	name := imageName(image)
+	if name == "" {
+		return false
+	}
+	for _, ds := range datastores {
+		if name == ds || datastoreAliases[name] == ds {
+			return true
+		}
+	}
+	return false
+}
+
+// isWildcardIP reports whether a host IP binds every interface
+func isWildcardIP(ip string) bool {
+	switch ip {
+	case "", "0.0.0.0", "::", "[::]":
+		return true
+	}
+	return false
+}
+
+// imageName strips the registry, namespace, tag and digest from an image
+// reference, e.g. docker.io/bitnami/postgresql:16 becomes postgresql
+func imageName(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	if i := strings.Index(image, ":"); i >= 0 {
+		image = image[:i]
+	}
+	return strings.ToLower(image)
+}
@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// collisionDescription names the address the bindings of a collision
+// overlap on. Wildcard binds conflict with each other on the wildcard
+// address and shadow every specific-IP bind of the same port; a single
+// wildcard bind only shadows.
+func collisionDescription(port int, wildcard, specific []PortBinding) string {
+	addr := displayIP(wildcard[0].HostIP)
+	if len(specific) == 0 {
+		return fmt.Sprintf("Port %d conflict on %s between %s", port, addr, serviceList(wildcard))
+	}
+	if len(wildcard) == 1 {
+		return fmt.Sprintf("Port %d conflict: %s (%s) shadows %s",
+			port, addr, wildcard[0].Service, addressList(specific))
+	}
+	return fmt.Sprintf("Port %d conflict on %s between %s; %s shadows %s",
+		port, addr, serviceList(wildcard), addr, addressList(specific))
+}
+
+// displayIP returns the address a host IP binds, spelling out the implicit
+// wildcard
+func displayIP(hostIP string) string {
+	if hostIP == "" {
+		return "0.0.0.0"
+	}
+	return hostIP
+}
+
+// serviceList joins the distinct services of bindings as "a, b and c"
+func serviceList(bindings []PortBinding) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, b := range bindings {
+		if !seen[b.Service] {
+			seen[b.Service] = true
+			names = append(names, b.Service)
+		}
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// addressList describes bindings grouped by address, in first-seen order:
+// "127.0.0.1 (web, api), 192.168.1.10 (db)"
+func addressList(bindings []PortBinding) string {
+	var addrs []string
+	services := make(map[string][]string)
+	for _, b := range bindings {
+		addr := displayIP(b.HostIP)
+		if _, ok := services[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+		services[addr] = append(services[addr], b.Service)
+	}
+
+	parts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		parts[i] = fmt.Sprintf("%s (%s)", addr, strings.Join(services[addr], ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bindForms describes how each participant of a collision binds the port
+// when a range is involved, e.g. "8080 single bind (web) vs 8078-8082 range
+// (api)". It returns "" when every participant is a single bind.
+func bindForms(port int, bindings []PortBinding) string {
+	hasRange := false
+	for _, b := range bindings {
+		hasRange = hasRange || b.HostRange != ""
+	}
+	if !hasRange {
+		return ""
+	}
+
+	forms := make([]string, len(bindings))
+	for i, b := range bindings {
+		if b.HostRange != "" {
+			forms[i] = fmt.Sprintf("%s range (%s)", b.HostRange, b.Service)
+		} else {
+			forms[i] = fmt.Sprintf("%d single bind (%s)", port, b.Service)
+		}
+	}
+	description := strings.Join(forms, " vs ")
+	if lo, hi, ok := rangeOverlap(bindings); ok {
+		description += fmt.Sprintf("; ports %d–%d overlap", lo, hi)
+	}
+	return description
+}
+
+// rangeOverlap returns the host ports shared by every range of bindings when
+// at least two ranges take part, so a range-vs-range collision names its
+// whole overlap rather than only the port it is reported on
+func rangeOverlap(bindings []PortBinding) (lo, hi int, ok bool) {
+	ranges := 0
+	for _, b := range bindings {
+		var start, end int
+		if _, err := fmt.Sscanf(b.HostRange, "%d-%d", &start, &end); err != nil {
+			continue
+		}
+		if ranges == 0 || start > lo {
+			lo = start
+		}
+		if ranges == 0 || end < hi {
+			hi = end
+		}
+		ranges++
+	}
+	return lo, hi, ranges > 1 && lo <= hi
+}
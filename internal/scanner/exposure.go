@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"net"
+	"strings"
+)
+
+// Exposure levels of a binding's host IP
+const (
+	ExposureLocal   = "local"   // loopback only
+	ExposurePrivate = "private" // a specific private-range address
+	ExposurePublic  = "public"  // every interface or a routable address
+)
+
+// classifyExposure returns the exposure level of a host IP
+func classifyExposure(hostIP string) string {
+	if isWildcardIP(hostIP) {
+		return ExposurePublic
+	}
+	if strings.EqualFold(hostIP, "localhost") {
+		return ExposureLocal
+	}
+
+	ip := net.ParseIP(strings.Trim(hostIP, "[]"))
+	switch {
+	case ip == nil:
+		// Unresolved names and variables could be anything
+		return ExposurePublic
+	case ip.IsLoopback():
+		return ExposureLocal
+	case ip.IsPrivate(), ip.IsLinkLocalUnicast():
+		return ExposurePrivate
+	}
+	return ExposurePublic
+}
+
+// ExposureCounts returns how many effective bindings have each exposure
+// level
+func (r *Result) ExposureCounts() map[string]int {
+	counts := map[string]int{ExposureLocal: 0, ExposurePrivate: 0, ExposurePublic: 0}
+	for _, b := range r.PortBindings {
+		counts[b.Exposure]++
+	}
+	return counts
+}
@@ -0,0 +1,254 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeLoader parses a compose file together with the files it pulls in
+// through top-level include and service extends. Decoded files are cached
+// so a file referenced several times is read once.
+type composeLoader struct {
+	project   string
+	decoded   map[string]*composeFile
+	including map[string]bool // include chain being loaded, to stop cycles
+	included  []string
+	sources   []string // every file read from disk, for the parse cache
+	variables bool     // a file read uses variable substitution
+}
+
+func newComposeLoader(project string) *composeLoader {
+	return &composeLoader{
+		project:   project,
+		decoded:   make(map[string]*composeFile),
+		including: make(map[string]bool),
+	}
+}
+
+// decode reads and decodes one compose file
+func (l *composeLoader) decode(path string) (*composeFile, error) {
+	if compose, ok := l.decoded[path]; ok {
+		return compose, nil
+	}
+
+	l.sources = append(l.sources, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l.variables = l.variables || bytes.Contains(data, []byte("$"))
+	return l.decodeData(path, data)
+}
+
+// decodeData decodes the contents of a compose file and caches them under
+// path, so data need not come from disk
+func (l *composeLoader) decodeData(path string, data []byte) (*composeFile, error) {
+	data = stripBOM(data)
+
+	// yaml.v3 resolves merge keys (<<: *base) while decoding, with keys
+	// set on the service itself taking precedence. Sequences such as ports
+	// are replaced, never concatenated.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, describeYAMLError(data, err)
+	}
+	var compose composeFile
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&compose); err != nil {
+			return nil, describeYAMLError(data, err)
+		}
+	}
+	compose.portNodes = portNodes(&doc)
+	l.decoded[path] = &compose
+	return &compose, nil
+}
+
+// parseComposeFile returns the services of a compose file followed by
+// those of the files it includes. Bindings keep the file they were
+// declared in.
+func (l *composeLoader) parseComposeFile(path string) ([]parsedService, error) {
+	compose, err := l.decode(path)
+	if err != nil {
+		return nil, err
+	}
+	l.including[path] = true
+	defer delete(l.including, path)
+
+	// Visit services in name order so bindings are deterministic
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var services []parsedService
+	for _, serviceName := range names {
+		visiting := map[string]bool{path + "|" + serviceName: true}
+		services = append(services, l.parseService(path, compose, serviceName, serviceName, visiting))
+	}
+
+	for _, include := range includePaths(compose.Include) {
+		included := resolveRelative(path, include)
+		if l.including[included] {
+			// compose rejects include cycles; stop rather than recurse
+			continue
+		}
+		l.included = append(l.included, included)
+		sub, err := l.parseComposeFile(included)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", include, err)
+		}
+		services = append(services, sub...)
+	}
+
+	return services, nil
+}
+
+// parseService parses the service name declared in file under the name as,
+// following extends first so inherited ports come before local ones, as
+// compose merges them. visiting holds the file|service definitions on the
+// current extends chain.
+func (l *composeLoader) parseService(file string, compose *composeFile, name, as string, visiting map[string]bool) parsedService {
+	svc := compose.Services[name]
+	ps := parsedService{
+		Name:          as,
+		Image:         svc.Image,
+		ContainerName: svc.ContainerName,
+		Profiles:      svc.Profiles,
+		HasPorts:      svc.Ports != nil,
+	}
+
+	if svc.Extends != nil {
+		base, issue := l.parseExtends(file, compose, svc.Extends, as, visiting)
+		if issue != nil {
+			ps.Issues = append(ps.Issues, *issue)
+		}
+		if base != nil {
+			if ps.Image == "" {
+				ps.Image = base.Image
+			}
+			if ps.ContainerName == "" {
+				ps.ContainerName = base.ContainerName
+			}
+			ps.HasPorts = ps.HasPorts || base.HasPorts
+			ps.Bindings = append(ps.Bindings, base.Bindings...)
+			ps.Issues = append(ps.Issues, base.Issues...)
+			ps.Exposed = append(ps.Exposed, base.Exposed...)
+		}
+	}
+
+	for _, entry := range svc.Expose {
+		for _, e := range parseExpose(entry) {
+			e.Service, e.File, e.Project = as, file, l.project
+			ps.Exposed = append(ps.Exposed, e)
+		}
+	}
+	bindings, issues := parsePorts(svc.Ports, compose.portNodes[name], envFileNames(svc.EnvFile), as, file, l.project)
+	ps.Bindings = append(ps.Bindings, bindings...)
+	ps.Issues = append(ps.Issues, issues...)
+
+	for i := range ps.Bindings {
+		ps.Bindings[i].Image = ps.Image
+		ps.Bindings[i].ContainerName = ps.ContainerName
+	}
+	return ps
+}
+
+// parseExtends parses the service an extends key refers to, either by
+// name in the same file or as a {file, service} map. It returns an issue
+// for references that cannot be followed.
+func (l *composeLoader) parseExtends(file string, compose *composeFile, extends interface{}, as string, visiting map[string]bool) (*parsedService, *Issue) {
+	target, name := file, ""
+	switch v := extends.(type) {
+	case string:
+		name = v
+	case map[string]interface{}:
+		name, _ = v["service"].(string)
+		if f, ok := v["file"].(string); ok && f != "" {
+			target = resolveRelative(file, f)
+		}
+	}
+	if name == "" {
+		return nil, extendsIssue(as, file, "", "names no service")
+	}
+
+	key := target + "|" + name
+	if visiting[key] {
+		return nil, extendsIssue(as, file, name, "is circular")
+	}
+
+	targetCompose := compose
+	if target != file {
+		var err error
+		if targetCompose, err = l.decode(target); err != nil {
+			return nil, extendsIssue(as, file, name, fmt.Sprintf("could not be read: %v", err))
+		}
+	}
+	if _, ok := targetCompose.Services[name]; !ok {
+		return nil, extendsIssue(as, file, name, "does not exist in "+target)
+	}
+
+	visiting[key] = true
+	defer delete(visiting, key)
+	base := l.parseService(target, targetCompose, name, as, visiting)
+	return &base, nil
+}
+
+// extendsIssue reports an extends reference the scan could not follow
+func extendsIssue(service, file, target, reason string) *Issue {
+	description := fmt.Sprintf("Service %s in %s extends %s, which %s; its inherited ports were not checked",
+		service, file, target, reason)
+	if target == "" {
+		description = fmt.Sprintf("Service %s in %s has an extends key that %s; its inherited ports were not checked",
+			service, file, reason)
+	}
+	return &Issue{
+		Severity:    "warning",
+		Type:        "invalid_extends",
+		Description: description,
+	}
+}
+
+// includePaths returns the files of a top-level include list, whose
+// entries are paths or maps with a path key holding one or more paths
+func includePaths(include []interface{}) []string {
+	var paths []string
+	for _, entry := range include {
+		switch v := entry.(type) {
+		case string:
+			paths = append(paths, v)
+		case map[string]interface{}:
+			switch p := v["path"].(type) {
+			case string:
+				paths = append(paths, p)
+			case []interface{}:
+				for _, item := range p {
+					if s, ok := item.(string); ok {
+						paths = append(paths, s)
+					}
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// resolveRelative resolves a path referenced from a compose file against
+// that file's directory
+func resolveRelative(from, path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(filepath.Dir(from), path)
+}
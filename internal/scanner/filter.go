@@ -0,0 +1,73 @@
+package scanner
+
+// severityRanks orders severities from most to least severe
+var severityRanks = map[string]int{"error": 0, "warning": 1, "info": 2}
+
+// severityRank returns the rank of a severity. Unknown severities rank as
+// info, matching how the reporters bucket them.
+func severityRank(severity string) int {
+	if rank, ok := severityRanks[severity]; ok {
+		return rank
+	}
+	return severityRanks["info"]
+}
+
+// IsSeverity reports whether s is a known severity
+func IsSeverity(s string) bool {
+	_, ok := severityRanks[s]
+	return ok
+}
+
+// FilterBySeverity returns the issues at least as severe as min. An unknown
+// min matches nothing.
+func (r *Result) FilterBySeverity(min string) []Issue {
+	threshold, ok := severityRanks[min]
+	if !ok {
+		return nil
+	}
+
+	var issues []Issue
+	for _, issue := range r.Issues {
+		if severityRank(issue.Severity) <= threshold {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// FilterByType returns the issues of the given types. With no types, every
+// issue is returned.
+func (r *Result) FilterByType(types ...string) []Issue {
+	if len(types) == 0 {
+		return append([]Issue(nil), r.Issues...)
+	}
+
+	wanted := typeSet(types)
+	var issues []Issue
+	for _, issue := range r.Issues {
+		if wanted[issue.Type] {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// IssuesExcluding returns the issues not of the given types
+func (r *Result) IssuesExcluding(types ...string) []Issue {
+	excluded := typeSet(types)
+	var issues []Issue
+	for _, issue := range r.Issues {
+		if !excluded[issue.Type] {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func typeSet(types []string) map[string]bool {
+	set := make(map[string]bool, len(types))
+	for _, t := range types {
+		set[t] = true
+	}
+	return set
+}
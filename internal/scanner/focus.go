@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// IssuesAffecting returns the issues a change to files can introduce:
+// issues with a binding declared in one of the files, plus every issue on
+// a host port those files publish, so collisions with untouched sibling
+// files are kept. Parse errors are kept when they name one of the files.
+func (r *Result) IssuesAffecting(files []string) []Issue {
+	changed := make(map[string]bool, len(files))
+	for _, f := range files {
+		changed[absPath(f)] = true
+	}
+
+	ports := make(map[int]bool)
+	for _, b := range r.RawBindings {
+		if changed[absPath(b.File)] {
+			ports[b.HostPort] = true
+		}
+	}
+
+	// Parse errors name the file as it was discovered
+	var failed []string
+	for _, f := range r.files {
+		if f.Err != nil && changed[absPath(f.Path)] {
+			failed = append(failed, f.Path)
+		}
+	}
+
+	var issues []Issue
+	for _, issue := range r.Issues {
+		if issueAffected(issue, changed, ports, failed) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func issueAffected(issue Issue, changed map[string]bool, ports map[int]bool, failed []string) bool {
+	if len(issue.Bindings) == 0 {
+		for _, path := range failed {
+			if strings.Contains(issue.Description, path) {
+				return true
+			}
+		}
+		return false
+	}
+	if issue.Port != 0 && ports[issue.Port] {
+		return true
+	}
+	for _, b := range issue.Bindings {
+		if changed[absPath(b.File)] {
+			return true
+		}
+	}
+	return false
+}
+
+// absPath returns the absolute form of path with symlinks resolved, as git
+// reports them, or path itself when it cannot be resolved
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hintIssues returns advisory issues for bindings that are useful but
+// prone to false positives, so they only run when Options.Hints is set
+func (r *Result) hintIssues(bindings []PortBinding) []Issue {
+	var issues []Issue
+	for _, binding := range bindings {
+		if r.looksSwapped(binding) {
+			issues = append(issues, Issue{
+				Severity: "info",
+				Type:     "possible_port_swap",
+				Port:     binding.HostPort,
+				Description: fmt.Sprintf("Port %d:%d may have host and container swapped (did you mean %d:%d?)",
+					binding.HostPort, binding.ContainerPort, binding.ContainerPort, binding.HostPort),
+				Bindings: []PortBinding{binding},
+			})
+		}
+		if issue := r.redundantExposeIssue(binding); issue != nil {
+			issues = append(issues, *issue)
+		}
+	}
+	return issues
+}
+
+// exposedPort is a container port listed under a service's expose key
+type exposedPort struct {
+	Port     int
+	Protocol string
+	Service  string
+	File     string
+	Project  string
+}
+
+// parseExpose parses one expose entry such as 80, "80/udp" or "3000-3002"
+func parseExpose(entry interface{}) []exposedPort {
+	spec := strings.TrimSpace(fmt.Sprint(entry))
+	protocol := "tcp"
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		spec, protocol = spec[:i], spec[i+1:]
+	}
+
+	lo, hi := spec, spec
+	if i := strings.Index(spec, "-"); i >= 0 {
+		lo, hi = spec[:i], spec[i+1:]
+	}
+	start, err1 := strconv.Atoi(lo)
+	end, err2 := strconv.Atoi(hi)
+	if err1 != nil || err2 != nil || start < 1 || end > 65535 || start > end {
+		return nil
+	}
+
+	var ports []exposedPort
+	for port := start; port <= end; port++ {
+		ports = append(ports, exposedPort{Port: port, Protocol: protocol})
+	}
+	return ports
+}
+
+// redundantExposeIssue notes an expose entry for a container port the
+// binding's service already publishes, since publishing a port also
+// exposes it
+func (r *Result) redundantExposeIssue(b PortBinding) *Issue {
+	for _, e := range r.exposed {
+		if b.Project != e.Project || b.Service != e.Service ||
+			b.ContainerPort != e.Port || b.Protocol != e.Protocol {
+			continue
+		}
+		return &Issue{
+			Severity: "info",
+			Type:     "redundant_expose",
+			Port:     e.Port,
+			Description: fmt.Sprintf("Service %s exposes container port %d, which ports already publishes as %s",
+				e.Service, e.Port, b.String()),
+			Bindings: []PortBinding{b},
+		}
+	}
+	return nil
+}
+
+// looksSwapped reports whether a binding publishes a well-known service port
+// onto a higher container port that is an obvious variant of it, such as
+// 80:8080 or 5432:15432. Ordinary mappings like 8080:80 never match.
+func (r *Result) looksSwapped(b PortBinding) bool {
+	if _, known := r.commonPortName(b.HostPort); !known || b.ContainerPort <= b.HostPort {
+		return false
+	}
+	if b.ContainerPort < 1024 {
+		return false
+	}
+
+	host := strconv.Itoa(b.HostPort)
+	container := strconv.Itoa(b.ContainerPort)
+	return strings.HasPrefix(container, host) || strings.HasSuffix(container, host)
+}
@@ -0,0 +1,32 @@
+package scanner
+
+import "fmt"
+
+// hostModeBindings returns the long syntax bindings with mode: host. They
+// publish on every node directly, bypassing the swarm routing mesh, so two
+// of them on one port always clash.
+func hostModeBindings(bindings []PortBinding) []PortBinding {
+	var host []PortBinding
+	for _, b := range bindings {
+		if b.Mode == "host" {
+			host = append(host, b)
+		}
+	}
+	return host
+}
+
+// hostModeIssue notes a mode: host binding, whose port is claimed on each
+// node rather than load balanced through the routing mesh
+func hostModeIssue(b PortBinding) *Issue {
+	if b.Mode != "host" {
+		return nil
+	}
+	return &Issue{
+		Severity: "info",
+		Type:     "host_mode",
+		Port:     b.HostPort,
+		Description: fmt.Sprintf("Port %d of %s uses mode: host, which bypasses the routing mesh and binds it on every node",
+			b.HostPort, b.Service),
+		Bindings: []PortBinding{b},
+	}
+}
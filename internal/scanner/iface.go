@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// interfacePortRegex matches short syntax whose host part is an interface
+// name, as produced by some wrappers: eth0:8080:80
+var interfacePortRegex = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_.-]*):(\d+:\d+(?:/(?i:tcp|udp))?)$`)
+
+// interfaceAddrs returns the current addresses of a network interface. It
+// is a variable so tests can stub it.
+var interfaceAddrs = func(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips, nil
+}
+
+// parseInterfacePort parses an entry bound to a named interface into one
+// binding per address of the interface. When the interface cannot be
+// resolved the binding keeps the name as its host IP and an info issue is
+// returned. ok is false when spec does not name an interface.
+func parseInterfacePort(spec, service, file string) (bindings []PortBinding, issue *Issue, ok bool) {
+	match := interfacePortRegex.FindStringSubmatch(spec)
+	if match == nil || strings.EqualFold(match[1], "localhost") {
+		return nil, nil, false
+	}
+	name := match[1]
+
+	binding := parsePort(match[2], service, file)
+	if binding == nil {
+		return nil, nil, false
+	}
+	binding.Original = spec
+	if issue := portBoundsIssue(binding.HostPort, binding.ContainerPort, spec, service, file); issue != nil {
+		return nil, issue, true
+	}
+
+	ips, err := interfaceAddrs(name)
+	if err != nil || len(ips) == 0 {
+		binding.HostIP = name
+		reason := "it has no addresses"
+		if err != nil {
+			reason = err.Error()
+		}
+		return []PortBinding{*binding}, &Issue{
+			Severity: "info",
+			Type:     "unresolved_interface",
+			Port:     binding.HostPort,
+			Description: fmt.Sprintf("Could not resolve interface %s for %s (%s); overlap analysis treats it as a distinct address",
+				name, service, reason),
+			Bindings: []PortBinding{*binding},
+		}, true
+	}
+
+	for _, ip := range ips {
+		b := *binding
+		b.HostIP = ip.String()
+		bindings = append(bindings, b)
+	}
+	return bindings, nil, true
+}
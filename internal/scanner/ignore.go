@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// IgnoreFile lists issues to suppress, picked up from the scanned directory
+const IgnoreFile = ".portcheckignore"
+
+// IgnoreRule suppresses the issues matching one field: port, type, service
+// or file
+type IgnoreRule struct {
+	Field string
+	Value string
+}
+
+// ParseIgnoreRule parses a "field:value" rule such as port:8080
+func ParseIgnoreRule(spec string) (IgnoreRule, error) {
+	field, value, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	rule := IgnoreRule{Field: strings.ToLower(strings.TrimSpace(field)), Value: strings.TrimSpace(value)}
+	if !ok || rule.Value == "" {
+		return rule, fmt.Errorf("invalid ignore rule %q (want field:value)", spec)
+	}
+	switch rule.Field {
+	case "port":
+		if port, err := strconv.Atoi(rule.Value); err != nil || port < 0 || port > 65535 {
+			return rule, fmt.Errorf("invalid port in ignore rule %q", spec)
+		}
+	case "type", "service", "file":
+	default:
+		return rule, fmt.Errorf("unknown field %q in ignore rule %q (want port, type, service or file)", rule.Field, spec)
+	}
+	return rule, nil
+}
+
+// LoadIgnoreFile reads one ignore rule per line, skipping blank lines and
+// # comments
+func LoadIgnoreFile(path string) ([]IgnoreRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []IgnoreRule
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := ParseIgnoreRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, s.Err()
+}
+
+// Matches reports whether the rule suppresses issue. Service and file rules
+// match when any binding of the issue does; a file rule matches the file's
+// name or a trailing part of its path.
+func (rule IgnoreRule) Matches(issue Issue) bool {
+	switch rule.Field {
+	case "port":
+		return strconv.Itoa(issue.Port) == rule.Value
+	case "type":
+		return issue.Type == rule.Value
+	}
+
+	for _, b := range issue.Bindings {
+		switch rule.Field {
+		case "service":
+			if b.Service == rule.Value {
+				return true
+			}
+		case "file":
+			file := filepath.ToSlash(b.File)
+			value := filepath.ToSlash(rule.Value)
+			if file == value || strings.HasSuffix(file, "/"+value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ApplyIgnores removes the issues matched by the ignore rules of the scan
+// and adds them to Suppressed. It is applied after analysis; callers
+// adding issues of their own may call it again.
+func (r *Result) ApplyIgnores() {
+	if len(r.ignore) == 0 {
+		return
+	}
+
+	kept := r.Issues[:0]
+	for _, issue := range r.Issues {
+		if r.ignored(issue) {
+			r.Suppressed++
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	r.Issues = kept
+}
+
+func (r *Result) ignored(issue Issue) bool {
+	for _, rule := range r.ignore {
+		if rule.Matches(issue) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadIgnores combines the rules of opts with those of the ignore file in
+// dir, if there is one
+func loadIgnores(dir string, opts Options) ([]IgnoreRule, error) {
+	rules := append([]IgnoreRule{}, opts.Ignore...)
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	path := filepath.Join(dir, IgnoreFile)
+	if _, err := os.Stat(path); err != nil {
+		return rules, nil
+	}
+	fromFile, err := LoadIgnoreFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, fromFile...), nil
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import "fmt"
+
+// UpdateFile re-parses one compose file of the scan after it changed and
+// recomputes the issues of the host ports it affects, leaving the issues of
+// every other port untouched. The result matches a full rescan of the same
+// files.
+func (r *Result) UpdateFile(path string) error {
+	i := -1
+	for j, f := range r.files {
+		if f.Path == path {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return fmt.Errorf("%s is not part of this scan", path)
+	}
+
+	before := r.PortBindings
+	old := r.files[i]
+	if old.Manifest {
+		r.files[i] = parseManifest(path, old.Project)
+	} else {
+		r.files[i] = r.opts.parseFile(path, old.Project)
+		r.opts.Cache.Save()
+	}
+
+	derived := r.derived
+	r.build()
+	r.derived = derived
+
+	r.reanalyze(touchedPorts(before, r.PortBindings, old, r.files[i]))
+	return nil
+}
+
+// touchedPorts returns the host ports whose analysis may differ after a
+// file changed: ports whose bindings differ, plus every port published by a
+// service declared in the old or new version of the file, since changes to
+// that service's other keys (image, expose) affect its issues too
+func touchedPorts(before, after []PortBinding, files ...parsedFile) map[int]bool {
+	services := make(map[string]bool)
+	for _, f := range files {
+		for _, svc := range f.Services {
+			services[f.Project+"/"+svc.Name] = true
+		}
+	}
+
+	counts := make(map[PortBinding]int)
+	for _, b := range before {
+		counts[b]++
+	}
+	for _, b := range after {
+		counts[b]--
+	}
+
+	touched := make(map[int]bool)
+	for b, n := range counts {
+		if n != 0 {
+			touched[b.HostPort] = true
+		}
+	}
+	for _, bindings := range [][]PortBinding{before, after} {
+		for _, b := range bindings {
+			if services[b.Project+"/"+b.Service] {
+				touched[b.HostPort] = true
+			}
+		}
+	}
+	return touched
+}
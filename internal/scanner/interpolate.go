@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// variableRegex matches compose variable references: $$, $VAR, ${VAR} and
+// ${VAR<op>value} with op one of :- - :+ + :? ?
+var variableRegex = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-+?])([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// lookupFunc returns the value of a variable and whether it is set
+type lookupFunc func(name string) (string, bool)
+
+// envLookup resolves variables like docker compose: the process
+// environment first, then the .env file next to the compose file, then
+// the service's env_file entries in order
+func envLookup(composePath string, envFiles []string) lookupFunc {
+	dir := filepath.Dir(composePath)
+	sources := []map[string]string{loadDotEnv(filepath.Join(dir, ".env"))}
+	for _, name := range envFiles {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		sources = append(sources, loadDotEnv(name))
+	}
+	return func(name string) (string, bool) {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true
+		}
+		for _, vars := range sources {
+			if v, ok := vars[name]; ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+}
+
+// envFileNames returns the paths of a service's env_file key, which is a
+// single path, a list of paths or a list of {path, required} mappings
+func envFileNames(envFile interface{}) []string {
+	var names []string
+	switch ef := envFile.(type) {
+	case string:
+		names = append(names, ef)
+	case []interface{}:
+		for _, entry := range ef {
+			switch e := entry.(type) {
+			case string:
+				names = append(names, e)
+			case map[string]interface{}:
+				if path, ok := e["path"].(string); ok {
+					names = append(names, path)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// loadDotEnv reads KEY=VALUE lines from a .env file, ignoring blank lines
+// and comments. A missing file has no variables.
+func loadDotEnv(path string) map[string]string {
+	vars := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return vars
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[strings.TrimSpace(name)] = value
+	}
+	return vars
+}
+
+// interpolate substitutes the variables of a port string. It returns the
+// names of variables that are unset and have no default, in which case the
+// result is incomplete.
+func interpolate(spec string, lookup lookupFunc) (string, []string) {
+	var missing []string
+	resolved := variableRegex.ReplaceAllStringFunc(spec, func(ref string) string {
+		if ref == "$$" {
+			return "$"
+		}
+		m := variableRegex.FindStringSubmatch(ref)
+		name, op, arg := m[1], m[2], m[3]
+		if name == "" {
+			name = m[4]
+		}
+
+		value, set := lookup(name)
+		empty := !set || value == ""
+		switch op {
+		case ":-":
+			if empty {
+				return arg
+			}
+		case "-":
+			if !set {
+				return arg
+			}
+		case ":+":
+			if empty {
+				return ""
+			}
+			return arg
+		case "+":
+			if !set {
+				return ""
+			}
+			return arg
+		case ":?":
+			if empty {
+				missing = append(missing, name)
+			}
+		default:
+			// ${VAR}, $VAR and ${VAR?err} need the variable to be set
+			if !set {
+				missing = append(missing, name)
+			}
+		}
+		return value
+	})
+	return resolved, missing
+}
+
+// unresolvedBinding records a port entry whose host port depends on unset
+// variables. resolved is the entry with those variables left empty; the
+// container port, protocol and host IP are taken from it when they do not
+// depend on them.
+func unresolvedBinding(resolved, spec, service, file string) PortBinding {
+	b := PortBinding{Service: service, File: file, Protocol: "tcp", Original: spec, Unresolved: true}
+	if i := strings.LastIndex(resolved, "/"); i >= 0 {
+		if protocol := strings.ToLower(resolved[i+1:]); protocol == "tcp" || protocol == "udp" {
+			b.Protocol = protocol
+		}
+		resolved = resolved[:i]
+	}
+	parts := strings.Split(resolved, ":")
+	if port, err := strconv.Atoi(parts[len(parts)-1]); err == nil && len(parts) > 1 {
+		b.ContainerPort = port
+	}
+	if len(parts) == 3 {
+		b.HostIP = parts[0]
+	}
+	return b
+}
+
+// unresolvedIssue reports a port entry whose host port depends on unset
+// variables, so the scan says what it could not check rather than
+// skipping the entry silently
+func unresolvedIssue(b PortBinding, missing []string) *Issue {
+	return &Issue{
+		Severity: "info",
+		Type:     "unresolved_port",
+		Description: fmt.Sprintf("Port %s in %s depends on unset %s without a default; it was not checked",
+			b.Original, b.Service, strings.Join(missing, ", ")),
+		Bindings: []PortBinding{b},
+	}
+}
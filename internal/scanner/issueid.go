@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// IssueID returns a stable, content-derived identity for an issue: a hash
+// of its type, host port, protocol and the sorted service and file pairs
+// of its bindings. Severity, description and binding order do not affect
+// it, so reworded messages keep their ID. Issues without bindings, such as
+// parse errors, are told apart by their description instead.
+func IssueID(issue Issue) string {
+	var pairs []string
+	seen := make(map[string]bool)
+	for _, b := range issue.Bindings {
+		pair := b.Service + "\x00" + b.File
+		if !seen[pair] {
+			seen[pair] = true
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Strings(pairs)
+	if len(pairs) == 0 {
+		pairs = []string{issue.Description}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(issue.Type + "\x00" + normalizeProtocol(issueProtocol(issue))))
+	h.Write([]byte{byte(issue.Port >> 8), byte(issue.Port)})
+	for _, pair := range pairs {
+		h.Write([]byte("\x00" + pair))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// AssignIDs sets the ID of every issue that does not have one yet
+func AssignIDs(issues []Issue) {
+	for i := range issues {
+		if issues[i].ID == "" {
+			issues[i].ID = IssueID(issues[i])
+		}
+	}
+}
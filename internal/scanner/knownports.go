@@ -0,0 +1,38 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadKnownPorts reads a YAML mapping of host ports to service names, such
+// as "9090: Prometheus", for Options.KnownPorts
+func LoadKnownPorts(path string) (map[int]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var known map[int]string
+	if err := yaml.Unmarshal(data, &known); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for port := range known {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%s: invalid port %d", path, port)
+		}
+	}
+	return known, nil
+}
+
+// commonPortName returns the service usually found on a host port, from
+// Options.KnownPorts or the built-in common ports
+func (r *Result) commonPortName(port int) (string, bool) {
+	if name, ok := r.opts.KnownPorts[port]; ok {
+		return name, name != ""
+	}
+	name, ok := commonPorts[port]
+	return name, ok
+}
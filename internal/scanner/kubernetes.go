@@ -0,0 +1,194 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourceKubernetes marks bindings read from Kubernetes manifests. Bindings
+// from compose files have an empty Source.
+const SourceKubernetes = "kubernetes"
+
+// k8sObject is the part of a Kubernetes manifest that can publish host
+// ports: a Service's nodePorts, or the hostPorts of a pod spec, directly
+// in a Pod or in a workload's pod template
+type k8sObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Ports      []yaml.Node    `yaml:"ports"`
+		Containers []k8sContainer `yaml:"containers"`
+		Template   k8sTemplate    `yaml:"template"`
+		// CronJob nests its pod template one level deeper
+		JobTemplate struct {
+			Spec struct {
+				Template k8sTemplate `yaml:"template"`
+			} `yaml:"spec"`
+		} `yaml:"jobTemplate"`
+	} `yaml:"spec"`
+}
+
+type k8sTemplate struct {
+	Spec struct {
+		Containers     []k8sContainer `yaml:"containers"`
+		InitContainers []k8sContainer `yaml:"initContainers"`
+	} `yaml:"spec"`
+}
+
+type k8sContainer struct {
+	Name  string      `yaml:"name"`
+	Image string      `yaml:"image"`
+	Ports []yaml.Node `yaml:"ports"`
+}
+
+// k8sServicePort is an entry of a Service's spec.ports
+type k8sServicePort struct {
+	Protocol string `yaml:"protocol"`
+	Port     int    `yaml:"port"`
+	NodePort int    `yaml:"nodePort"`
+}
+
+// k8sContainerPort is an entry of a container's ports
+type k8sContainerPort struct {
+	Protocol      string `yaml:"protocol"`
+	ContainerPort int    `yaml:"containerPort"`
+	HostPort      int    `yaml:"hostPort"`
+	HostIP        string `yaml:"hostIP"`
+}
+
+// kubernetesFiles expands the Options.Kubernetes paths into manifest files:
+// files are kept, directories contribute their *.yaml and *.yml files
+func kubernetesFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, _ := filepath.Glob(filepath.Join(path, pattern))
+			files = append(files, matches...)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// addManifests parses the Kubernetes manifests of r's options, recording
+// them alongside the compose files of the scan of basePath
+func (r *Result) addManifests(basePath string) {
+	for _, file := range kubernetesFiles(r.opts.Kubernetes) {
+		r.Manifests = append(r.Manifests, file)
+		r.files = append(r.files, parseManifest(file, projectOf(basePath, file)))
+	}
+}
+
+// parseManifest parses a Kubernetes manifest, which may hold several
+// documents separated by ---, into one service per object publishing a
+// nodePort or hostPort. Objects of other kinds are ignored.
+func parseManifest(path, project string) parsedFile {
+	f := parsedFile{Path: path, Project: project, Manifest: true}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		f.Err = err
+		return f
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(stripBOM(data)))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			f.Err = err
+			return f
+		}
+
+		var obj k8sObject
+		if err := doc.Decode(&obj); err != nil {
+			f.Err = err
+			return f
+		}
+		if svc, ok := manifestService(obj, path, project); ok {
+			f.Services = append(f.Services, svc)
+		}
+	}
+	return f
+}
+
+// manifestService returns the bindings of one Kubernetes object as a
+// service named kind/name, e.g. Service/web
+func manifestService(obj k8sObject, path, project string) (parsedService, bool) {
+	name := obj.Kind + "/" + obj.Metadata.Name
+	if obj.Metadata.Namespace != "" {
+		name = obj.Metadata.Namespace + "/" + name
+	}
+	svc := parsedService{Name: name}
+
+	add := func(b PortBinding, node yaml.Node) {
+		b.Protocol = strings.ToLower(b.Protocol)
+		if b.Protocol == "" {
+			b.Protocol = "tcp"
+		}
+		b.Exposure = classifyExposure(b.HostIP)
+		b.Service = name
+		b.File = path
+		b.Project = project
+		b.Source = SourceKubernetes
+		b.Line, b.Column = node.Line, node.Column
+		svc.Bindings = append(svc.Bindings, b)
+	}
+
+	if obj.Kind == "Service" {
+		for _, node := range obj.Spec.Ports {
+			var p k8sServicePort
+			if node.Decode(&p) != nil || p.NodePort == 0 {
+				continue
+			}
+			add(PortBinding{
+				HostPort:      p.NodePort,
+				ContainerPort: p.Port,
+				Protocol:      p.Protocol,
+				Original:      fmt.Sprintf("nodePort %d -> %d", p.NodePort, p.Port),
+			}, node)
+		}
+		return svc, len(svc.Bindings) > 0
+	}
+
+	containers := append([]k8sContainer{}, obj.Spec.Containers...)
+	for _, t := range []k8sTemplate{obj.Spec.Template, obj.Spec.JobTemplate.Spec.Template} {
+		containers = append(containers, t.Spec.Containers...)
+		containers = append(containers, t.Spec.InitContainers...)
+	}
+	for _, c := range containers {
+		for _, node := range c.Ports {
+			var p k8sContainerPort
+			if node.Decode(&p) != nil || p.HostPort == 0 {
+				continue
+			}
+			add(PortBinding{
+				HostPort:      p.HostPort,
+				ContainerPort: p.ContainerPort,
+				Protocol:      p.Protocol,
+				HostIP:        p.HostIP,
+				Image:         c.Image,
+				Original:      fmt.Sprintf("hostPort %d -> %d (container %s)", p.HostPort, p.ContainerPort, c.Name),
+			}, node)
+		}
+	}
+	return svc, len(svc.Bindings) > 0
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import "path/filepath"
+
+// mergeOverrides folds every override file into the base compose file
+// loaded before it from the same directory, applying compose override
+// semantics: a service's ports in the override replace the ports declared
+// for it in the base, while expose entries are combined. Other files, and
+// overrides without a base, are kept as they are. Bindings keep the File
+// they were declared in.
+func mergeOverrides(files []parsedFile, isOverride func(path string) bool) []parsedFile {
+	var merged []parsedFile
+	bases := make(map[string]int) // directory -> position of its base in merged
+
+	for _, f := range files {
+		dir := filepath.Dir(f.Path)
+		i, hasBase := bases[dir]
+		if hasBase && isOverride(f.Path) {
+			merged[i].Services = overrideServices(merged[i].Services, f.Services)
+			continue
+		}
+		if !hasBase && hasName(f.Path, baseComposeNames) {
+			bases[dir] = len(merged)
+		}
+		merged = append(merged, f)
+	}
+
+	return merged
+}
+
+// mergeInOrder folds every file into the first one, each overriding the
+// files before it, as docker compose does with repeated -f flags
+func mergeInOrder(files []parsedFile) []parsedFile {
+	if len(files) == 0 {
+		return nil
+	}
+	merged := files[0]
+	for _, f := range files[1:] {
+		merged.Services = overrideServices(merged.Services, f.Services)
+	}
+	return []parsedFile{merged}
+}
+
+// hasName reports whether the file name of path is one of names
+func hasName(path string, names []string) bool {
+	base := filepath.Base(path)
+	for _, name := range names {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideServices applies override's services on top of base
+func overrideServices(base, override []parsedService) []parsedService {
+	result := append([]parsedService{}, base...)
+
+	for _, svc := range override {
+		found := false
+		for i := range result {
+			if result[i].Name != svc.Name {
+				continue
+			}
+			found = true
+			// expose entries are merged rather than replaced
+			exposed := append(append([]exposedPort{}, result[i].Exposed...), svc.Exposed...)
+			image, containerName, profiles := result[i].Image, result[i].ContainerName, result[i].Profiles
+			if svc.Image != "" {
+				image = svc.Image
+			}
+			if svc.ContainerName != "" {
+				containerName = svc.ContainerName
+			}
+			if svc.Profiles != nil {
+				profiles = svc.Profiles
+			}
+			if svc.HasPorts {
+				result[i] = svc
+			}
+			result[i].Exposed = exposed
+			result[i].Image = image
+			result[i].ContainerName = containerName
+			result[i].Profiles = profiles
+		}
+		if !found {
+			result = append(result, svc)
+		}
+	}
+
+	return result
+}
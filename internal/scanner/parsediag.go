@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// publishedRangeRegex matches a long syntax published port range such as
+// "8000-8005", which longSyntaxInt does not read
+var publishedRangeRegex = regexp.MustCompile(`^\d+-\d+$`)
+
+// unparsedIssue reports a ports entry that parses as neither a binding nor
+// an invalid range, naming its YAML path so it can be found in large
+// files. A long syntax entry names the offending key.
+func unparsedIssue(port interface{}, service, file string, index int) *Issue {
+	raw := fmt.Sprint(port)
+	problem := fmt.Sprintf("%q", raw)
+	if v, ok := port.(map[string]interface{}); ok {
+		problem = longSyntaxProblem(v)
+	}
+	return &Issue{
+		Severity: "warning",
+		Type:     "parse",
+		Description: fmt.Sprintf("Could not parse services.%s.ports[%d] in %s: %s",
+			service, index, file, problem),
+		Bindings: []PortBinding{{Service: service, File: file, Original: raw}},
+	}
+}
+
+// longSyntaxProblem describes why a long syntax ports entry cannot be
+// read: an unknown key, or a missing or non-numeric target or published
+// port. It returns "" for an entry parseBinding can read.
+func longSyntaxProblem(v map[string]interface{}) string {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		known := strings.HasPrefix(key, "x-")
+		for _, longKey := range longSyntaxKeys {
+			known = known || key == longKey
+		}
+		if !known {
+			return fmt.Sprintf("unknown key %q", key)
+		}
+	}
+
+	target, present := v["target"]
+	if !present {
+		return `missing key "target"`
+	}
+	if _, ok := longSyntaxInt(target); !ok {
+		return fmt.Sprintf("target %q is not a port number", fmt.Sprint(target))
+	}
+	if published, present := v["published"]; present {
+		if _, ok := longSyntaxInt(published); !ok && !publishedRangeRegex.MatchString(fmt.Sprint(published)) {
+			return fmt.Sprintf("published %q is not a port number", fmt.Sprint(published))
+		}
+	}
+	return ""
+}
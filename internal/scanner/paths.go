@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadPathsFile reads newline-separated paths to scan, skipping blank lines
+// and lines starting with #
+func ReadPathsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// ScanPaths scans every path with opts and merges the results into one
+// report, so collisions between the paths are detected too
+func ScanPaths(paths []string, opts Options) (*Result, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths to scan")
+	}
+
+	var merged *Result
+	for _, path := range paths {
+		result, err := ScanWithOptions(path, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if merged == nil {
+			merged = result
+			// The manifests belong to the report, not to each path
+			opts.Kubernetes = nil
+			continue
+		}
+		merged.Merge(result)
+	}
+	merged.Path = strings.Join(paths, ", ")
+	return merged, nil
+}
+
+// ScanFiles scans the given compose files as one project, like docker
+// compose -f: no other files are discovered, and each file is merged onto
+// the ones before it with override semantics, whatever its name. Result.Path
+// is the directory of the first file.
+func ScanFiles(paths []string, opts Options) (*Result, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose files to scan")
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory, not a compose file", path)
+		}
+	}
+
+	dir := filepath.Dir(paths[0])
+	ignore, err := loadIgnores(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	r := &Result{
+		Path:         dir,
+		ComposeFiles: append([]string{}, paths...),
+		PortMap:      make(map[int][]PortBinding),
+		opts:         opts,
+		explicit:     true,
+		ignore:       ignore,
+	}
+	project := projectOf(dir, paths[0])
+	r.files = opts.parseFiles(r.ComposeFiles, func(string) string { return project })
+	r.addManifests(dir)
+	opts.Cache.Save()
+
+	r.build()
+	r.analyze()
+	return r, nil
+}
+
+// StdinLabel is the file name reported for compose content read from
+// standard input
+const StdinLabel = "<stdin>"
+
+// ScanReader scans one compose document read from rd, reporting its
+// bindings as declared in the file label. No files are discovered.
+// Relative include, extends, env_file and .env paths, and ignore rules,
+// are resolved from the working directory.
+func ScanReader(rd io.Reader, label string, opts Options) (*Result, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := loadIgnores(".", opts)
+	if err != nil {
+		return nil, err
+	}
+	r := &Result{
+		Path:         label,
+		ComposeFiles: []string{label},
+		PortMap:      make(map[int][]PortBinding),
+		opts:         opts,
+		explicit:     true,
+		ignore:       ignore,
+	}
+
+	project := projectOf(".", label)
+	loader := newComposeLoader(project)
+	f := parsedFile{Path: label, Project: project}
+	if _, f.Err = loader.decodeData(label, data); f.Err == nil {
+		f.Services, f.Err = loader.parseComposeFile(label)
+		f.Includes = loader.included
+	}
+	r.files = append(r.files, f)
+	r.addManifests(".")
+
+	r.build()
+	r.analyze()
+	return r, nil
+}
+
+// Merge adds the files and bindings of other to r and re-runs the analysis
+// over the combined bindings. Parse issues from both results are kept.
+func (r *Result) Merge(other *Result) {
+	r.ComposeFiles = append(r.ComposeFiles, other.ComposeFiles...)
+	r.Manifests = append(r.Manifests, other.Manifests...)
+	r.files = append(r.files, other.files...)
+	r.ignore = append(r.ignore, other.ignore...)
+	r.build()
+	r.analyze()
+}
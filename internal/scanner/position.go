@@ -0,0 +1,71 @@
+package scanner
+
+import "gopkg.in/yaml.v3"
+
+// portNodes returns the ports entry nodes of every service in a decoded
+// compose document, so bindings can record where they were declared.
+// Aliases and merge keys are followed, with keys set on a service taking
+// precedence as when decoding.
+func portNodes(doc *yaml.Node) map[string][]*yaml.Node {
+	nodes := make(map[string][]*yaml.Node)
+	if doc == nil || len(doc.Content) == 0 {
+		return nodes
+	}
+
+	services := nodeValue(doc.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nodes
+	}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		ports := nodeValue(services.Content[i+1], "ports")
+		if ports == nil || ports.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, entry := range ports.Content {
+			nodes[services.Content[i].Value] = append(nodes[services.Content[i].Value], resolveAlias(entry))
+		}
+	}
+	return nodes
+}
+
+// nodeValue returns the value of key in a mapping node, looking in merged
+// mappings when the node does not set it itself
+func nodeValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var merged []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if k.Tag == "!!merge" {
+			merged = append(merged, resolveAlias(v))
+			continue
+		}
+		if k.Value == key {
+			return resolveAlias(v)
+		}
+	}
+
+	for _, m := range merged {
+		sources := []*yaml.Node{m}
+		if m != nil && m.Kind == yaml.SequenceNode {
+			sources = m.Content
+		}
+		for _, source := range sources {
+			if v := nodeValue(source, key); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// resolveAlias returns the node an alias refers to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// rangeRegex matches short syntax with a port range on either side:
+// "8000-8005", "8000-8005:9000-9005", "127.0.0.1:9000-9002:80/udp"
+var rangeRegex = regexp.MustCompile(`^(?:` + hostPrefixPattern + `:)?(\d+)(?:-(\d+))?(?::(\d+)(?:-(\d+))?)?(?:/((?i)tcp|udp))?$`)
+
+// portRange is a parsed range port declaration
+type portRange struct {
+	HostIP         string
+	HostStart      int
+	HostEnd        int
+	ContainerStart int
+	ContainerEnd   int
+	Protocol       string
+	RandomHostPort bool // bare "8000-8005": container ports on random host ports
+}
+
+// parseRange parses a short-syntax range declaration. It returns nil, nil
+// when spec is not a range and an invalid_range issue when the range is
+// reversed or its host and container spans differ in length. A single
+// container port is shared by every host port of the range.
+func parseRange(spec, service, file string) (*portRange, *Issue) {
+	match := rangeRegex.FindStringSubmatch(spec)
+	if match == nil || (match[3] == "" && match[5] == "") {
+		return nil, nil
+	}
+
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	r := &portRange{HostIP: match[1], Protocol: "tcp"}
+	r.HostStart = atoi(match[2])
+	r.HostEnd = r.HostStart
+	if match[3] != "" {
+		r.HostEnd = atoi(match[3])
+	}
+
+	switch {
+	case match[4] == "" && r.HostIP == "":
+		// "8000-8005" publishes container ports on random host ports,
+		// like a bare "80"
+		r.ContainerStart, r.ContainerEnd = r.HostStart, r.HostEnd
+		r.RandomHostPort = true
+	case match[4] == "":
+		// "127.0.0.1:8000-8005" publishes the same range on both sides
+		r.ContainerStart, r.ContainerEnd = r.HostStart, r.HostEnd
+	case match[5] == "":
+		r.ContainerStart = atoi(match[4])
+		r.ContainerEnd = r.ContainerStart
+	default:
+		r.ContainerStart, r.ContainerEnd = atoi(match[4]), atoi(match[5])
+	}
+	if match[6] != "" {
+		r.Protocol = match[6]
+	}
+
+	invalid := func(description string) *Issue {
+		return &Issue{
+			Severity:    "error",
+			Type:        "invalid_range",
+			Port:        r.HostStart,
+			Description: description,
+			Bindings:    []PortBinding{{Service: service, File: file, Protocol: r.Protocol, Original: spec}},
+		}
+	}
+
+	if r.HostEnd > 65535 || r.ContainerEnd > 65535 {
+		return nil, portBoundsIssue(r.HostEnd, r.ContainerEnd, spec, service, file)
+	}
+	if r.HostStart > r.HostEnd {
+		return nil, invalid(fmt.Sprintf("Port range %d-%d in %s is reversed", r.HostStart, r.HostEnd, service))
+	}
+	if r.ContainerStart > r.ContainerEnd {
+		return nil, invalid(fmt.Sprintf("Container port range %d-%d in %s is reversed",
+			r.ContainerStart, r.ContainerEnd, service))
+	}
+
+	hostSpan := r.HostEnd - r.HostStart + 1
+	containerSpan := r.ContainerEnd - r.ContainerStart + 1
+	if containerSpan != 1 && containerSpan != hostSpan {
+		return nil, invalid(fmt.Sprintf("Port range %s in %s maps %d host ports (%d-%d) to %d container ports (%d-%d)",
+			spec, service, hostSpan, r.HostStart, r.HostEnd, containerSpan, r.ContainerStart, r.ContainerEnd))
+	}
+
+	return r, nil
+}
+
+// bindings expands a range into one binding per host port. A single
+// container port is shared by every host port. A bare range yields one
+// random host port binding per container port.
+func (r *portRange) bindings(spec, service, file string) []PortBinding {
+	hostRange := fmt.Sprintf("%d-%d", r.HostStart, r.HostEnd)
+
+	var bindings []PortBinding
+	for i := 0; i <= r.HostEnd-r.HostStart; i++ {
+		if r.RandomHostPort {
+			bindings = append(bindings, PortBinding{
+				ContainerPort:  r.ContainerStart + i,
+				Protocol:       r.Protocol,
+				Service:        service,
+				File:           file,
+				Original:       spec,
+				RandomHostPort: true,
+			})
+			continue
+		}
+		containerPort := r.ContainerStart
+		if r.ContainerEnd != r.ContainerStart {
+			containerPort += i
+		}
+		bindings = append(bindings, PortBinding{
+			HostPort:      r.HostStart + i,
+			ContainerPort: containerPort,
+			Protocol:      r.Protocol,
+			HostIP:        r.HostIP,
+			Service:       service,
+			File:          file,
+			HostRange:     hostRange,
+			Original:      spec,
+		})
+	}
+	return bindings
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Remediation returns a suggested next step for an issue, or "" when there
+// is no obvious one
+func Remediation(issue Issue) string {
+	switch issue.Type {
+	case "collision":
+		if len(issue.Bindings) < 2 {
+			return ""
+		}
+		keep := issue.Bindings[0]
+		var move []string
+		for _, b := range issue.Bindings[1:] {
+			move = append(move, fmt.Sprintf("%s in %s", b.Service, filepath.Base(b.File)))
+		}
+		return fmt.Sprintf("Keep port %d for %s and change the host port of %s",
+			issue.Port, keep.Service, strings.Join(move, ", "))
+
+	case "shadowed":
+		return fmt.Sprintf("Bind every service on port %d to a specific host IP, or give the wildcard bind its own host port", issue.Port)
+
+	case "potential_collision":
+		return fmt.Sprintf("Confirm each binding of port %d uses a distinct host interface", issue.Port)
+
+	case "privileged":
+		if len(issue.Bindings) == 0 {
+			return ""
+		}
+		b := issue.Bindings[0]
+		return fmt.Sprintf("Publish on a host port above 1023 (e.g. \"%d:%d\") or run Docker with root privileges",
+			b.HostPort+8000, b.ContainerPort)
+
+	case "common_port":
+		return fmt.Sprintf("Stop any local service on port %d or publish on a different host port", issue.Port)
+
+	case "parse":
+		return "Use \"HOST:CONTAINER\", \"IP:HOST:CONTAINER\" or the long syntax with target and published"
+
+	case "container_name_port_collision":
+		return fmt.Sprintf("Give each service its own container_name, and keep host port %d for only one of them", issue.Port)
+
+	case "duplicate_binding":
+		return "Remove the repeated ports entry; compose publishes each host port once per service"
+
+	case "container_name_collision":
+		return "Give each service a unique container_name, or remove it to let compose name the containers"
+
+	case "invalid_extends":
+		return "Point extends at an existing service, and make sure no chain of extends leads back to itself"
+
+	case "host_mode":
+		return "Use the default ingress mode unless the service needs the client address or one task per node"
+
+	case "unresolved_port":
+		return "Set the variable in the environment or .env, or give it a default such as ${HOST_PORT:-8080}"
+
+	case "invalid_port":
+		return "Quote the port and use a whole number between 1 and 65535, e.g. \"8080:80\""
+
+	case "invalid_host_ip":
+		return "Use an IP address such as 127.0.0.1 or ::1 for host_ip, or leave it out to bind every interface"
+
+	case "random_port":
+		return "Publish a fixed host port if clients need a stable address; otherwise no action is needed"
+
+	case "invalid_protocol":
+		return "Set protocol to tcp or udp, or remove it to default to tcp"
+
+	case "invalid_range":
+		return "Use an ascending range and give both sides the same number of ports, or a single container port"
+
+	case "host_in_use":
+		return fmt.Sprintf("Stop the host service using port %d or publish on a different host port", issue.Port)
+
+	case "host_not_probed":
+		return fmt.Sprintf("Re-run --check-host with the privileges Docker binds port %d with, on the host it will run on", issue.Port)
+
+	case "possible_port_swap":
+		if len(issue.Bindings) == 0 {
+			return ""
+		}
+		b := issue.Bindings[0]
+		return fmt.Sprintf("If the container listens on %d, change the mapping to \"%d:%d\"",
+			b.HostPort, b.ContainerPort, b.HostPort)
+
+	case "exposed_datastore":
+		if len(issue.Bindings) == 0 {
+			return ""
+		}
+		b := issue.Bindings[0]
+		return fmt.Sprintf("Publish on loopback only (\"127.0.0.1:%d:%d\") or drop the published port if only other containers need it",
+			b.HostPort, b.ContainerPort)
+
+	case "externally_claimed":
+		return fmt.Sprintf("Publish on a host port that is not claimed, or release port %d in the other tool", issue.Port)
+
+	case "unresolved_interface":
+		return "Bring the interface up before scanning, or publish on its IP address instead of its name"
+
+	case "redundant_expose":
+		return fmt.Sprintf("Remove %d from the expose list; publishing it already exposes it", issue.Port)
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import "sort"
+
+// ruleTypes describes every issue type portcheck can report, including
+// those the CLI adds for profiles and host probing
+var ruleTypes = map[string]string{
+	"collision":                     "Host port bound more than once on overlapping addresses",
+	"potential_collision":           "Host port bound more than once on the same specific address",
+	"shadowed":                      "Wildcard bind of a host port eclipsing a specific-IP bind of it",
+	"container_name_port_collision": "Services share both a container_name and a host port",
+	"duplicate_binding":             "Service publishing the same host port and protocol twice",
+	"container_name_collision":      "Services share a container_name",
+	"host_mode":                     "Long syntax port with mode: host, bypassing the routing mesh",
+	"privileged":                    "Host port below 1024",
+	"common_port":                   "Host port commonly used by a system service",
+	"exposed_datastore":             "Database or cache published on all interfaces",
+	"externally_claimed":            "Host port claimed by tooling outside Docker",
+	"invalid_range":                 "Malformed port range",
+	"invalid_port":                  "Port that is not a whole number between 1 and 65535",
+	"invalid_host_ip":               "Host IP that is not an IPv4 or IPv6 address",
+	"random_port":                   "Host port 0, assigned randomly by Docker",
+	"invalid_protocol":              "Long syntax protocol other than tcp or udp",
+	"parse":                         "Ports entry that could not be parsed",
+	"parse_error":                   "Compose file that could not be parsed",
+	"invalid_extends":               "Service extends a missing, unreadable or circular definition",
+	"unresolved_port":               "Port depends on an unset variable without a default",
+	"unresolved_interface":          "Interface name used as host address could not be resolved",
+	"possible_port_swap":            "Host and container ports look swapped (--hints)",
+	"redundant_expose":              "Expose entry already covered by ports (--hints)",
+	"profile_collision":             "Collision between services of the active profiles",
+	"all_profiles_collision":        "Collision only with every profile active",
+	"profile_hygiene":               "Inconsistent profile usage",
+	"host_in_use":                   "Host port already bound outside Docker (--check-host)",
+	"host_not_probed":               "Host port --check-host could not probe, e.g. without privileges",
+}
+
+// RuleTypes returns the sorted issue types portcheck can report
+func RuleTypes() []string {
+	types := make([]string, 0, len(ruleTypes))
+	for t := range ruleTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RuleDescription returns a one-line description of an issue type, or ""
+// for unknown types
+func RuleDescription(issueType string) string {
+	return ruleTypes[issueType]
+}
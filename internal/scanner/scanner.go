@@ -2,13 +2,18 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,27 +24,146 @@ type PortBinding struct {
 	ContainerPort int
 	Protocol      string // tcp, udp
 	HostIP        string // binding address
+	Exposure      string // local, private or public, derived from HostIP
 	Service       string
 	File          string
+	Project       string // top-level directory the compose file belongs to
+	Image         string // image of the service, if declared
+	ContainerName string // container_name of the service, if declared
+	HostRange     string // host port range the binding was expanded from, e.g. "8078-8082"
+	Mode          string // long syntax mode, host or ingress; empty when not set
+	Line          int    // line of the ports entry in File, 0 when unknown
+	Column        int    // column of the ports entry in File, 0 when unknown
+	Source        string // SourceKubernetes for manifest bindings, empty for compose files
 	Original      string // original string from compose file
+
+	// RandomHostPort is set for a bare container port such as "80", which
+	// Docker publishes on a random host port. HostPort is then 0 and the
+	// binding takes no part in collision or privileged port analysis.
+	RandomHostPort bool
+
+	// Unresolved is set when the host port depends on unset variables
+	// without a default, such as "${WEB_PORT}:80". HostPort is then 0 and,
+	// like a random host port, the binding takes no part in collision
+	// analysis.
+	Unresolved bool
 }
 
 // Issue represents a detected port problem
 type Issue struct {
+	ID          string // stable identity across runs, see IssueID
 	Severity    string // error, warning
-	Type        string // collision, privileged, shadowed
+	Type        string // collision, shadowed, privileged, parse, see RuleTypes
 	Port        int
 	Description string
+	Remediation string // suggested next step, if any
 	Bindings    []PortBinding
+	Occurrences int // issues merged into this one by CollapseDuplicates, 0 otherwise
 }
 
 // Result contains the scan results
 type Result struct {
 	Path         string
 	ComposeFiles []string
-	PortBindings []PortBinding
+	Manifests    []string              // Kubernetes manifests scanned through Options.Kubernetes
+	PortBindings []PortBinding         // effective bindings used for analysis
+	RawBindings  []PortBinding         `json:"-"` // every declared binding, before merging
 	PortMap      map[int][]PortBinding // grouped by host port
 	Issues       []Issue
+	Suppressed   int          // issues removed by ignore rules
+	ScannedFiles []FileReport // what each scanned file contributed, in scan order
+
+	opts     Options
+	sockets  map[hostSocket][]PortBinding // PortMap split by protocol, used for collisions
+	files    []parsedFile                 // every scanned file, before merging
+	exposed  []exposedPort                // effective expose entries, used by hints
+	names    []namedContainer             // effective container_name declarations
+	declared []Issue                      // issues found while parsing, before analysis
+	explicit bool                         // files were listed by the caller and merge in order
+	ignore   []IgnoreRule                 // Options.Ignore plus the scanned directory's ignore file
+	derived  map[int][]Issue              // analysis issues by the host port they derive from
+}
+
+// FileReport describes what one scanned file contributed, so a scan that
+// finds fewer bindings than expected can be diagnosed
+type FileReport struct {
+	Path       string `json:"path"`
+	Manifest   bool   `json:"manifest,omitempty"`    // a Kubernetes manifest rather than a compose file
+	IncludedBy string `json:"included_by,omitempty"` // the file whose include pulled this one in
+	Parsed     bool   `json:"parsed"`
+	Unreadable bool   `json:"unreadable,omitempty"` // the file could not be read at all
+	Bindings   int    `json:"bindings"`             // declared bindings, before merging
+	Error      string `json:"error,omitempty"`
+}
+
+// Options controls how compose files are discovered and analyzed
+type Options struct {
+	// ProjectsIndependent namespaces bindings by top-level directory so
+	// sibling projects are not assumed to share a host
+	ProjectsIndependent bool
+	// AssumeCoLocated reports cross-project collisions even when
+	// ProjectsIndependent is set
+	AssumeCoLocated bool
+	// Hints enables low-confidence advisory checks
+	Hints bool
+	// Env selects the base compose files plus docker-compose.<Env>.yml,
+	// merged with override semantics, instead of every variant
+	Env string
+	// Sniff also scans other *.yml and *.yaml files in the scanned
+	// directories that have a services key with published ports
+	Sniff bool
+	// Datastores lists image names treated as databases and caches for
+	// exposed_datastore; nil uses DefaultDatastores
+	Datastores []string
+	// KnownPorts names well-known host ports in addition to the built-in
+	// common ports, overriding their names; an empty name removes a
+	// built-in port. See LoadKnownPorts.
+	KnownPorts map[int]string
+	// NoCommonPorts disables the common_port check
+	NoCommonPorts bool
+	// AllowPrivileged reports privileged host ports as info rather than
+	// warnings, for hosts where Docker may bind them
+	AllowPrivileged bool
+	// Paranoid groups bindings by host port number alone, ignoring
+	// projects and IP specificity, so every reuse of a port is reported
+	Paranoid bool
+	// MaxDepth is how many directory levels below the scanned directory
+	// are searched for compose files: 0 for the directory itself, a
+	// negative value for no limit. nil uses DefaultMaxDepth.
+	MaxDepth *int
+	// NoSkipDirs also searches the directories in SkippedDirs
+	NoSkipDirs bool
+	// Ignore suppresses matching issues, in addition to the rules of the
+	// IgnoreFile in the scanned directory
+	Ignore []IgnoreRule
+	// Kubernetes lists Kubernetes manifests, or directories of them, whose
+	// Service nodePorts and pod hostPorts are analyzed with the compose
+	// bindings
+	Kubernetes []string
+	// Cache, when set, reuses the parse of compose files unchanged since
+	// an earlier scan and records new parses in it. Scans save it before
+	// returning; failing to save it does not fail the scan.
+	Cache *Cache
+	// Profiles lists the active compose profiles. When set, services with
+	// a profiles key naming none of them are left out, as compose would;
+	// services without profiles always count. nil scans every service.
+	Profiles []string
+}
+
+// serviceActive reports whether a service with the given profiles runs
+// under the active profiles of opts
+func (opts Options) serviceActive(profiles []string) bool {
+	if len(opts.Profiles) == 0 || len(profiles) == 0 {
+		return true
+	}
+	for _, p := range profiles {
+		for _, active := range opts.Profiles {
+			if p == active {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // HasIssues returns true if there are any issues
@@ -49,85 +173,774 @@ func (r *Result) HasIssues() bool {
 
 // Scan scans compose files for port collisions
 func Scan(basePath string) (*Result, error) {
+	return ScanWithOptions(basePath, Options{})
+}
+
+// ScanWithOptions scans compose files for port collisions using opts
+func ScanWithOptions(basePath string, opts Options) (*Result, error) {
 	r := &Result{
 		Path:    basePath,
 		PortMap: make(map[int][]PortBinding),
+		opts:    opts,
 	}
 
-	// Find compose files
-	patterns := []string{
-		"docker-compose.yml",
-		"docker-compose.yaml",
-		"compose.yml",
-		"compose.yaml",
-		"docker-compose.*.yml",
-		"docker-compose.*.yaml",
+	ignore, err := loadIgnores(basePath, opts)
+	if err != nil {
+		return nil, err
 	}
+	r.ignore = ignore
 
-	for _, pattern := range patterns {
-		matches, _ := filepath.Glob(filepath.Join(basePath, pattern))
-		r.ComposeFiles = append(r.ComposeFiles, matches...)
+	r.ComposeFiles = discoverComposeFiles(basePath, opts)
+
+	// Parse each compose file
+	r.files = opts.parseFiles(r.ComposeFiles, func(file string) string {
+		return projectOf(basePath, file)
+	})
+	r.addManifests(basePath)
+	opts.Cache.Save()
+
+	r.build()
+
+	// Analyze for issues
+	r.analyze()
+
+	return r, nil
+}
+
+// Analyze returns the issues of bindings that did not come from compose
+// files, such as ports read from other manifests. It applies the same
+// analysis as Scan; see AnalyzeWithOptions.
+func Analyze(bindings []PortBinding) []Issue {
+	return AnalyzeWithOptions(bindings, Options{}).Issues
+}
+
+// AnalyzeWithOptions builds a Result holding bindings, as if a scan had
+// declared them, and analyzes it using opts. Discovery options do not
+// apply. Unset exposures are derived from HostIP and every distinct
+// ContainerName counts as declared, so container_name checks still run.
+func AnalyzeWithOptions(bindings []PortBinding, opts Options) *Result {
+	r := &Result{
+		PortMap: make(map[int][]PortBinding),
+		sockets: make(map[hostSocket][]PortBinding),
+		opts:    opts,
+		ignore:  opts.Ignore,
 	}
 
-	// Also check subdirectories
-	entries, _ := os.ReadDir(basePath)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			for _, pattern := range patterns[:4] { // Only standard names in subdirs
-				subPath := filepath.Join(basePath, entry.Name(), pattern)
-				if _, err := os.Stat(subPath); err == nil {
-					r.ComposeFiles = append(r.ComposeFiles, subPath)
-				}
+	seen := make(map[namedContainer]bool)
+	for _, b := range bindings {
+		if b.Exposure == "" {
+			b.Exposure = classifyExposure(b.HostIP)
+		}
+		r.RawBindings = append(r.RawBindings, b)
+		r.addBinding(b)
+
+		named := namedContainer{Name: b.ContainerName, Service: b.Service, File: b.File, Project: b.Project}
+		if b.ContainerName != "" && !seen[named] {
+			seen[named] = true
+			r.names = append(r.names, named)
+		}
+	}
+
+	r.analyze()
+	return r
+}
+
+// parseFile parses one compose file, recording a failure on the result
+// rather than returning it so the scan can continue
+func parseFile(path, project string) parsedFile {
+	loader := newComposeLoader(project)
+	services, err := loader.parseComposeFile(path)
+	return parsedFile{Path: path, Project: project, Services: services, Includes: loader.included, Err: err}
+}
+
+// parseFiles parses compose files concurrently, at most GOMAXPROCS at a
+// time. Each parse is stored at the index of its file, so the files, and
+// the bindings and issues built from them, keep the order of paths however
+// the parses finish.
+func (opts Options) parseFiles(paths []string, project func(path string) string) []parsedFile {
+	files := make([]parsedFile, len(paths))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				files[i] = opts.parseFile(paths[i], project(paths[i]))
 			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return files
+}
+
+// build derives the effective bindings and parse-time issues from the
+// parsed files, replacing any previous ones
+func (r *Result) build() {
+	r.PortBindings = nil
+	r.RawBindings = nil
+	r.PortMap = make(map[int][]PortBinding)
+	r.sockets = make(map[hostSocket][]PortBinding)
+	r.Issues = nil
+	r.exposed = nil
+	r.names = nil
+
+	// Files pulled in through include are scanned as part of the file
+	// including them, so listing them again would double their bindings
+	included := make(map[string]bool)
+	for _, f := range r.files {
+		for _, path := range f.Includes {
+			included[path] = true
 		}
 	}
 
-	// Parse each compose file
-	for _, file := range r.ComposeFiles {
-		if err := r.parseComposeFile(file); err != nil {
+	var parsed, manifests []parsedFile
+	for _, f := range r.files {
+		if included[f.Path] {
+			continue
+		}
+		if f.Err != nil {
 			// Add as warning but continue
 			r.Issues = append(r.Issues, Issue{
 				Severity:    "warning",
 				Type:        "parse_error",
-				Description: fmt.Sprintf("Failed to parse %s: %v", file, err),
+				Description: fmt.Sprintf("Failed to parse %s: %v", f.Path, f.Err),
+				Remediation: fmt.Sprintf("Fix the YAML syntax in %s", f.Path),
 			})
+			continue
+		}
+		if f.Manifest {
+			manifests = append(manifests, f)
+		} else {
+			parsed = append(parsed, f)
+		}
+		for _, svc := range f.Services {
+			r.RawBindings = append(r.RawBindings, svc.Bindings...)
 		}
 	}
 
-	// Analyze for issues
-	r.analyze()
+	// Apply override semantics. Explicitly listed files each override the
+	// ones before them. With an environment, its file is the override;
+	// otherwise the standard compose.override.* names are.
+	if r.explicit {
+		parsed = mergeInOrder(parsed)
+	} else {
+		overrides := overrideComposeNames
+		if r.opts.Env != "" {
+			overrides = envComposeNames(r.opts.Env)
+		}
+		parsed = mergeOverrides(parsed, func(path string) bool {
+			return hasName(path, overrides)
+		})
+	}
+	// Manifests have no override semantics
+	parsed = append(parsed, manifests...)
 
-	return r, nil
+	for _, f := range parsed {
+		for _, svc := range f.Services {
+			if !r.opts.serviceActive(svc.Profiles) {
+				continue
+			}
+			r.Issues = append(r.Issues, svc.Issues...)
+			r.exposed = append(r.exposed, svc.Exposed...)
+			if svc.ContainerName != "" {
+				r.names = append(r.names, namedContainer{
+					Name: svc.ContainerName, Service: svc.Name, File: f.Path, Project: f.Project,
+				})
+			}
+			for _, b := range svc.Bindings {
+				// Overrides often set ports but inherit the image
+				if b.Image == "" {
+					b.Image = svc.Image
+				}
+				if b.ContainerName == "" {
+					b.ContainerName = svc.ContainerName
+				}
+				r.addBinding(b)
+			}
+		}
+	}
+
+	r.declared = append([]Issue{}, r.Issues...)
+	r.ScannedFiles = r.fileReports()
+}
+
+// fileReports reports every scanned file, each followed by the files it
+// includes, with the bindings each one declared
+func (r *Result) fileReports() []FileReport {
+	counts := make(map[string]int)
+	for _, b := range r.RawBindings {
+		counts[b.File]++
+	}
+
+	var reports []FileReport
+	seen := make(map[string]bool)
+	add := func(path, includedBy string, err error, manifest bool) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		report := FileReport{
+			Path:       path,
+			Manifest:   manifest,
+			IncludedBy: includedBy,
+			Parsed:     err == nil,
+			Bindings:   counts[path],
+		}
+		if err != nil {
+			var pathErr *fs.PathError
+			report.Unreadable = errors.As(err, &pathErr)
+			report.Error = err.Error()
+		}
+		reports = append(reports, report)
+	}
+	for _, f := range r.files {
+		add(f.Path, "", f.Err, f.Manifest)
+		if f.Err != nil {
+			// A failed include fails the file including it
+			continue
+		}
+		for _, path := range f.Includes {
+			add(path, f.Path, nil, false)
+		}
+	}
+	return reports
+}
+
+// hostSocket identifies what a binding claims on the host: a port for one
+// protocol. tcp and udp on the same port never conflict.
+type hostSocket struct {
+	Port     int
+	Protocol string
+}
+
+// addBinding records a binding and indexes it by host port and protocol
+func (r *Result) addBinding(b PortBinding) {
+	r.PortBindings = append(r.PortBindings, b)
+	if b.RandomHostPort || b.Unresolved {
+		// No fixed host port to collide on
+		return
+	}
+	r.PortMap[b.HostPort] = append(r.PortMap[b.HostPort], b)
+	socket := hostSocket{Port: b.HostPort, Protocol: normalizeProtocol(b.Protocol)}
+	r.sockets[socket] = append(r.sockets[socket], b)
+}
+
+// protocolsOf returns the protocols bound on a host port, sorted
+func (r *Result) protocolsOf(port int) []string {
+	var protocols []string
+	seen := make(map[string]bool)
+	for _, b := range r.PortMap[port] {
+		protocol := normalizeProtocol(b.Protocol)
+		if !seen[protocol] {
+			seen[protocol] = true
+			protocols = append(protocols, protocol)
+		}
+	}
+	sort.Strings(protocols)
+	return protocols
+}
+
+// baseComposeNames are the file names docker compose loads by default
+var baseComposeNames = []string{
+	"docker-compose.yml",
+	"docker-compose.yaml",
+	"compose.yml",
+	"compose.yaml",
+}
+
+// overrideComposeNames are the override files docker compose merges onto
+// the base file by default
+var overrideComposeNames = []string{
+	"docker-compose.override.yml",
+	"docker-compose.override.yaml",
+	"compose.override.yml",
+	"compose.override.yaml",
+}
+
+// DiscoverComposeFiles returns the compose files in basePath and its
+// immediate subdirectories, or basePath itself when it is a file. Deeper
+// searches go through Options.MaxDepth.
+func DiscoverComposeFiles(basePath string) []string {
+	return discoverComposeFiles(basePath, Options{})
+}
+
+// DiscoverComposeFilesWithOptions returns the compose files a scan of
+// basePath with opts would load
+func DiscoverComposeFilesWithOptions(basePath string, opts Options) []string {
+	return discoverComposeFiles(basePath, opts)
+}
+
+func discoverComposeFiles(basePath string, opts Options) []string {
+	var files []string
+
+	// A compose file given directly is scanned on its own
+	if info, err := os.Stat(basePath); err == nil && !info.IsDir() {
+		return []string{basePath}
+	}
+
+	// Find compose files. With an environment, only that environment's
+	// variant is loaded next to the base files, like docker compose -f.
+	// Base files come first so overrides can be merged onto them.
+	patterns := append([]string{}, baseComposeNames...)
+	subPatterns := append(append([]string{}, baseComposeNames...), overrideComposeNames...) // Only standard names in subdirs
+	if opts.Env != "" {
+		patterns = append(patterns, envComposeNames(opts.Env)...)
+		subPatterns = patterns
+	} else {
+		// docker-compose.*.yml already covers docker-compose.override.yml
+		patterns = append(patterns, "compose.override.yml", "compose.override.yaml",
+			"docker-compose.*.yml", "docker-compose.*.yaml")
+	}
+
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(filepath.Join(basePath, pattern))
+		files = append(files, matches...)
+	}
+
+	// Also check subdirectories
+	subdirs := subdirectories(basePath, 1, opts)
+	for _, dir := range subdirs {
+		for _, pattern := range subPatterns {
+			subPath := filepath.Join(dir, pattern)
+			if _, err := os.Stat(subPath); err == nil {
+				files = append(files, subPath)
+			}
+		}
+	}
+
+	// Non-standard names, identified by content, come after the rest
+	if opts.Sniff {
+		known := make(map[string]bool, len(files))
+		for _, f := range files {
+			known[f] = true
+		}
+		files = append(files, sniffComposeFiles(basePath, known)...)
+		for _, dir := range subdirs {
+			files = append(files, sniffComposeFiles(dir, known)...)
+		}
+	}
+
+	return files
+}
+
+// DefaultMaxDepth is the subdirectory depth searched when Options.MaxDepth
+// is not set: the scanned directory and its immediate subdirectories
+const DefaultMaxDepth = 1
+
+// SkippedDirs are never searched for compose files unless
+// Options.NoSkipDirs is set
+var SkippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// subdirectories returns the directories below dir, which is level-1
+// levels below the scanned directory, down to the depth allowed by opts.
+// Each directory is followed by its own subdirectories, in name order.
+func subdirectories(dir string, level int, opts Options) []string {
+	maxDepth := DefaultMaxDepth
+	if opts.MaxDepth != nil {
+		maxDepth = *opts.MaxDepth
+	}
+	if maxDepth >= 0 && level > maxDepth {
+		return nil
+	}
+
+	var dirs []string
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if !entry.IsDir() || (!opts.NoSkipDirs && SkippedDirs[entry.Name()]) {
+			continue
+		}
+		sub := filepath.Join(dir, entry.Name())
+		dirs = append(dirs, sub)
+		dirs = append(dirs, subdirectories(sub, level+1, opts)...)
+	}
+	return dirs
+}
+
+// envComposeNames returns the file names of an environment-specific
+// compose file, e.g. docker-compose.prod.yml
+func envComposeNames(env string) []string {
+	return []string{
+		"docker-compose." + env + ".yml",
+		"docker-compose." + env + ".yaml",
+		"compose." + env + ".yml",
+		"compose." + env + ".yaml",
+	}
 }
 
 type composeFile struct {
-	Services map[string]struct {
-		Ports []interface{} `yaml:"ports"`
-	} `yaml:"services"`
+	Include  []interface{}             `yaml:"include"`
+	Services map[string]composeService `yaml:"services"`
+
+	portNodes map[string][]*yaml.Node // ports entries by service, for positions
+}
+
+type composeService struct {
+	Image         string        `yaml:"image"`
+	ContainerName string        `yaml:"container_name"`
+	Extends       interface{}   `yaml:"extends"`
+	Profiles      []string      `yaml:"profiles"`
+	Ports         []interface{} `yaml:"ports"`
+	Expose        []interface{} `yaml:"expose"`
+	EnvFile       interface{}   `yaml:"env_file"`
 }
 
-func (r *Result) parseComposeFile(path string) error {
-	data, err := os.ReadFile(path)
+// parsedFile holds the bindings declared by one compose file
+type parsedFile struct {
+	Path     string
+	Project  string
+	Services []parsedService // including those of included files
+	Includes []string        // files pulled in through include, transitively
+	Err      error           // parse failure; the file contributes no services
+	Manifest bool            // a Kubernetes manifest rather than a compose file
+}
+
+// parsedService holds the bindings declared by one service in one file
+type parsedService struct {
+	Name          string
+	Image         string
+	ContainerName string
+	Profiles      []string
+	HasPorts      bool // a ports key is present, even if empty
+	Bindings      []PortBinding
+	Issues        []Issue // entries that were recognized but invalid
+	Exposed       []exposedPort
+}
+
+// projectOf returns the project a compose file belongs to: its top-level
+// directory below basePath, or the base directory's name for root files
+func projectOf(basePath, file string) string {
+	if info, err := os.Stat(basePath); err == nil && !info.IsDir() {
+		basePath = filepath.Dir(basePath)
+	}
+	rel, err := filepath.Rel(basePath, file)
+	if err == nil {
+		if parts := strings.Split(filepath.ToSlash(rel), "/"); len(parts) > 1 {
+			return parts[0]
+		}
+	}
+	abs, err := filepath.Abs(basePath)
 	if err != nil {
-		return err
+		return filepath.Base(basePath)
+	}
+	return filepath.Base(abs)
+}
+
+// parsePorts parses the ports list of one service declared in file,
+// substituting variables from the environment, the file's .env and the
+// service's envFiles. nodes holds the YAML node of each entry, when known,
+// for binding positions.
+func parsePorts(ports []interface{}, nodes []*yaml.Node, envFiles []string, service, file, project string) ([]PortBinding, []Issue) {
+	var lookup lookupFunc
+	var all []PortBinding
+	var issues []Issue
+	for i, port := range ports {
+		var bindings []PortBinding
+		var issue *Issue
+		spec, isString := port.(string)
+		resolved, missing := spec, []string(nil)
+		if isString && strings.Contains(spec, "$") {
+			if lookup == nil {
+				lookup = envLookup(file, envFiles)
+			}
+			resolved, missing = interpolate(spec, lookup)
+		}
+
+		if len(missing) > 0 {
+			// Kept as a binding, but without a host port to check
+			b := unresolvedBinding(resolved, spec, service, file)
+			bindings, issue = []PortBinding{b}, unresolvedIssue(b, missing)
+		} else if isString {
+			bindings, issue = parseEntry(resolved, service, file)
+		} else {
+			bindings, issue = parseEntry(port, service, file)
+		}
+		if issue == nil && bindings == nil {
+			issue = unparsedIssue(port, service, file, i)
+		}
+		if issue != nil {
+			issues = append(issues, *issue)
+		}
+		for _, binding := range bindings {
+			if resolved != spec {
+				// Keep the declaration as written
+				binding.Original = spec
+			}
+			binding.Project = project
+			binding.Protocol = normalizeProtocol(binding.Protocol)
+			binding.Exposure = classifyExposure(binding.HostIP)
+			if i < len(nodes) {
+				binding.Line, binding.Column = nodes[i].Line, nodes[i].Column
+			}
+			all = append(all, binding)
+		}
+	}
+	issues = append(issues, duplicateBindingIssues(all)...)
+	return all, issues
+}
+
+// duplicateBindingIssues warns about a host port and protocol published
+// more than once on the same host IP by the bindings of one service, such
+// as "8080:80" listed twice or next to "0.0.0.0:8080:80/tcp". Only the
+// first repeat of each is reported.
+func duplicateBindingIssues(bindings []PortBinding) []Issue {
+	type hostAddress struct {
+		hostSocket
+		HostIP string
+	}
+
+	var issues []Issue
+	first := make(map[hostAddress]PortBinding)
+	reported := make(map[hostAddress]bool)
+	for _, b := range bindings {
+		if b.RandomHostPort || b.Unresolved {
+			continue
+		}
+		socket := hostAddress{hostSocket{Port: b.HostPort, Protocol: b.Protocol}, NormalizeHostIP(b.HostIP)}
+		prev, seen := first[socket]
+		if !seen {
+			first[socket] = b
+			continue
+		}
+		if reported[socket] {
+			continue
+		}
+		reported[socket] = true
+		issues = append(issues, Issue{
+			Severity: "warning",
+			Type:     "duplicate_binding",
+			Port:     b.HostPort,
+			Description: fmt.Sprintf("Service %s in %s publishes host port %d/%s more than once: %s and %s",
+				b.Service, filepath.Base(b.File), b.HostPort, b.Protocol, prev, b),
+			Bindings: []PortBinding{prev, b},
+		})
+	}
+	return issues
+}
+
+// ParsePort parses a single compose port entry as decoded from YAML. It
+// returns nil when the entry is not a host port binding; a bare container
+// port is returned with RandomHostPort set.
+func ParsePort(port interface{}, service, file string) *PortBinding {
+	return parsePort(port, service, file)
+}
+
+// ParseEntry parses a compose port entry as decoded from YAML into every
+// binding it publishes, expanding ranges. The issue is non-nil when the
+// entry was recognized but is invalid; see parseEntry.
+func ParseEntry(port interface{}, service, file string) ([]PortBinding, *Issue) {
+	bindings, issue := parseEntry(port, service, file)
+	for i := range bindings {
+		bindings[i].Protocol = normalizeProtocol(bindings[i].Protocol)
+	}
+	return bindings, issue
+}
+
+// parseEntry parses one ports entry into its bindings. The issue is non-nil
+// when the entry was recognized but is invalid; an invalid host IP still
+// comes with its bindings.
+func parseEntry(port interface{}, service, file string) ([]PortBinding, *Issue) {
+	if spec, ok := mappingShorthand(port); ok {
+		port = spec
+	}
+	if spec, ok := port.(string); ok {
+		if r, issue := parseRange(spec, service, file); r != nil || issue != nil {
+			if issue != nil {
+				return nil, issue
+			}
+			bindings := r.bindings(spec, service, file)
+			if len(bindings) > 0 {
+				return bindings, hostIPIssue(bindings[0])
+			}
+			return bindings, nil
+		}
+		if bindings, issue, ok := parseInterfacePort(spec, service, file); ok {
+			return bindings, issue
+		}
+	}
+
+	if v, ok := port.(map[string]interface{}); ok {
+		if issue := protocolIssue(v, service, file); issue != nil {
+			return nil, issue
+		}
+	}
+	if v, ok := port.(float64); ok && !validFloatPort(v) {
+		return nil, &Issue{
+			Severity:    "error",
+			Type:        "invalid_port",
+			Port:        int(v),
+			Description: fmt.Sprintf("Port %v in %s is not a whole number between 1 and 65535", v, service),
+			Bindings:    []PortBinding{{Service: service, File: file, Protocol: "tcp", Original: fmt.Sprint(v)}},
+		}
 	}
 
-	var compose composeFile
-	if err := yaml.Unmarshal(data, &compose); err != nil {
-		return err
+	binding := parseBinding(port, service, file)
+	if binding == nil {
+		return nil, nil
+	}
+	if issue := portBoundsIssue(binding.HostPort, binding.ContainerPort, binding.Original, service, file); issue != nil {
+		return nil, issue
+	}
+	if binding.HostPort == 0 && !binding.RandomHostPort {
+		return nil, randomPortIssue(*binding)
 	}
+	return []PortBinding{*binding}, hostIPIssue(*binding)
+}
 
-	for serviceName, svc := range compose.Services {
-		for _, port := range svc.Ports {
-			binding := parsePort(port, serviceName, path)
-			if binding != nil {
-				r.PortBindings = append(r.PortBindings, *binding)
-				r.PortMap[binding.HostPort] = append(r.PortMap[binding.HostPort], *binding)
+// hostIPIssue reports a host IP that is not an IPv4 or IPv6 literal, such
+// as 127.0.0.256 or localhost. The binding is still recorded, but compose
+// would refuse it and collision grouping cannot place it on an interface.
+func hostIPIssue(b PortBinding) *Issue {
+	if b.HostIP == "" || net.ParseIP(strings.Trim(b.HostIP, "[]")) != nil {
+		return nil
+	}
+	return &Issue{
+		Severity:    "error",
+		Type:        "invalid_host_ip",
+		Port:        b.HostPort,
+		Description: fmt.Sprintf("Port %s in %s binds host IP %q, which is not a valid IPv4 or IPv6 address", b.Original, b.Service, b.HostIP),
+		Bindings:    []PortBinding{b},
+	}
+}
+
+// portBoundsIssue reports a host or container port outside 0-65535. Zero is
+// left to the caller: on the host side it asks Docker for a random port.
+func portBoundsIssue(host, container int, spec, service, file string) *Issue {
+	inRange := func(p int) bool { return p >= 0 && p <= 65535 }
+	bad := container
+	switch {
+	case !inRange(host):
+		bad = host
+	case inRange(container):
+		return nil
+	}
+	port := host
+	if !inRange(port) {
+		// Keep the issue off every real port
+		port = 0
+	}
+	return &Issue{
+		Severity:    "error",
+		Type:        "invalid_port",
+		Port:        port,
+		Description: fmt.Sprintf("Port %s in %s uses %d, outside the valid range 1-65535", spec, service, bad),
+		Bindings:    []PortBinding{{Service: service, File: file, Protocol: "tcp", Original: spec}},
+	}
+}
+
+// randomPortIssue notes a binding with host port 0, which Docker replaces
+// with a random free port, so it takes no part in collision analysis
+func randomPortIssue(b PortBinding) *Issue {
+	return &Issue{
+		Severity: "info",
+		Type:     "random_port",
+		Description: fmt.Sprintf("Port %s in %s publishes container port %d on a random host port; it was not checked for collisions",
+			b.Original, b.Service, b.ContainerPort),
+		Bindings: []PortBinding{b},
+	}
+}
+
+// longSyntaxKeys are the keys of a long syntax ports entry
+var longSyntaxKeys = []string{"target", "published", "protocol", "host_ip", "mode", "name", "app_protocol"}
+
+// mappingShorthand returns the short syntax of an entry written as a
+// one-key mapping of published to target port, such as "8080": "80",
+// 8080: 80 or "127.0.0.1:8080": 80, which some generators emit
+func mappingShorthand(port interface{}) (string, bool) {
+	var published, target interface{}
+	switch v := port.(type) {
+	case map[string]interface{}:
+		if len(v) != 1 {
+			return "", false
+		}
+		for key, value := range v {
+			for _, longKey := range longSyntaxKeys {
+				if key == longKey {
+					return "", false
+				}
 			}
+			published, target = key, value
+		}
+	case map[interface{}]interface{}:
+		// yaml.v3 decodes unquoted numeric keys this way
+		if len(v) != 1 {
+			return "", false
 		}
+		for key, value := range v {
+			published, target = key, value
+		}
+	default:
+		return "", false
 	}
 
-	return nil
+	switch published.(type) {
+	case string, int:
+	default:
+		return "", false
+	}
+	switch target.(type) {
+	case string, int:
+		return fmt.Sprintf("%v:%v", published, target), true
+	}
+	return "", false
+}
+
+// validFloatPort reports whether a float decoded from YAML is a whole
+// number in the port range
+func validFloatPort(v float64) bool {
+	return v == float64(int(v)) && v >= 1 && v <= 65535
+}
+
+// longSyntaxInt reads a long syntax port field. yaml.v3 decodes some
+// numbers as float64, and published may be quoted.
+func longSyntaxInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	case string:
+		if i, err := strconv.Atoi(n); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// protocolIssue reports a long syntax protocol that is present but is not
+// tcp or udp, instead of silently treating the binding as tcp
+func protocolIssue(v map[string]interface{}, service, file string) *Issue {
+	raw, present := v["protocol"]
+	if !present {
+		return nil
+	}
+	if protocol, ok := raw.(string); ok && (normalizeProtocol(protocol) == "tcp" || normalizeProtocol(protocol) == "udp") {
+		return nil
+	}
+
+	published, _ := longSyntaxInt(v["published"])
+	return &Issue{
+		Severity:    "error",
+		Type:        "invalid_protocol",
+		Port:        published,
+		Description: fmt.Sprintf("Port %d in %s has invalid protocol %v (want tcp or udp)", published, service, raw),
+		Bindings:    []PortBinding{{HostPort: published, Service: service, File: file, Original: fmt.Sprint(v)}},
+	}
 }
 
 // parsePort parses various port formats:
@@ -135,11 +948,33 @@ func (r *Result) parseComposeFile(path string) error {
 // - "3000:3000"
 // - "8080:80"
 // - "127.0.0.1:8080:80"
+// - "[::1]:8080:80"
 // - "8080:80/tcp"
 // - {target: 80, published: 8080}
-var portRegex = regexp.MustCompile(`^(?:(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}):)?(\d+)(?::(\d+))?(?:/(tcp|udp))?$`)
+// Ranges such as "8000-8005:8000-8005" are expanded by parseRange instead.
+var portRegex = regexp.MustCompile(`^(?:` + hostPrefixPattern + `:)?(\d+)(?::(\d+))?(?:/((?i)tcp|udp))?$`)
+
+// hostPrefixPattern captures the host part of short syntax: a bracketed
+// IPv6 address or anything else that is more than digits and hyphens, so
+// not a port or range, such as an IPv4 address or a name like localhost.
+// hostIPIssue reports the ones that are not IP addresses.
+const hostPrefixPattern = `(\[[0-9A-Fa-f:.%]*\]|[^:/\[\]]*[^\d:/\[\]-][^:/\[\]]*)`
 
 func parsePort(port interface{}, service, file string) *PortBinding {
+	binding := parseBinding(port, service, file)
+	if binding == nil || binding.HostPort == 0 && !binding.RandomHostPort {
+		return nil
+	}
+	return binding
+}
+
+// parseBinding parses a single port entry without validating its numbers,
+// so the host port may be 0 or out of range. Long syntax entries it cannot
+// read, as described by longSyntaxProblem, return nil.
+func parseBinding(port interface{}, service, file string) *PortBinding {
+	if spec, ok := mappingShorthand(port); ok {
+		port = spec
+	}
 	binding := &PortBinding{
 		Service:  service,
 		File:     file,
@@ -161,12 +996,17 @@ func parsePort(port interface{}, service, file string) *PortBinding {
 
 		hostPort, _ := strconv.Atoi(portStr)
 
-		if containerStr != "" {
+		switch {
+		case containerStr != "":
 			containerPort, _ := strconv.Atoi(containerStr)
 			binding.HostPort = hostPort
 			binding.ContainerPort = containerPort
-		} else {
-			// Single port: same for host and container
+		case binding.HostIP == "":
+			// A bare container port is published on a random host port
+			binding.ContainerPort = hostPort
+			binding.RandomHostPort = true
+		default:
+			// Address and single port: same for host and container
 			binding.HostPort = hostPort
 			binding.ContainerPort = hostPort
 		}
@@ -177,18 +1017,28 @@ func parsePort(port interface{}, service, file string) *PortBinding {
 
 	case int:
 		binding.Original = fmt.Sprintf("%d", v)
-		binding.HostPort = v
 		binding.ContainerPort = v
+		binding.RandomHostPort = true
+
+	case float64:
+		// Unquoted values such as 8080.0 decode as floats
+		if !validFloatPort(v) {
+			return nil
+		}
+		binding.Original = fmt.Sprint(v)
+		binding.ContainerPort = int(v)
+		binding.RandomHostPort = true
 
 	case map[string]interface{}:
 		// Long syntax
-		if target, ok := v["target"].(int); ok {
+		if longSyntaxProblem(v) != "" {
+			return nil
+		}
+		if target, ok := longSyntaxInt(v["target"]); ok {
 			binding.ContainerPort = target
 		}
-		if published, ok := v["published"].(int); ok {
+		if published, ok := longSyntaxInt(v["published"]); ok {
 			binding.HostPort = published
-		} else if published, ok := v["published"].(string); ok {
-			binding.HostPort, _ = strconv.Atoi(published)
 		}
 		if protocol, ok := v["protocol"].(string); ok {
 			binding.Protocol = protocol
@@ -196,119 +1046,358 @@ func parsePort(port interface{}, service, file string) *PortBinding {
 		if hostIP, ok := v["host_ip"].(string); ok {
 			binding.HostIP = hostIP
 		}
+		if mode, ok := v["mode"].(string); ok {
+			binding.Mode = strings.ToLower(mode)
+		}
 		binding.Original = fmt.Sprintf("%d:%d", binding.HostPort, binding.ContainerPort)
 
 	default:
 		return nil
 	}
 
-	if binding.HostPort == 0 {
-		return nil
-	}
-
 	return binding
 }
 
+// commonPorts maps well-known host ports to the service usually found there
+var commonPorts = map[int]string{
+	22:    "SSH",
+	25:    "SMTP",
+	53:    "DNS",
+	80:    "HTTP",
+	443:   "HTTPS",
+	3306:  "MySQL",
+	5432:  "PostgreSQL",
+	6379:  "Redis",
+	8080:  "HTTP Alternate",
+	27017: "MongoDB",
+}
+
+// CommonPorts returns a copy of the well-known host ports checked by the
+// common_port advisory, with the service usually found on each
+func CommonPorts() map[int]string {
+	ports := make(map[int]string, len(commonPorts))
+	for port, name := range commonPorts {
+		ports[port] = name
+	}
+	return ports
+}
+
+// analyze computes every analysis issue from scratch
 func (r *Result) analyze() {
+	r.derived = make(map[int][]Issue)
+	for port := range r.PortMap {
+		r.derived[port] = r.analyzePort(port)
+	}
+	r.collectIssues()
+}
+
+// reanalyze recomputes the analysis issues of the given host ports only,
+// keeping the issues of every other port
+func (r *Result) reanalyze(ports map[int]bool) {
+	for port := range ports {
+		if len(r.PortMap[port]) == 0 {
+			delete(r.derived, port)
+			continue
+		}
+		r.derived[port] = r.analyzePort(port)
+	}
+	r.collectIssues()
+}
+
+// analyzePort returns the issues derived from the bindings of one host port
+func (r *Result) analyzePort(port int) []Issue {
+	var issues []Issue
+	all := r.PortMap[port]
+
 	// Check for collisions (same port bound multiple times)
-	for port, bindings := range r.PortMap {
-		if len(bindings) > 1 {
-			// Group by binding specificity
-			directCollisions := []PortBinding{}
-			potentialCollisions := []PortBinding{}
-
-			for _, b := range bindings {
-				if b.HostIP == "" || b.HostIP == "0.0.0.0" {
-					directCollisions = append(directCollisions, b)
-				} else {
-					potentialCollisions = append(potentialCollisions, b)
-				}
+	for _, bindings := range r.collisionGroups(port) {
+		if len(bindings) < 2 {
+			continue
+		}
+
+		// Group by binding specificity
+		directCollisions := []PortBinding{}
+		potentialCollisions := []PortBinding{}
+
+		for _, b := range bindings {
+			if isWildcardIP(b.HostIP) {
+				directCollisions = append(directCollisions, b)
+			} else {
+				potentialCollisions = append(potentialCollisions, b)
 			}
+		}
 
-			// Direct collision (any wildcard + any other binding)
-			if len(directCollisions) > 1 ||
-				(len(directCollisions) > 0 && len(potentialCollisions) > 0) {
-				r.Issues = append(r.Issues, Issue{
-					Severity:    "error",
-					Type:        "collision",
-					Port:        port,
-					Description: fmt.Sprintf("Port %d bound by multiple services", port),
-					Bindings:    bindings,
-				})
-			} else if len(potentialCollisions) > 1 {
-				// Multiple specific bindings - might be intentional
-				r.Issues = append(r.Issues, Issue{
+		// Direct collision (any wildcard + any other binding)
+		if len(directCollisions) > 1 ||
+			(len(directCollisions) > 0 && len(potentialCollisions) > 0) {
+			description := collisionDescription(port, directCollisions, potentialCollisions)
+			if forms := bindForms(port, bindings); forms != "" {
+				description += ": " + forms
+			}
+			if projects := projectsOf(bindings); r.opts.ProjectsIndependent && len(projects) > 1 {
+				description += " across projects " + strings.Join(projects, ", ")
+			}
+			if issue := containerNamePortIssue(port, bindings); issue != nil {
+				// Fixing only the port would still leave the name clash
+				issues = append(issues, *issue)
+				continue
+			}
+			if len(directCollisions) == 1 {
+				// One wildcard bind eclipses the specific-IP binds
+				issues = append(issues, Issue{
 					Severity:    "warning",
-					Type:        "potential_collision",
+					Type:        "shadowed",
 					Port:        port,
-					Description: fmt.Sprintf("Port %d bound multiple times with specific IPs", port),
+					Description: description,
 					Bindings:    bindings,
 				})
+				continue
 			}
+			issues = append(issues, Issue{
+				Severity:    "error",
+				Type:        "collision",
+				Port:        port,
+				Description: description,
+				Bindings:    bindings,
+			})
+		} else if hostMode := hostModeBindings(bindings); len(hostMode) > 1 {
+			// mode: host claims the port on every node whatever the address
+			issues = append(issues, Issue{
+				Severity: "error",
+				Type:     "collision",
+				Port:     port,
+				Description: fmt.Sprintf("Port %d conflict between %s: mode: host binds it on every node regardless of host IP",
+					port, serviceList(hostMode)),
+				Bindings: bindings,
+			})
+		} else if len(potentialCollisions) > 1 && (r.opts.Paranoid || sharesHostIP(potentialCollisions)) {
+			// Multiple specific bindings on one address - might be intentional
+			issues = append(issues, Issue{
+				Severity: "warning",
+				Type:     "potential_collision",
+				Port:     port,
+				Description: fmt.Sprintf("Port %d bound multiple times with specific IPs: %s",
+					port, addressList(potentialCollisions)),
+				Bindings: bindings,
+			})
 		}
 	}
+	collided := false
+	for _, issue := range issues {
+		collided = collided || issue.Type == "collision" || issue.Type == "container_name_port_collision" || issue.Type == "shadowed"
+	}
 
 	// Check for privileged ports
-	for _, binding := range r.PortBindings {
-		if binding.HostPort > 0 && binding.HostPort < 1024 {
-			r.Issues = append(r.Issues, Issue{
-				Severity:    "warning",
-				Type:        "privileged",
-				Port:        binding.HostPort,
-				Description: fmt.Sprintf("Port %d is privileged (requires root/sudo)", binding.HostPort),
-				Bindings:    []PortBinding{binding},
-			})
+	for _, binding := range all {
+		if binding.RandomHostPort || binding.HostPort <= 0 || binding.HostPort >= 1024 {
+			continue
 		}
+		severity := "warning"
+		if r.opts.AllowPrivileged {
+			severity = "info"
+		}
+		issues = append(issues, Issue{
+			Severity:    severity,
+			Type:        "privileged",
+			Port:        binding.HostPort,
+			Description: privilegedDescription(binding),
+			Bindings:    []PortBinding{binding},
+		})
 	}
 
-	// Check for common system port conflicts
-	commonPorts := map[int]string{
-		22:   "SSH",
-		25:   "SMTP",
-		53:   "DNS",
-		80:   "HTTP",
-		443:  "HTTPS",
-		3306: "MySQL",
-		5432: "PostgreSQL",
-		6379: "Redis",
-		8080: "HTTP Alternate",
-		27017: "MongoDB",
-	}
-
-	for _, binding := range r.PortBindings {
-		if svc, ok := commonPorts[binding.HostPort]; ok {
-			// Only warn if binding to all interfaces
-			if binding.HostIP == "" || binding.HostIP == "0.0.0.0" {
-				alreadyWarned := false
-				for _, issue := range r.Issues {
-					if issue.Port == binding.HostPort && issue.Type == "collision" {
-						alreadyWarned = true
-						break
-					}
-				}
-				if !alreadyWarned {
-					r.Issues = append(r.Issues, Issue{
-						Severity:    "info",
-						Type:        "common_port",
-						Port:        binding.HostPort,
-						Description: fmt.Sprintf("Port %d is commonly used by %s", binding.HostPort, svc),
-						Bindings:    []PortBinding{binding},
-					})
-				}
+	// Check for common system port conflicts, only when binding to all
+	// interfaces and not already reported as a collision
+	if svc, ok := r.commonPortName(port); ok && !collided && !r.opts.NoCommonPorts {
+		for _, binding := range all {
+			if isWildcardIP(binding.HostIP) {
+				issues = append(issues, Issue{
+					Severity:    "info",
+					Type:        "common_port",
+					Port:        binding.HostPort,
+					Description: fmt.Sprintf("Port %d is commonly used by %s", binding.HostPort, svc),
+					Bindings:    []PortBinding{binding},
+				})
 			}
 		}
 	}
 
+	for _, binding := range all {
+		if issue := r.datastoreIssue(binding); issue != nil {
+			issues = append(issues, *issue)
+		}
+		if issue := hostModeIssue(binding); issue != nil {
+			issues = append(issues, *issue)
+		}
+	}
+
+	if r.opts.Hints {
+		issues = append(issues, r.hintIssues(all)...)
+	}
+
+	for i := range issues {
+		if issues[i].Remediation == "" {
+			issues[i].Remediation = Remediation(issues[i])
+		}
+	}
+	return issues
+}
+
+// collectIssues rebuilds Issues from the parse-time issues and the derived
+// issues of every port, in a deterministic order
+func (r *Result) collectIssues() {
+	ports := make([]int, 0, len(r.derived))
+	for port := range r.derived {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	r.Issues = append([]Issue{}, r.declared...)
+	for _, port := range ports {
+		r.Issues = append(r.Issues, r.derived[port]...)
+	}
+	r.Issues = append(r.Issues, r.containerNameIssues()...)
+	AssignIDs(r.Issues)
+	r.Suppressed = 0
+	r.ApplyIgnores()
+
 	// Sort issues by severity then port
-	severityOrder := map[string]int{"error": 0, "warning": 1, "info": 2}
-	sort.Slice(r.Issues, func(i, j int) bool {
-		if severityOrder[r.Issues[i].Severity] != severityOrder[r.Issues[j].Severity] {
-			return severityOrder[r.Issues[i].Severity] < severityOrder[r.Issues[j].Severity]
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		if severityRank(r.Issues[i].Severity) != severityRank(r.Issues[j].Severity) {
+			return severityRank(r.Issues[i].Severity) < severityRank(r.Issues[j].Severity)
 		}
 		return r.Issues[i].Port < r.Issues[j].Port
 	})
 }
 
+// collisionGroups splits the bindings of one host port into the groups that
+// can collide with each other, using the protocol-aware index so TCP and
+// UDP never collide. Unless projects are independent, every binding of a
+// protocol shares the host. In paranoid mode the port number alone decides.
+func (r *Result) collisionGroups(port int) [][]PortBinding {
+	if r.opts.Paranoid {
+		return [][]PortBinding{r.PortMap[port]}
+	}
+
+	var groups [][]PortBinding
+	for _, protocol := range r.protocolsOf(port) {
+		// A service repeating its own binding is a duplicate_binding, not
+		// a collision with itself
+		group := distinctBindings(r.sockets[hostSocket{Port: port, Protocol: protocol}])
+		if !r.opts.ProjectsIndependent || r.opts.AssumeCoLocated {
+			groups = append(groups, group)
+			continue
+		}
+
+		byProject := make(map[string][]PortBinding)
+		for _, b := range group {
+			byProject[b.Project] = append(byProject[b.Project], b)
+		}
+		for _, project := range projectsOf(group) {
+			groups = append(groups, byProject[project])
+		}
+	}
+	return groups
+}
+
+// distinctBindings drops bindings repeating an earlier one of the same
+// service, file and host IP, an omitted IP being the same as 0.0.0.0
+func distinctBindings(bindings []PortBinding) []PortBinding {
+	type owner struct {
+		Service, File, Project, HostIP string
+	}
+	seen := make(map[owner]bool)
+	var distinct []PortBinding
+	for _, b := range bindings {
+		key := owner{b.Service, b.File, b.Project, NormalizeHostIP(b.HostIP)}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, b)
+	}
+	return distinct
+}
+
+// privilegedDescription describes a privileged host port together with the
+// interface it is bound on. Binding every interface always needs
+// privileges; a specific address, even loopback, still does on most hosts.
+func privilegedDescription(b PortBinding) string {
+	if isWildcardIP(b.HostIP) {
+		return fmt.Sprintf("Port %d is privileged on all interfaces (requires root/sudo)", b.HostPort)
+	}
+	return fmt.Sprintf("Port %d is privileged on %s (requires root/sudo on most systems, even for a specific address)",
+		b.HostPort, b.HostIP)
+}
+
+// normalizeProtocol returns the canonical form of a binding protocol:
+// lower case, with an omitted protocol meaning tcp as in compose
+func normalizeProtocol(protocol string) string {
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	if protocol == "" {
+		return "tcp"
+	}
+	return protocol
+}
+
+// NormalizeHostIP returns the canonical form of a binding host IP: empty
+// for every IPv4 interface, whether the address was omitted or 0.0.0.0
+func NormalizeHostIP(ip string) string {
+	if ip == "0.0.0.0" {
+		return ""
+	}
+	return ip
+}
+
+// sharesHostIP reports whether two of the given bindings use the same
+// host IP. Binds on distinct specific addresses do not overlap.
+func sharesHostIP(bindings []PortBinding) bool {
+	seen := make(map[string]bool)
+	for _, b := range bindings {
+		if seen[b.HostIP] {
+			return true
+		}
+		seen[b.HostIP] = true
+	}
+	return false
+}
+
+// projectsOf returns the sorted, distinct projects of the given bindings
+func projectsOf(bindings []PortBinding) []string {
+	seen := make(map[string]bool)
+	var projects []string
+	for _, b := range bindings {
+		if !seen[b.Project] {
+			seen[b.Project] = true
+			projects = append(projects, b.Project)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// SortBindings returns a copy of bindings in a stable order: host port,
+// protocol, host IP, service, then file
+func SortBindings(bindings []PortBinding) []PortBinding {
+	sorted := append([]PortBinding(nil), bindings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.HostPort != b.HostPort {
+			return a.HostPort < b.HostPort
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.HostIP != b.HostIP {
+			return a.HostIP < b.HostIP
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return a.File < b.File
+	})
+	return sorted
+}
+
 // GroupedByFile returns bindings grouped by compose file
 func (r *Result) GroupedByFile() map[string][]PortBinding {
 	grouped := make(map[string][]PortBinding)
@@ -320,6 +1409,18 @@ func (r *Result) GroupedByFile() map[string][]PortBinding {
 
 // String returns a summary string
 func (b PortBinding) String() string {
+	if b.RandomHostPort {
+		str := fmt.Sprintf("random:%d", b.ContainerPort)
+		if b.Protocol != "tcp" {
+			str += "/" + b.Protocol
+		}
+		return str
+	}
+	if b.HostPort == 0 && b.Original != "" {
+		// Invalid entries carry only their declaration
+		return b.Original
+	}
+
 	var parts []string
 	if b.HostIP != "" {
 		parts = append(parts, b.HostIP)
@@ -1,8 +1,13 @@
 package scanner
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -173,7 +178,7 @@ func TestScan_PortFormats(t *testing.T) {
 		wantIP   string
 		wantProt string
 	}{
-		{3000, "", "tcp"},
+		{0, "", "tcp"}, // "3000" publishes on a random host port
 		{8080, "", "tcp"},
 		{9000, "127.0.0.1", "tcp"},
 		{5000, "", "udp"},
@@ -284,13 +289,23 @@ func TestParsePort(t *testing.T) {
 		wantProto   string
 		shouldBeNil bool
 	}{
-		{"3000", 3000, 3000, "", "tcp", false},
+		{"3000", 0, 3000, "", "tcp", false},
 		{"8080:80", 8080, 80, "", "tcp", false},
 		{"127.0.0.1:9000:9000", 9000, 9000, "127.0.0.1", "tcp", false},
 		{"5000:5000/udp", 5000, 5000, "", "udp", false},
-		{3000, 3000, 3000, "", "tcp", false},
+		{3000, 0, 3000, "", "tcp", false},
 		{"invalid", 0, 0, "", "", true},
 		{"", 0, 0, "", "", true},
+		{map[string]interface{}{"target": 80, "published": 8080}, 8080, 80, "", "tcp", false},
+		{map[string]interface{}{"target": 80.0, "published": 8080.0}, 8080, 80, "", "tcp", false},
+		{map[string]interface{}{"target": 80, "published": "8080", "protocol": "udp"}, 8080, 80, "", "udp", false},
+		{map[string]interface{}{"target": 80, "published": 8080.5}, 0, 0, "", "", true},
+		{map[string]interface{}{"target": "80", "published": "8080", "host_ip": "127.0.0.1", "protocol": "udp"}, 8080, 80, "127.0.0.1", "udp", false},
+		{map[string]interface{}{"8080": "80"}, 8080, 80, "", "tcp", false},
+		{map[string]interface{}{"127.0.0.1:7000": 70}, 7000, 70, "127.0.0.1", "tcp", false},
+		{map[interface{}]interface{}{9000: 90}, 9000, 90, "", "tcp", false},
+		{8080.0, 0, 8080, "", "tcp", false},
+		{8080.5, 0, 0, "", "", true},
 	}
 
 	for _, tc := range tests {
@@ -332,6 +347,66 @@ func TestHasIssues(t *testing.T) {
 	}
 }
 
+func TestAnalyze_InMemoryBindings(t *testing.T) {
+	bindings := []PortBinding{
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp", Service: "web", File: "k8s/web.yaml"},
+		{HostPort: 8080, ContainerPort: 3000, Protocol: "tcp", Service: "api", File: "nomad/api.hcl"},
+		{HostPort: 8080, ContainerPort: 53, Protocol: "udp", Service: "dns", File: "nomad/dns.hcl"},
+		{HostPort: 443, ContainerPort: 443, Protocol: "tcp", HostIP: "127.0.0.1", Service: "proxy", File: "k8s/proxy.yaml"},
+	}
+
+	issues := Analyze(bindings)
+
+	got := make(map[string]int)
+	for _, issue := range issues {
+		got[fmt.Sprintf("%s/%d", issue.Type, issue.Port)]++
+		if issue.ID == "" {
+			t.Errorf("Issue should have an ID: %+v", issue)
+		}
+	}
+	want := map[string]int{"collision/8080": 1, "privileged/443": 1}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Issues = %v, want %v", got, want)
+	}
+
+	collision := issues[0]
+	if collision.Type != "collision" || len(collision.Bindings) != 2 {
+		t.Fatalf("Expected the tcp collision first with both tcp bindings, got %+v", collision)
+	}
+
+	r := AnalyzeWithOptions(bindings, Options{Ignore: []IgnoreRule{{Field: "type", Value: "privileged"}}})
+	if len(r.Issues) != 1 || r.Suppressed != 1 {
+		t.Errorf("Expected ignore rules to apply, got %+v (%d suppressed)", r.Issues, r.Suppressed)
+	}
+	if len(r.PortMap[8080]) != 3 || r.PortBindings[3].Exposure != ExposureLocal {
+		t.Errorf("Expected bindings indexed with derived exposure, got %+v", r.PortBindings)
+	}
+}
+
+func TestSummary_ExitCode(t *testing.T) {
+	tests := []struct {
+		summary Summary
+		failOn  string
+		want    int
+	}{
+		{Summary{}, "info", ExitClean},
+		{Summary{Errors: 1, Warnings: 2, Info: 3}, "info", ExitErrors},
+		{Summary{Warnings: 2, Info: 3}, "info", ExitWarnings},
+		{Summary{Info: 3}, "info", ExitInfo},
+		{Summary{Errors: 1}, "error", ExitErrors},
+		{Summary{Warnings: 2, Info: 3}, "error", ExitClean},
+		{Summary{Warnings: 2}, "warning", ExitWarnings},
+		{Summary{Info: 3}, "warning", ExitClean},
+		{Summary{Errors: 1}, "", ExitClean},
+	}
+
+	for _, tt := range tests {
+		if got := tt.summary.ExitCode(tt.failOn); got != tt.want {
+			t.Errorf("%+v.ExitCode(%q) = %d, want %d", tt.summary, tt.failOn, got, tt.want)
+		}
+	}
+}
+
 // Edge case tests
 
 func TestScan_PortRanges(t *testing.T) {
@@ -352,10 +427,102 @@ func TestScan_PortRanges(t *testing.T) {
 		t.Fatalf("Scan failed: %v", err)
 	}
 
-	// Port ranges are complex - just verify no crash
 	if result == nil {
 		t.Fatal("Result should not be nil")
 	}
+
+	// One binding per host port of the range
+	if len(result.PortBindings) != 6 {
+		t.Fatalf("Expected 6 bindings, got %d", len(result.PortBindings))
+	}
+	for i, b := range SortBindings(result.PortBindings) {
+		if b.HostPort != 8000+i || b.ContainerPort != 8000+i || b.HostRange != "8000-8005" {
+			t.Errorf("Binding %d = %+v, want %d->%d from 8000-8005", i, b, 8000+i, 8000+i)
+		}
+	}
+}
+
+func TestScan_BarePortRangeIsRandom(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  blue:
+    image: app
+    ports:
+      - "8000-8002"
+  green:
+    image: app
+    ports:
+      - "8000-8002"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortBindings) != 6 {
+		t.Fatalf("Expected 6 bindings, got %+v", result.PortBindings)
+	}
+	for _, b := range result.PortBindings {
+		if !b.RandomHostPort || b.HostPort != 0 || b.ContainerPort < 8000 || b.ContainerPort > 8002 {
+			t.Errorf("Binding = %+v, want a random host port for container port 8000-8002", b)
+		}
+	}
+	if len(result.PortMap) != 0 {
+		t.Errorf("Random host ports should not be in PortMap, got %v", result.PortMap)
+	}
+	for _, issue := range result.Issues {
+		if issue.Type == "collision" || issue.Type == "range_overlap" {
+			t.Errorf("Bare ranges should not collide, got %+v", issue)
+		}
+	}
+}
+
+func TestScan_PortRangeToSingleContainerPort(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  workers:
+    image: test
+    ports:
+      - "9000-9002:80"
+  admin:
+    image: admin
+    ports:
+      - "9002:8080"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var workers []PortBinding
+	for _, b := range SortBindings(result.PortBindings) {
+		if b.Service == "workers" {
+			workers = append(workers, b)
+		}
+	}
+	if len(workers) != 3 {
+		t.Fatalf("Expected 3 bindings for the range, got %+v", workers)
+	}
+	for i, b := range workers {
+		if b.HostPort != 9000+i || b.ContainerPort != 80 {
+			t.Errorf("Binding %d = %d:%d, want %d:80", i, b.HostPort, b.ContainerPort, 9000+i)
+		}
+	}
+
+	// The overlap with the range is a normal collision
+	if countIssues(result, "collision", 9002) != 1 || countIssues(result, "collision", 9000) != 0 {
+		t.Errorf("Expected a single collision on 9002, got %+v", result.Issues)
+	}
 }
 
 func TestScan_IPv6Binding(t *testing.T) {
@@ -380,6 +547,51 @@ func TestScan_IPv6Binding(t *testing.T) {
 	if result == nil {
 		t.Fatal("Result should not be nil")
 	}
+	if b := result.PortMap[8080]; len(b) != 1 || b[0].HostIP != "[::1]" || b[0].ContainerPort != 80 {
+		t.Errorf("Expected [::1]:8080:80 to be recorded, got %+v", result.PortBindings)
+	}
+}
+
+func TestScan_IPv6WildcardCollides(t *testing.T) {
+	tests := []struct {
+		name  string
+		other string
+	}{
+		{"next to an omitted address", `- "8080:80"`},
+		{"next to another ::", "- target: 80\n        published: 8080\n        host_ip: \"::\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			compose := fmt.Sprintf(`services:
+  a:
+    image: app
+    ports:
+      - target: 80
+        published: 8080
+        host_ip: "::"
+  b:
+    image: app
+    ports:
+      %s
+`, tt.other)
+			if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Scan(dir)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			if countIssues(result, "collision", 8080) != 1 {
+				t.Errorf("Expected a collision between two wildcard binds, got %+v", result.Issues)
+			}
+			if countIssues(result, "shadowed", 8080) != 0 || countIssues(result, "potential_collision", 8080) != 0 {
+				t.Errorf("Expected :: to count as a wildcard, got %+v", result.Issues)
+			}
+		})
+	}
 }
 
 func TestScan_MixedPortSyntax(t *testing.T) {
@@ -458,6 +670,59 @@ func TestScan_ZeroPort(t *testing.T) {
 	}
 }
 
+func TestScan_PortBounds(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  big:
+    image: test
+    ports:
+      - "70000:80"
+      - "8080:70000"
+  edge:
+    image: test
+    ports:
+      - "65535:65535"
+  random:
+    image: test
+    ports:
+      - "0:80"
+  negative:
+    image: test
+    ports:
+      - published: -1
+        target: 80
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortBindings) != 1 || result.PortBindings[0].HostPort != 65535 {
+		t.Errorf("Expected only 65535 to become a binding, got %+v", result.PortBindings)
+	}
+
+	invalid := result.FilterByType("invalid_port")
+	if len(invalid) != 3 {
+		t.Fatalf("Expected invalid_port for 70000 on either side and for -1, got %+v", result.Issues)
+	}
+	for _, issue := range invalid {
+		service := issue.Bindings[0].Service
+		if issue.Severity != "error" || !strings.Contains(issue.Description, service) {
+			t.Errorf("Expected an error naming the service, got %+v", issue)
+		}
+	}
+
+	random := result.FilterByType("random_port")
+	if len(random) != 1 || random[0].Severity != "info" || random[0].Bindings[0].Service != "random" {
+		t.Errorf("Expected an info note for host port 0, got %+v", random)
+	}
+}
+
 func TestScan_MalformedCompose(t *testing.T) {
 	dir := t.TempDir()
 
@@ -495,11 +760,53 @@ func TestScan_UDPAndTCPSamePort(t *testing.T) {
 	// TCP and UDP on same port should NOT be a collision
 	for _, issue := range result.Issues {
 		if issue.Type == "collision" {
-			t.Log("Warning: TCP/UDP same port detected as collision - might be intentional")
+			t.Errorf("TCP and UDP on the same port should not collide, got %s", issue.Description)
 		}
 	}
 }
 
+func TestScan_SameProtocolSamePortCollides(t *testing.T) {
+	tests := []struct {
+		name       string
+		ports      [2]string
+		collisions int
+	}{
+		{"tcp and udp", [2]string{"53:53/tcp", "53:53/udp"}, 0},
+		{"tcp and tcp", [2]string{"53:53/tcp", "53:5353/tcp"}, 1},
+		{"udp and udp", [2]string{"53:53/udp", "53:5353/udp"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			compose := fmt.Sprintf(`services:
+  dns:
+    image: test
+    ports:
+      - "%s"
+  resolver:
+    image: test
+    ports:
+      - "%s"
+`, tt.ports[0], tt.ports[1])
+			if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Scan(dir)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			if got := len(result.FilterByType("collision")); got != tt.collisions {
+				t.Errorf("Expected %d collisions, got %d: %+v", tt.collisions, got, result.Issues)
+			}
+			if len(result.PortMap[53]) != 2 {
+				t.Errorf("PortMap should still hold both bindings of port 53, got %d", len(result.PortMap[53]))
+			}
+		})
+	}
+}
+
 func TestScan_EnvironmentVariableInPort(t *testing.T) {
 	dir := t.TempDir()
 
@@ -524,6 +831,201 @@ func TestScan_EnvironmentVariableInPort(t *testing.T) {
 	}
 }
 
+func TestScan_InterpolatedPortDefaults(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("API_PORT", "")
+	t.Setenv("ADMIN_ENABLED", "1")
+	// t.Setenv restores these; unset them so .env and defaults apply
+	for _, name := range []string{"WEB_PORT", "METRICS_PORT"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	compose := `services:
+  api:
+    image: test
+    ports:
+      - "${API_PORT:-8080}:80"
+  web:
+    image: test
+    ports:
+      - "${WEB_PORT}:80"
+  admin:
+    image: test
+    ports:
+      - "${ADMIN_ENABLED:+127.0.0.1:}9000:9000"
+  metrics:
+    image: test
+    ports:
+      - "${METRICS_PORT}:9090"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("# local ports\nWEB_PORT=\"8080\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortMap[8080]) != 2 {
+		t.Errorf("Expected the default and the .env value to resolve to 8080, got %+v", result.PortMap[8080])
+	}
+	if b := result.PortMap[9000]; len(b) != 1 || b[0].HostIP != "127.0.0.1" {
+		t.Errorf("Expected :+ to apply the alternate host IP, got %+v", b)
+	}
+	if b := result.PortMap[8080]; len(b) > 0 && b[0].Original != "${API_PORT:-8080}:80" {
+		t.Errorf("Expected bindings to keep the spec as written, got %q", b[0].Original)
+	}
+	if len(result.FilterByType("collision")) != 1 {
+		t.Errorf("Expected a collision on the resolved port, got %+v", result.Issues)
+	}
+
+	unresolved := result.FilterByType("unresolved_port")
+	if len(unresolved) != 1 || unresolved[0].Severity != "info" ||
+		!strings.Contains(unresolved[0].Description, "METRICS_PORT") {
+		t.Errorf("Expected an info issue naming the unset variable, got %+v", unresolved)
+	}
+}
+
+func TestScan_UnresolvedPortIsKeptButNotAnalyzed(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"BLUE_PORT", "GREEN_PORT"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	compose := `services:
+  blue:
+    image: app
+    ports:
+      - "127.0.0.1:${BLUE_PORT}:80/udp"
+  green:
+    image: app
+    ports:
+      - "${GREEN_PORT}:80"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortBindings) != 2 {
+		t.Fatalf("Expected both unresolved entries as bindings, got %+v", result.PortBindings)
+	}
+	for _, b := range SortBindings(result.PortBindings) {
+		if !b.Unresolved || b.HostPort != 0 || b.ContainerPort != 80 {
+			t.Errorf("Binding = %+v, want an unresolved binding to container port 80", b)
+		}
+		if b.Service == "blue" && (b.HostIP != "127.0.0.1" || b.Protocol != "udp") {
+			t.Errorf("Expected the host IP and protocol of %q, got %+v", b.Original, b)
+		}
+	}
+	if len(result.PortMap) != 0 {
+		t.Errorf("Unresolved bindings should not be in PortMap, got %v", result.PortMap)
+	}
+	if len(result.FilterByType("collision")) != 0 || len(result.FilterByType("duplicate_binding")) != 0 {
+		t.Errorf("Unresolved bindings should not collide, got %+v", result.Issues)
+	}
+	if len(result.FilterByType("unresolved_port")) != 2 {
+		t.Errorf("Expected an unresolved_port issue per entry, got %+v", result.Issues)
+	}
+}
+
+func TestScan_EnvFileVariablesInPort(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"DB_PORT", "CACHE_PORT", "QUEUE_PORT"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	compose := `services:
+  db:
+    image: postgres
+    env_file: db.env
+    ports:
+      - "${DB_PORT}:5432"
+  cache:
+    image: redis
+    env_file:
+      - db.env
+      - cache.env
+    ports:
+      - "${CACHE_PORT}:6379"
+  queue:
+    image: rabbitmq
+    ports:
+      - "${QUEUE_PORT}:5672"
+`
+	files := map[string]string{
+		"docker-compose.yml": compose,
+		".env":               "CACHE_PORT=7000\n",
+		"db.env":             "DB_PORT=15432\nCACHE_PORT=7001\nQUEUE_PORT=5672\n",
+		"cache.env":          "CACHE_PORT=7002\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if b := result.PortMap[15432]; len(b) != 1 || b[0].Service != "db" {
+		t.Errorf("Expected db.env to supply DB_PORT, got %+v", result.PortBindings)
+	}
+	if len(result.PortMap[7000]) != 1 {
+		t.Errorf("Expected .env to take precedence over env_file, got %+v", result.PortBindings)
+	}
+
+	// queue has no env_file, so db.env must not leak into it
+	unresolved := result.FilterByType("unresolved_port")
+	if len(unresolved) != 1 || !strings.Contains(unresolved[0].Description, "QUEUE_PORT") {
+		t.Errorf("Expected only QUEUE_PORT to stay unresolved, got %+v", unresolved)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{"SET": "1", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		spec    string
+		want    string
+		missing int
+	}{
+		{"${SET}:80", "1:80", 0},
+		{"$SET:80", "1:80", 0},
+		{"${UNSET:-8080}:80", "8080:80", 0},
+		{"${EMPTY:-8080}:80", "8080:80", 0},
+		{"${EMPTY-8080}:80", ":80", 0},
+		{"${SET:+9090}:80", "9090:80", 0},
+		{"${UNSET:+9090}80", "80", 0},
+		{"${UNSET}:80", ":80", 1},
+		{"${UNSET?required}:80", ":80", 1},
+		{"$$80", "$80", 0},
+	}
+	for _, tt := range tests {
+		got, missing := interpolate(tt.spec, lookup)
+		if got != tt.want || len(missing) != tt.missing {
+			t.Errorf("interpolate(%q) = %q, %v; want %q with %d missing", tt.spec, got, missing, tt.want, tt.missing)
+		}
+	}
+}
+
 func TestScan_ExposedPorts(t *testing.T) {
 	dir := t.TempDir()
 
@@ -663,25 +1165,69 @@ func TestScan_ContainerPortOnly(t *testing.T) {
 	if result == nil {
 		t.Fatal("Result should not be nil")
 	}
+	if len(result.PortBindings) != 2 || len(result.PortMap) != 0 {
+		t.Fatalf("Expected two bindings without fixed host ports, got %+v", result.PortBindings)
+	}
+	for _, b := range result.PortBindings {
+		if !b.RandomHostPort || b.HostPort != 0 {
+			t.Errorf("Expected %q on a random host port, got %+v", b.Original, b)
+		}
+	}
+	if b := result.PortBindings[0]; b.String() != "random:80" && b.String() != "random:443" {
+		t.Errorf("Unexpected String() %q", b.String())
+	}
 }
 
-func TestScan_DuplicateComposeFiles(t *testing.T) {
+func TestScan_RandomHostPortsDoNotCollide(t *testing.T) {
 	dir := t.TempDir()
 
-	compose1 := `services:
+	compose := `services:
   web:
-    image: test
+    image: nginx
     ports:
-      - "8080:80"
-`
-	compose2 := `services:
-  web:
-    image: test
+      - "80"
+  admin:
+    image: nginx
     ports:
-      - "8080:80"
+      - "80"
+      - 443
 `
-	// Create both common compose file names
-	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose1), 0644); err != nil {
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortBindings) != 3 {
+		t.Fatalf("Expected 3 bindings, got %+v", result.PortBindings)
+	}
+	for _, issueType := range []string{"collision", "privileged", "common_port"} {
+		if issues := result.FilterByType(issueType); len(issues) != 0 {
+			t.Errorf("Random host ports should not produce %s issues, got %+v", issueType, issues)
+		}
+	}
+}
+
+func TestScan_DuplicateComposeFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	compose1 := `services:
+  web:
+    image: test
+    ports:
+      - "8080:80"
+`
+	compose2 := `services:
+  web:
+    image: test
+    ports:
+      - "8080:80"
+`
+	// Create both common compose file names
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose1), 0644); err != nil {
 		t.Fatal(err)
 	}
 	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yaml"), []byte(compose2), 0644); err != nil {
@@ -776,3 +1322,2836 @@ func TestScan_PortWithProtocolSuffix(t *testing.T) {
 		t.Errorf("Expected at least 3 port bindings, got %d", len(result.PortBindings))
 	}
 }
+
+func writeProjects(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	compose := `services:
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`
+	for _, project := range []string{"billing", "inventory"} {
+		if err := os.MkdirAll(filepath.Join(dir, project), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, project, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func countIssues(result *Result, issueType string, port int) int {
+	count := 0
+	for _, issue := range result.Issues {
+		if issue.Type == issueType && issue.Port == port {
+			count++
+		}
+	}
+	return count
+}
+
+func TestScan_ProjectsIndependent(t *testing.T) {
+	dir := writeProjects(t)
+
+	result, err := ScanWithOptions(dir, Options{ProjectsIndependent: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if got := countIssues(result, "collision", 5432); got != 0 {
+		t.Errorf("Expected no cross-project collision, got %d", got)
+	}
+
+	for _, b := range result.PortBindings {
+		if b.Project != "billing" && b.Project != "inventory" {
+			t.Errorf("Unexpected project %q for %s", b.Project, b.File)
+		}
+	}
+}
+
+func TestScan_ProjectsIndependentCoLocated(t *testing.T) {
+	dir := writeProjects(t)
+
+	result, err := ScanWithOptions(dir, Options{ProjectsIndependent: true, AssumeCoLocated: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if got := countIssues(result, "collision", 5432); got != 1 {
+		t.Fatalf("Expected 1 cross-project collision, got %d", got)
+	}
+
+	for _, issue := range result.Issues {
+		if issue.Type == "collision" && !strings.Contains(issue.Description, "billing, inventory") {
+			t.Errorf("Description should name both projects, got %q", issue.Description)
+		}
+	}
+}
+
+func TestScan_PossiblePortSwap(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  swapped:
+    image: nginx
+    ports:
+      - "80:8080"
+  normal:
+    image: nginx
+    ports:
+      - "8081:80"
+  proxy:
+    image: node
+    ports:
+      - "443:3000"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ScanWithOptions(dir, Options{Hints: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var hinted []string
+	for _, issue := range result.Issues {
+		if issue.Type == "possible_port_swap" {
+			hinted = append(hinted, issue.Bindings[0].Service)
+		}
+	}
+	if len(hinted) != 1 || hinted[0] != "swapped" {
+		t.Errorf("Expected a swap hint only for 'swapped', got %v", hinted)
+	}
+
+	// Hints are opt-in
+	result, err = Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if got := countIssues(result, "possible_port_swap", 80); got != 0 {
+		t.Errorf("Expected no hints without Options.Hints, got %d", got)
+	}
+}
+
+func TestScan_Remediation(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  api:
+    image: node
+    ports:
+      - "3000:3000"
+  web:
+    image: node
+    ports:
+      - "3000:3000"
+  proxy:
+    image: nginx
+    ports:
+      - "443:443"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	want := map[string]string{
+		"collision":  "Keep port 3000 for api and change the host port of web in docker-compose.yml",
+		"privileged": `Publish on a host port above 1023 (e.g. "8443:443") or run Docker with root privileges`,
+	}
+	for _, issue := range result.Issues {
+		if expected, ok := want[issue.Type]; ok {
+			if issue.Remediation != expected {
+				t.Errorf("%s remediation = %q, want %q", issue.Type, issue.Remediation, expected)
+			}
+			delete(want, issue.Type)
+		}
+	}
+	for issueType := range want {
+		t.Errorf("No %s issue found", issueType)
+	}
+}
+
+func TestScan_EnvSelectsOverride(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"docker-compose.yml": `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`,
+		"docker-compose.prod.yml": `services:
+  web:
+    ports:
+      - "9080:80"
+  db:
+    image: postgres:16
+`,
+		"docker-compose.dev.yml": `services:
+  api:
+    image: node
+    ports:
+      - "9080:3000"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := ScanWithOptions(dir, Options{Env: "prod"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.ComposeFiles) != 2 {
+		t.Fatalf("Expected base and prod files, got %v", result.ComposeFiles)
+	}
+	for _, f := range result.ComposeFiles {
+		if filepath.Base(f) == "docker-compose.dev.yml" {
+			t.Errorf("dev file should not be scanned with --env prod")
+		}
+	}
+
+	ports := make(map[string]int)
+	for _, b := range result.PortBindings {
+		ports[b.Service] = b.HostPort
+	}
+	if len(result.PortBindings) != 2 || ports["web"] != 9080 || ports["db"] != 5432 {
+		t.Errorf("Expected web on 9080 (override) and db on 5432 (base), got %+v", ports)
+	}
+
+	if got := countIssues(result, "collision", 9080); got != 0 {
+		t.Errorf("Expected no cross-env collision on 9080, got %d", got)
+	}
+}
+
+func filterFixture() *Result {
+	return &Result{Issues: []Issue{
+		{Severity: "error", Type: "collision", Port: 8080},
+		{Severity: "warning", Type: "privileged", Port: 80},
+		{Severity: "info", Type: "common_port", Port: 5432},
+		{Severity: "notice", Type: "custom", Port: 9000},
+	}}
+}
+
+func issueTypes(issues []Issue) []string {
+	var types []string
+	for _, issue := range issues {
+		types = append(types, issue.Type)
+	}
+	return types
+}
+
+func TestFilterBySeverity(t *testing.T) {
+	r := filterFixture()
+
+	tests := []struct {
+		min  string
+		want []string
+	}{
+		{"error", []string{"collision"}},
+		{"warning", []string{"collision", "privileged"}},
+		{"info", []string{"collision", "privileged", "common_port", "custom"}},
+		{"bogus", nil},
+		{"", nil},
+	}
+
+	for _, tc := range tests {
+		got := issueTypes(r.FilterBySeverity(tc.min))
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("FilterBySeverity(%q) = %v, want %v", tc.min, got, tc.want)
+		}
+	}
+}
+
+func TestFilterByType(t *testing.T) {
+	r := filterFixture()
+
+	if got := r.FilterByType(); len(got) != len(r.Issues) {
+		t.Errorf("FilterByType() should return all issues, got %d", len(got))
+	}
+	if got := issueTypes(r.FilterByType("privileged", "custom")); strings.Join(got, ",") != "privileged,custom" {
+		t.Errorf("FilterByType(privileged, custom) = %v", got)
+	}
+	if got := r.FilterByType("shadowed"); len(got) != 0 {
+		t.Errorf("FilterByType(shadowed) should be empty, got %v", issueTypes(got))
+	}
+}
+
+func TestIssuesExcluding(t *testing.T) {
+	r := filterFixture()
+
+	if got := r.IssuesExcluding(); len(got) != len(r.Issues) {
+		t.Errorf("IssuesExcluding() should return all issues, got %d", len(got))
+	}
+	if got := issueTypes(r.IssuesExcluding("privileged", "common_port")); strings.Join(got, ",") != "collision,custom" {
+		t.Errorf("IssuesExcluding(privileged, common_port) = %v", got)
+	}
+
+	// Combined: warnings and above, minus privileged
+	combined := &Result{Issues: r.FilterBySeverity("warning")}
+	if got := issueTypes(combined.IssuesExcluding("privileged")); strings.Join(got, ",") != "collision" {
+		t.Errorf("Combined filters = %v, want [collision]", got)
+	}
+}
+
+func TestScan_XTemplatePorts(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `x-service-template: &web-template
+  image: nginx
+  ports:
+    - "8080:80"
+
+services:
+  blue:
+    <<: *web-template
+  green:
+    <<: *web-template
+    environment:
+      COLOR: green
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	// The template itself is not a service
+	services := make(map[string]bool)
+	for _, b := range result.PortBindings {
+		services[b.Service] = true
+	}
+	if len(result.PortBindings) != 2 || !services["blue"] || !services["green"] {
+		t.Fatalf("Expected template port attributed to blue and green, got %+v", result.PortBindings)
+	}
+
+	found := false
+	for _, issue := range result.Issues {
+		if issue.Type == "collision" && issue.Port == 8080 {
+			found = true
+			if len(issue.Bindings) != 2 {
+				t.Errorf("Collision should have 2 bindings, got %d", len(issue.Bindings))
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected collision between services sharing the template port")
+	}
+}
+
+func TestScan_InvalidRanges(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"8000-8002:9000-9003", "maps 3 host ports (8000-8002) to 4 container ports (9000-9003)"},
+		{"8005-8000:8005-8000", "Port range 8005-8000 in svc is reversed"},
+		{"8000-8002:9003-9000", "Container port range 9003-9000 in svc is reversed"},
+	}
+
+	for _, tc := range tests {
+		dir := t.TempDir()
+		compose := "services:\n  svc:\n    image: test\n    ports:\n      - \"" + tc.spec + "\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Scan(dir)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+
+		if len(result.PortBindings) != 0 {
+			t.Errorf("%s: expected no bindings, got %d", tc.spec, len(result.PortBindings))
+		}
+
+		found := false
+		for _, issue := range result.Issues {
+			if issue.Type == "invalid_range" {
+				found = true
+				if issue.Severity != "error" || !strings.Contains(issue.Description, tc.want) {
+					t.Errorf("%s: unexpected issue %s %q", tc.spec, issue.Severity, issue.Description)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected invalid_range issue", tc.spec)
+		}
+	}
+}
+
+func TestScan_OverrideFilenames(t *testing.T) {
+	base := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  worker:
+    image: worker
+    ports:
+      - "9000:9000"
+`
+	override := `services:
+  web:
+    ports:
+      - "8081:80"
+`
+	for _, baseName := range baseComposeNames {
+		for _, overrideName := range overrideComposeNames {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, baseName), []byte(base), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, overrideName), []byte(override), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Scan(dir)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+
+			if len(result.ComposeFiles) != 2 {
+				t.Errorf("%s + %s: expected 2 compose files, got %v", baseName, overrideName, result.ComposeFiles)
+			}
+
+			ports := make(map[string][]int)
+			for _, b := range result.PortBindings {
+				ports[b.Service] = append(ports[b.Service], b.HostPort)
+			}
+			if len(ports["web"]) != 1 || ports["web"][0] != 8081 {
+				t.Errorf("%s + %s: web ports = %v, want [8081]", baseName, overrideName, ports["web"])
+			}
+			if len(ports["worker"]) != 1 || ports["worker"][0] != 9000 {
+				t.Errorf("%s + %s: worker ports = %v, want [9000]", baseName, overrideName, ports["worker"])
+			}
+		}
+	}
+}
+
+func TestScan_OverrideDoesNotCollideWithBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		want     int
+	}{
+		{"changed host port", `services:
+  web:
+    ports:
+      - "8081:80"
+`, 8081},
+		{"same host port redeclared", `services:
+  web:
+    ports:
+      - "8080:80"
+`, 8080},
+	}
+
+	base := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+`
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "compose.yml"), []byte(base), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "compose.override.yml"), []byte(tt.override), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Scan(dir)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+
+			if len(result.PortBindings) != 1 || result.PortBindings[0].HostPort != tt.want {
+				t.Errorf("Expected web only on %d, got %+v", tt.want, result.PortBindings)
+			}
+			if result.PortBindings[0].File != filepath.Join(dir, "compose.override.yml") {
+				t.Errorf("Expected the binding to come from the override, got %s", result.PortBindings[0].File)
+			}
+			if n := len(result.FilterByType("collision")); n != 0 {
+				t.Errorf("Expected no collision between base and override, got %+v", result.Issues)
+			}
+		})
+	}
+}
+
+func TestScan_BOMPrefixedFile(t *testing.T) {
+	dir := t.TempDir()
+	compose := "\xef\xbb\xbfservices:\n  web:\n    image: nginx\n    ports:\n      - \"8080:80\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if countIssues(result, "parse_error", 0) != 0 {
+		t.Errorf("Expected BOM-prefixed file to parse, got %+v", result.Issues)
+	}
+	if len(result.PortBindings) != 1 || result.PortBindings[0].HostPort != 8080 {
+		t.Errorf("Expected binding on 8080, got %+v", result.PortBindings)
+	}
+}
+
+func TestScan_TabIndentedFile(t *testing.T) {
+	dir := t.TempDir()
+	compose := "services:\n  web:\n\timage: nginx\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.Issues) != 1 || result.Issues[0].Type != "parse_error" {
+		t.Fatalf("Expected one parse_error, got %+v", result.Issues)
+	}
+	desc := result.Issues[0].Description
+	if !strings.Contains(desc, "line 3, column 1") || !strings.Contains(desc, "tab character used for indentation") {
+		t.Errorf("Expected a tab indentation message with position, got %q", desc)
+	}
+}
+
+func TestScan_RedundantExpose(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    expose:
+      - "80"
+      - 9000
+    ports:
+      - "8080:80"
+  dns:
+    image: coredns
+    expose:
+      - "53/tcp"
+    ports:
+      - "5353:53/udp"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ScanWithOptions(dir, Options{Hints: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var redundant []Issue
+	for _, issue := range result.Issues {
+		if issue.Type == "redundant_expose" {
+			redundant = append(redundant, issue)
+		}
+	}
+	if len(redundant) != 1 || redundant[0].Port != 80 || redundant[0].Bindings[0].Service != "web" {
+		t.Fatalf("Expected one redundant_expose for web port 80, got %+v", redundant)
+	}
+	if redundant[0].Severity != "info" || redundant[0].Remediation == "" {
+		t.Errorf("Expected an info advisory with remediation, got %+v", redundant[0])
+	}
+
+	// Hints are opt-in
+	result, err = Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if countIssues(result, "redundant_expose", 80) != 0 {
+		t.Error("Expected no redundant_expose without hints")
+	}
+}
+
+func TestScanPaths_FromPathsFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api", "web", "ignored"} {
+		compose := fmt.Sprintf(`services:
+  %s:
+    image: nginx
+    ports:
+      - "8080:80"
+`, name)
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := fmt.Sprintf("# changed directories\n%s\n\n%s\n# %s\n",
+		filepath.Join(dir, "api"), filepath.Join(dir, "web"), filepath.Join(dir, "ignored"))
+	pathsFile := filepath.Join(dir, "paths.txt")
+	if err := os.WriteFile(pathsFile, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := ReadPathsFile(pathsFile)
+	if err != nil {
+		t.Fatalf("ReadPathsFile failed: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("Expected 2 paths, got %v", paths)
+	}
+
+	result, err := ScanPaths(paths, Options{})
+	if err != nil {
+		t.Fatalf("ScanPaths failed: %v", err)
+	}
+
+	if len(result.ComposeFiles) != 2 {
+		t.Errorf("Expected 2 compose files, got %v", result.ComposeFiles)
+	}
+	if countIssues(result, "collision", 8080) != 1 {
+		t.Errorf("Expected one cross-directory collision on 8080, got %+v", result.Issues)
+	}
+	for _, b := range result.PortBindings {
+		if b.Service == "ignored" {
+			t.Error("Commented path should not be scanned")
+		}
+	}
+}
+
+func TestScan_ExposedDatastore(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  db:
+    image: postgres:16
+    ports:
+      - "0.0.0.0:5432:5432"
+  cache:
+    image: docker.io/library/redis:7
+    ports:
+      - "127.0.0.1:6379:6379"
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  admin:
+    image: mongo-express
+    ports:
+      - "8081:8081"
+  legacy:
+    image: bitnami/postgresql:15
+    ports:
+      - "5433:5432"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if countIssues(result, "exposed_datastore", 5432) != 1 {
+		t.Errorf("Expected exposed_datastore for postgres on 0.0.0.0, got %+v", result.Issues)
+	}
+	if countIssues(result, "exposed_datastore", 6379) != 0 {
+		t.Error("Expected no exposed_datastore for redis on loopback")
+	}
+	if countIssues(result, "exposed_datastore", 8080) != 0 {
+		t.Error("Expected no exposed_datastore for nginx")
+	}
+	if countIssues(result, "exposed_datastore", 8081) != 0 {
+		t.Error("Expected no exposed_datastore for mongo-express, a tool named after a datastore")
+	}
+	if countIssues(result, "exposed_datastore", 5433) != 1 {
+		t.Error("Expected exposed_datastore for bitnami/postgresql, an alias of postgres")
+	}
+
+	// The datastore list is configurable
+	result, err = ScanWithOptions(dir, Options{Datastores: []string{"nginx"}})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if countIssues(result, "exposed_datastore", 5432) != 0 || countIssues(result, "exposed_datastore", 8080) != 1 {
+		t.Errorf("Expected only nginx to be flagged with a custom list, got %+v", result.Issues)
+	}
+}
+
+func TestClaimedIssues(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "3000:80"
+      - "5353:53/udp"
+  api:
+    image: node
+    ports:
+      - "4000:4000"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	files := map[string]string{
+		"plain": "# claimed by dev tooling\n3000 vite dev server\n5353/tcp\n9000\n",
+		"json":  `[{"port": 3000, "owner": "vite dev server"}, {"port": 5353, "protocol": "tcp"}, 9000]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		claimed, err := LoadClaimedPorts(path)
+		if err != nil {
+			t.Fatalf("%s: LoadClaimedPorts failed: %v", name, err)
+		}
+		if len(claimed) != 3 {
+			t.Fatalf("%s: expected 3 claimed ports, got %+v", name, claimed)
+		}
+
+		issues := result.ClaimedIssues(claimed)
+		if len(issues) != 1 || issues[0].Type != "externally_claimed" || issues[0].Port != 3000 {
+			t.Fatalf("%s: expected one externally_claimed issue on 3000, got %+v", name, issues)
+		}
+		if issues[0].Description != "Port 3000 (for web) is claimed by vite dev server" {
+			t.Errorf("%s: unexpected description %q", name, issues[0].Description)
+		}
+	}
+}
+
+func TestScan_Exposure(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  local:
+    image: nginx
+    ports:
+      - "127.0.0.1:8080:80"
+  lan:
+    image: nginx
+    ports:
+      - "192.168.1.10:8081:80"
+  public:
+    image: nginx
+    ports:
+      - "8082:80"
+      - "0.0.0.0:8083:80"
+      - "203.0.113.7:8084:80"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	want := map[int]string{
+		8080: ExposureLocal,
+		8081: ExposurePrivate,
+		8082: ExposurePublic,
+		8083: ExposurePublic,
+		8084: ExposurePublic,
+	}
+	for _, b := range result.PortBindings {
+		if b.Exposure != want[b.HostPort] {
+			t.Errorf("Port %d exposure = %q, want %q", b.HostPort, b.Exposure, want[b.HostPort])
+		}
+	}
+
+	counts := result.ExposureCounts()
+	if counts[ExposureLocal] != 1 || counts[ExposurePrivate] != 1 || counts[ExposurePublic] != 3 {
+		t.Errorf("ExposureCounts = %v", counts)
+	}
+}
+
+func TestPublicDatastores(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  db:
+    image: postgres:16
+    ports:
+      - "5432:5432"
+  cache:
+    image: redis:7
+    ports:
+      - "127.0.0.1:6379:6379"
+  search:
+    image: elasticsearch:8
+    ports:
+      - "203.0.113.7:9200:9200"
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var services []string
+	for _, b := range result.PublicDatastores() {
+		services = append(services, b.Service)
+	}
+	sort.Strings(services)
+	if strings.Join(services, ",") != "db,search" {
+		t.Errorf("PublicDatastores = %v, want [db search]", services)
+	}
+}
+
+func TestUpdateFile_MatchesFullRescan(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	base := write("docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  db:
+    image: postgres
+    ports:
+      - "127.0.0.1:5432:5432"
+`)
+	override := write("docker-compose.override.yml", `services:
+  web:
+    ports:
+      - "8081:80"
+`)
+	write("other/docker-compose.yml", `services:
+  api:
+    image: node
+    ports:
+      - "8081:3000"
+      - "80:8080"
+`)
+
+	opts := Options{Hints: true}
+	result, err := ScanWithOptions(dir, opts)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	steps := []struct {
+		path, content string
+	}{
+		// Resolve the 8081 collision from the override
+		{override, `services:
+  web:
+    ports:
+      - "9090:80"
+`},
+		// Publish the datastore on all interfaces and add a redundant expose
+		{base, `services:
+  web:
+    image: nginx
+    expose:
+      - "80"
+    ports:
+      - "8080:80"
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`},
+		// Break the file
+		{base, "services:\n  web:\n\timage: nginx\n"},
+		// Drop the override's ports so the base ones apply again
+		{override, `services:
+  web:
+    image: nginx
+`},
+	}
+
+	for i, step := range steps {
+		write(strings.TrimPrefix(step.path, dir+string(filepath.Separator)), step.content)
+		if err := result.UpdateFile(step.path); err != nil {
+			t.Fatalf("step %d: UpdateFile failed: %v", i, err)
+		}
+
+		want, err := ScanWithOptions(dir, opts)
+		if err != nil {
+			t.Fatalf("step %d: Scan failed: %v", i, err)
+		}
+		if !reflect.DeepEqual(result.Issues, want.Issues) {
+			t.Errorf("step %d: incremental issues differ from a full rescan\n got: %+v\nwant: %+v", i, result.Issues, want.Issues)
+		}
+		if !reflect.DeepEqual(result.PortBindings, want.PortBindings) {
+			t.Errorf("step %d: incremental bindings differ from a full rescan", i)
+		}
+	}
+
+	if err := result.UpdateFile(filepath.Join(dir, "missing.yml")); err == nil {
+		t.Error("Expected an error for a file outside the scan")
+	}
+}
+
+func TestTouchedPorts_OnlyAffectedPorts(t *testing.T) {
+	web := PortBinding{HostPort: 8080, ContainerPort: 80, Protocol: "tcp", Service: "web", Project: "p"}
+	api := PortBinding{HostPort: 3000, ContainerPort: 3000, Protocol: "tcp", Service: "api", Project: "p"}
+	moved := web
+	moved.HostPort = 9090
+
+	changed := parsedFile{Project: "p", Services: []parsedService{{Name: "web"}}}
+	touched := touchedPorts([]PortBinding{web, api}, []PortBinding{moved, api}, changed)
+
+	if !touched[8080] || !touched[9090] || touched[3000] || len(touched) != 2 {
+		t.Errorf("touchedPorts = %v, want 8080 and 9090 only", touched)
+	}
+}
+
+func TestScan_InterfaceNameHost(t *testing.T) {
+	defer func(orig func(string) ([]net.IP, error)) { interfaceAddrs = orig }(interfaceAddrs)
+	interfaceAddrs = func(name string) ([]net.IP, error) {
+		if name == "eth0" {
+			return []net.IP{net.ParseIP("192.168.1.10"), net.ParseIP("fe80::1")}, nil
+		}
+		return nil, fmt.Errorf("route ip+net: no such network interface")
+	}
+
+	dir := t.TempDir()
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "eth0:8080:80"
+  api:
+    image: node
+    ports:
+      - "192.168.1.10:8080:3000"
+  vpn:
+    image: wireguard
+    ports:
+      - "wg0:51820:51820/udp"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var webIPs []string
+	for _, b := range result.PortBindings {
+		if b.Service == "web" {
+			webIPs = append(webIPs, b.HostIP)
+			if b.Original != "eth0:8080:80" {
+				t.Errorf("Expected the original entry to be kept, got %q", b.Original)
+			}
+		}
+		if b.Service == "vpn" && (b.HostIP != "wg0" || b.Protocol != "udp") {
+			t.Errorf("Unresolved interface should keep its name, got %+v", b)
+		}
+	}
+	sort.Strings(webIPs)
+	if strings.Join(webIPs, ",") != "192.168.1.10,fe80::1" {
+		t.Errorf("web host IPs = %v, want the resolved interface addresses", webIPs)
+	}
+
+	// The resolved address overlaps api's specific bind
+	if countIssues(result, "potential_collision", 8080) != 1 {
+		t.Errorf("Expected a potential collision on the resolved address, got %+v", result.Issues)
+	}
+	if countIssues(result, "unresolved_interface", 51820) != 1 {
+		t.Errorf("Expected an unresolved_interface info for wg0, got %+v", result.Issues)
+	}
+}
+
+func TestScan_CollisionDescriptionsNameAddress(t *testing.T) {
+	tests := []struct {
+		name  string
+		ports map[string]string // service -> port entry
+		want  string
+	}{
+		{
+			name:  "wildcard",
+			ports: map[string]string{"api": "8080:3000", "web": "0.0.0.0:8080:80"},
+			want:  "Port 8080 conflict on 0.0.0.0 between api and web",
+		},
+		{
+			name:  "shadowed",
+			ports: map[string]string{"api": "127.0.0.1:8080:3000", "web": "8080:80"},
+			want:  "Port 8080 conflict: 0.0.0.0 (web) shadows 127.0.0.1 (api)",
+		},
+		{
+			name:  "mixed",
+			ports: map[string]string{"api": "8080:3000", "db": "192.168.1.10:8080:5432", "web": "8080:80"},
+			want:  "Port 8080 conflict on 0.0.0.0 between api and web; 0.0.0.0 shadows 192.168.1.10 (db)",
+		},
+		{
+			name:  "specific",
+			ports: map[string]string{"api": "127.0.0.1:8080:3000", "db": "192.168.1.10:8080:5432", "web": "127.0.0.1:8080:80"},
+			want:  "Port 8080 bound multiple times with specific IPs: 127.0.0.1 (api, web), 192.168.1.10 (db)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var compose strings.Builder
+			compose.WriteString("services:\n")
+			for service, port := range tt.ports {
+				fmt.Fprintf(&compose, "  %s:\n    image: test\n    ports:\n      - %q\n", service, port)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose.String()), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Scan(dir)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+
+			for _, issue := range result.Issues {
+				if issue.Port == 8080 && (issue.Type == "collision" || issue.Type == "shadowed" || issue.Type == "potential_collision") {
+					if issue.Description != tt.want {
+						t.Errorf("Description = %q, want %q", issue.Description, tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("No collision on 8080 in %+v", result.Issues)
+		})
+	}
+}
+
+func TestScan_WildcardShadowsSpecificBind(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  admin:
+    image: nginx
+    ports:
+      - "127.0.0.1:8080:80"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if n := len(result.FilterByType("collision")); n != 0 {
+		t.Errorf("A single wildcard bind should not be a plain collision, got %d", n)
+	}
+	shadowed := result.FilterByType("shadowed")
+	if len(shadowed) != 1 {
+		t.Fatalf("Expected one shadowed issue, got %+v", result.Issues)
+	}
+	issue := shadowed[0]
+	if issue.Severity != "warning" || issue.Port != 8080 || len(issue.Bindings) != 2 {
+		t.Errorf("Unexpected shadowed issue %+v", issue)
+	}
+	if want := "0.0.0.0 (web) shadows 127.0.0.1 (admin)"; !strings.Contains(issue.Description, want) {
+		t.Errorf("Description %q should contain %q", issue.Description, want)
+	}
+	if issue.Remediation == "" {
+		t.Error("Expected a remediation")
+	}
+}
+
+func TestScan_RangeAndSinglePortCollision(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: node
+    ports:
+      - "8078-8082:3000"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortMap[8078]) != 1 || len(result.PortMap[8082]) != 1 {
+		t.Errorf("Expected every port of the range in PortMap, got %v", result.PortMap)
+	}
+
+	var collisions []Issue
+	for _, issue := range result.Issues {
+		if issue.Type == "collision" {
+			collisions = append(collisions, issue)
+		}
+	}
+	if len(collisions) != 1 || collisions[0].Port != 8080 {
+		t.Fatalf("Expected exactly one collision on 8080, got %+v", collisions)
+	}
+	want := "Port 8080 conflict on 0.0.0.0 between api and web: 8078-8082 range (api) vs 8080 single bind (web)"
+	if collisions[0].Description != want {
+		t.Errorf("Description = %q, want %q", collisions[0].Description, want)
+	}
+}
+
+func TestScan_RangeOverlap(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		ports []int
+		want  string
+	}{
+		{"partial overlap", "8000-8008:8000-8008", "8005-8010:8005-8010", []int{8005, 8006, 8007, 8008}, "ports 8005–8008 overlap"},
+		{"containment", "8000-8010:3000", "8003-8004:8003-8004", []int{8003, 8004}, "ports 8003–8004 overlap"},
+		{"adjacent", "8000-8004:8000-8004", "8005-8009:8005-8009", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			compose := fmt.Sprintf(`services:
+  blue:
+    image: app
+    ports:
+      - "%s"
+  green:
+    image: app
+    ports:
+      - "%s"
+`, tt.a, tt.b)
+			if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Scan(dir)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+
+			var ports []int
+			for _, issue := range result.Issues {
+				if issue.Type != "collision" {
+					continue
+				}
+				ports = append(ports, issue.Port)
+				if !strings.Contains(issue.Description, tt.want) {
+					t.Errorf("Description %q should contain %q", issue.Description, tt.want)
+				}
+			}
+			if fmt.Sprint(ports) != fmt.Sprint(tt.ports) {
+				t.Errorf("Collisions on %v, want %v", ports, tt.ports)
+			}
+		})
+	}
+}
+
+func TestScan_Paranoid(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "127.0.0.1:8080:80"
+  api:
+    image: node
+    ports:
+      - "192.168.1.10:8080:3000"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if result.HasIssues() {
+		t.Errorf("Distinct specific IPs should not conflict, got %+v", result.Issues)
+	}
+
+	result, err = ScanWithOptions(dir, Options{Paranoid: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if countIssues(result, "potential_collision", 8080) != 1 {
+		t.Fatalf("Expected a potential collision under paranoid mode, got %+v", result.Issues)
+	}
+	if result.Issues[0].Severity != "warning" {
+		t.Errorf("Severity = %s, want warning", result.Issues[0].Severity)
+	}
+}
+
+func TestScan_ParseDiagnosticNamesYAMLPath(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - "8443:443"
+      - "80:eighty"
+      - "${METRICS_PORT}:9090"
+      - target: 9000
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	// The unresolved entry is kept as a binding too
+	if len(result.PortBindings) != 3 {
+		t.Errorf("Expected the two valid entries and the unresolved one as bindings, got %d", len(result.PortBindings))
+	}
+
+	var diagnostics []Issue
+	for _, issue := range result.Issues {
+		if issue.Type == "parse" {
+			diagnostics = append(diagnostics, issue)
+		}
+	}
+	if len(diagnostics) != 1 {
+		t.Fatalf("Expected one parse diagnostic, got %+v", diagnostics)
+	}
+	if !strings.Contains(diagnostics[0].Description, `services.web.ports[2]`) ||
+		!strings.Contains(diagnostics[0].Description, `"80:eighty"`) {
+		t.Errorf("Diagnostic should name the YAML path and raw value, got %q", diagnostics[0].Description)
+	}
+}
+
+func TestScan_ParseDiagnosticNamesLongSyntaxKey(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - target: 80
+        published: 8080
+        x-note: public site
+      - target: http
+        published: 8081
+      - published: 8082
+      - target: 443
+        publihsed: 8443
+      - target: 9000
+        published: nine
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortBindings) != 1 || result.PortBindings[0].HostPort != 8080 {
+		t.Errorf("Expected only the valid entry as a binding, got %+v", result.PortBindings)
+	}
+	want := map[int]string{
+		1: `target "http" is not a port number`,
+		2: `missing key "target"`,
+		3: `unknown key "publihsed"`,
+		4: `published "nine" is not a port number`,
+	}
+	diagnostics := result.FilterByType("parse")
+	if len(diagnostics) != len(want) {
+		t.Fatalf("Expected %d parse diagnostics, got %+v", len(want), diagnostics)
+	}
+	for i, issue := range diagnostics {
+		index := i + 1
+		if !strings.Contains(issue.Description, fmt.Sprintf("services.web.ports[%d]", index)) ||
+			!strings.HasSuffix(issue.Description, want[index]) {
+			t.Errorf("Diagnostic %d = %q, want the YAML path and %s", index, issue.Description, want[index])
+		}
+	}
+}
+
+func TestParseEntry_InvalidLongSyntaxProtocol(t *testing.T) {
+	tests := []interface{}{
+		map[string]interface{}{"target": 53, "published": 5353, "protocol": 17},
+		map[string]interface{}{"target": 53, "published": 5353, "protocol": true},
+		map[string]interface{}{"target": 53, "published": 5353, "protocol": "icmp"},
+	}
+
+	for _, input := range tests {
+		bindings, issue := parseEntry(input, "dns", "test.yml")
+		if len(bindings) != 0 {
+			t.Errorf("parseEntry(%v) should not produce bindings, got %+v", input, bindings)
+		}
+		if issue == nil || issue.Type != "invalid_protocol" || issue.Port != 5353 {
+			t.Errorf("parseEntry(%v) issue = %+v, want invalid_protocol on 5353", input, issue)
+		}
+	}
+
+	bindings, issue := parseEntry(map[string]interface{}{"target": 53.0, "published": 5353, "protocol": "udp"}, "dns", "test.yml")
+	if issue != nil || len(bindings) != 1 || bindings[0].Protocol != "udp" || bindings[0].ContainerPort != 53 {
+		t.Errorf("Valid long syntax = %+v, %+v", bindings, issue)
+	}
+}
+
+func TestCollapseDuplicates_AcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"docker-compose.yml", "docker-compose.dev.yml", "docker-compose.test.yml"} {
+		compose := "services:\n  proxy:\n    image: nginx\n    ports:\n      - \"80:80\"\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(compose), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if countIssues(result, "privileged", 80) != 3 {
+		t.Fatalf("Expected one privileged issue per file, got %+v", result.Issues)
+	}
+
+	collapsed := CollapseDuplicates(result.Issues)
+	if len(collapsed) != len(result.Issues)-2 {
+		t.Errorf("Expected the privileged issues to collapse into one, got %+v", collapsed)
+	}
+	for _, issue := range collapsed {
+		switch issue.Type {
+		case "privileged":
+			if issue.Occurrences != 3 || len(issue.Bindings) != 3 {
+				t.Errorf("privileged: Occurrences = %d with %d bindings, want 3 and 3", issue.Occurrences, len(issue.Bindings))
+			}
+			files := make(map[string]bool)
+			for _, b := range issue.Bindings {
+				files[b.File] = true
+			}
+			if len(files) != 3 {
+				t.Errorf("Expected bindings from every file, got %+v", issue.Bindings)
+			}
+		case "collision":
+			if issue.Occurrences != 1 {
+				t.Errorf("collision: Occurrences = %d, want 1", issue.Occurrences)
+			}
+		}
+	}
+}
+
+func TestCollapseDuplicates_KeepsFileIssuesApart(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"docker-compose.yml", "docker-compose.dev.yml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("services: [\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	collapsed := CollapseDuplicates(result.Issues)
+	files := make(map[string]bool)
+	for _, issue := range collapsed {
+		if issue.Port != 0 {
+			continue
+		}
+		if issue.Occurrences != 1 {
+			t.Errorf("Expected %s to stay on its own, Occurrences = %d", issue.Description, issue.Occurrences)
+		}
+		files[issue.Description] = true
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected one issue per broken file, got %+v", collapsed)
+	}
+}
+
+func TestScan_ContainerNameAndPortCollision(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  api:
+    image: node
+    container_name: app
+    ports:
+      - "8080:3000"
+  web:
+    image: nginx
+    container_name: app
+    ports:
+      - "8080:80"
+  worker:
+    image: node
+    container_name: jobs
+  cron:
+    image: node
+    container_name: jobs
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if countIssues(result, "collision", 8080) != 0 {
+		t.Errorf("The port collision should be folded into the combined issue, got %+v", result.Issues)
+	}
+	var combined, names []Issue
+	for _, issue := range result.Issues {
+		switch issue.Type {
+		case "container_name_port_collision":
+			combined = append(combined, issue)
+		case "container_name_collision":
+			names = append(names, issue)
+		}
+	}
+	if len(combined) != 1 {
+		t.Fatalf("Expected one combined issue, got %+v", result.Issues)
+	}
+	want := "Services api and web share container_name app and host port 8080; changing only one still leaves the other conflict"
+	if combined[0].Severity != "error" || combined[0].Description != want {
+		t.Errorf("Combined issue = %+v, want error %q", combined[0], want)
+	}
+
+	// Only the name clash without a shared port is reported on its own
+	if len(names) != 1 || !strings.Contains(names[0].Description, "container_name jobs") {
+		t.Errorf("Expected a single container_name_collision for jobs, got %+v", names)
+	}
+}
+
+func TestRuleTypes_CoverReportedIssues(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    container_name: app
+    ports:
+      - "80:80"
+      - "8080:80"
+      - "9000-9001:9000"
+      - "bogus"
+      - target: 53
+        published: 5353
+        protocol: icmp
+  api:
+    image: node
+    container_name: app
+    ports:
+      - "8080:3000"
+      - "127.0.0.1:4000:4000"
+      - "127.0.0.1:4000:4001"
+      - "9005-9003:9000"
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ScanWithOptions(dir, Options{Hints: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(result.Issues) < 5 {
+		t.Fatalf("Expected a variety of issues, got %+v", result.Issues)
+	}
+	for _, issue := range result.Issues {
+		if RuleDescription(issue.Type) == "" {
+			t.Errorf("Issue type %s is not listed in RuleTypes", issue.Type)
+		}
+	}
+}
+
+func TestScan_ImplicitAndExplicitTCPCollide(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+  web:
+    image: nginx
+    ports:
+      - "8080:80/TCP"
+  dns:
+    image: dns
+    ports:
+      - "8080:53/udp"
+  admin:
+    image: admin
+    ports:
+      - target: 9000
+        published: 8080
+        protocol: tcp
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	for _, b := range result.PortBindings {
+		if b.Service != "dns" && b.Protocol != "tcp" {
+			t.Errorf("Protocol of %s = %q, want normalized tcp", b.Service, b.Protocol)
+		}
+	}
+
+	collisions := result.FilterByType("collision")
+	if len(collisions) != 1 {
+		t.Fatalf("Expected a single TCP collision on 8080, got %+v", collisions)
+	}
+	services := make(map[string]bool)
+	for _, b := range collisions[0].Bindings {
+		services[b.Service] = true
+	}
+	if len(services) != 3 || services["dns"] {
+		t.Errorf("Expected api, web and admin to collide without dns, got %+v", collisions[0].Bindings)
+	}
+}
+
+func TestScan_SniffNonStandardNames(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"stack.yaml": `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+`,
+		"infra/services.yml": `services:
+  api:
+    image: node
+    ports:
+      - "3000:3000"
+`,
+		".github-workflow.yml": `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+`,
+		"values.yaml": `services:
+  worker:
+    image: worker
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(result.ComposeFiles) != 0 {
+		t.Errorf("Without sniffing no files should be found, got %v", result.ComposeFiles)
+	}
+
+	result, err = ScanWithOptions(dir, Options{Sniff: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "stack.yaml"), filepath.Join(dir, "infra", "services.yml")}
+	if !reflect.DeepEqual(result.ComposeFiles, want) {
+		t.Errorf("ComposeFiles = %v, want %v", result.ComposeFiles, want)
+	}
+	if len(result.PortBindings) != 2 {
+		t.Errorf("Expected bindings from both sniffed files, got %+v", result.PortBindings)
+	}
+}
+
+func TestIssueID_StableAndContentDerived(t *testing.T) {
+	scan := func(compose string) *Result {
+		t.Helper()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "docker-compose.yml")
+		if err := os.WriteFile(path, []byte(compose), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Relative file names keep IDs comparable across temp dirs
+		result, err := Scan(dir)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		for i := range result.Issues {
+			if result.Issues[i].ID == "" {
+				t.Errorf("Issue without ID: %+v", result.Issues[i])
+			}
+			for j := range result.Issues[i].Bindings {
+				result.Issues[i].Bindings[j].File = filepath.Base(result.Issues[i].Bindings[j].File)
+			}
+			result.Issues[i].ID = IssueID(result.Issues[i])
+		}
+		return result
+	}
+	collisionID := func(r *Result) string {
+		t.Helper()
+		collisions := r.FilterByType("collision")
+		if len(collisions) != 1 {
+			t.Fatalf("Expected one collision, got %+v", r.Issues)
+		}
+		return collisions[0].ID
+	}
+
+	base := "services:\n  api:\n    image: node\n    ports:\n      - \"8080:3000\"\n  web:\n    image: nginx\n    ports:\n      - \"8080:80\"\n"
+	first, second := collisionID(scan(base)), collisionID(scan(base))
+	if first == "" || first != second {
+		t.Errorf("IDs should be stable across runs, got %q and %q", first, second)
+	}
+
+	// Binding order and description do not matter
+	issue := scan(base).FilterByType("collision")[0]
+	issue.Bindings[0], issue.Bindings[1] = issue.Bindings[1], issue.Bindings[0]
+	issue.Description = "reworded"
+	if IssueID(issue) != first {
+		t.Error("ID should not depend on binding order or description")
+	}
+
+	otherService := strings.Replace(base, "web:", "proxy:", 1)
+	if collisionID(scan(otherService)) == first {
+		t.Error("ID should change when the services change")
+	}
+	otherPort := strings.ReplaceAll(base, "8080:", "9090:")
+	if collisionID(scan(otherPort)) == first {
+		t.Error("ID should change when the port changes")
+	}
+}
+
+func TestScan_AnchorMergeLocalPortsWin(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `x-base: &base
+  image: nginx
+  ports:
+    - "8080:80"
+x-debug: &debug
+  ports:
+    - "7070:70"
+services:
+  web:
+    <<: *base
+    ports:
+      - "9090:90"
+  admin:
+    ports:
+      - "9191:91"
+    <<: *base
+  plain:
+    <<: *base
+  multi:
+    <<: [*debug, *base]
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	got := make(map[string][]int)
+	for _, b := range result.PortBindings {
+		got[b.Service] = append(got[b.Service], b.HostPort)
+	}
+	want := map[string][]int{
+		"web":   {9090},
+		"admin": {9191},
+		"plain": {8080},
+		"multi": {7070},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bindings by service = %v, want %v", got, want)
+	}
+}
+
+func TestScan_FloatPorts(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - 8080.0
+      - 8080.5
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.PortBindings) != 1 || !result.PortBindings[0].RandomHostPort || result.PortBindings[0].ContainerPort != 8080 {
+		t.Errorf("Expected 8080.0 to publish container port 8080 on a random host port, got %+v", result.PortBindings)
+	}
+
+	invalid := result.FilterByType("invalid_port")
+	if len(invalid) != 1 || !strings.Contains(invalid[0].Description, "8080.5") {
+		t.Errorf("Expected an invalid_port issue for 8080.5, got %+v", result.Issues)
+	}
+	if len(result.FilterByType("parse")) != 0 {
+		t.Errorf("8080.5 should be reported once, as invalid_port, got %+v", result.Issues)
+	}
+}
+
+func TestScan_ExtendsAndInclude(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"docker-compose.yml": `include:
+  - db/compose.yml
+services:
+  base:
+    image: node
+    ports:
+      - "3000:3000"
+  api:
+    extends: base
+    ports:
+      - "3001:3001"
+  worker:
+    extends:
+      file: common/services.yml
+      service: metrics
+`,
+		"common/services.yml": `services:
+  metrics:
+    image: prom
+    ports:
+      - "9090:9090"
+`,
+		"db/compose.yml": `services:
+  postgres:
+    image: postgres
+    ports:
+      - "5432:5432"
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	want := map[string]string{
+		"api/3000":      filepath.Join(dir, "docker-compose.yml"),
+		"api/3001":      filepath.Join(dir, "docker-compose.yml"),
+		"base/3000":     filepath.Join(dir, "docker-compose.yml"),
+		"worker/9090":   filepath.Join(dir, "common", "services.yml"),
+		"postgres/5432": filepath.Join(dir, "db", "compose.yml"),
+	}
+	got := make(map[string]string)
+	for _, b := range result.PortBindings {
+		got[fmt.Sprintf("%s/%d", b.Service, b.HostPort)] = b.File
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bindings by service/port = %v, want %v", got, want)
+	}
+	for _, b := range result.PortMap[9090] {
+		if b.Image != "prom" {
+			t.Errorf("Expected worker to inherit the prom image, got %q", b.Image)
+		}
+	}
+
+	// api inherits base's 3000, which is a real collision when both run
+	if collisions := result.FilterByType("collision"); len(collisions) != 1 || collisions[0].Port != 3000 {
+		t.Errorf("Expected one collision on the inherited port 3000, got %+v", collisions)
+	}
+}
+
+func TestScan_CircularExtends(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  a:
+    extends: b
+    ports:
+      - "8080:80"
+  b:
+    extends: a
+    ports:
+      - "8081:80"
+  c:
+    extends: missing
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	issues := result.FilterByType("invalid_extends")
+	if len(issues) != 3 {
+		t.Fatalf("Expected circular extends for a and b and a missing target for c, got %+v", issues)
+	}
+	var circular int
+	for _, issue := range issues {
+		if strings.Contains(issue.Description, "is circular") {
+			circular++
+		}
+	}
+	if circular != 2 {
+		t.Errorf("Expected two circular extends issues, got %+v", issues)
+	}
+}
+
+func TestScanWithOptions_Profiles(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  debug:
+    image: debug
+    profiles: [dev]
+    ports:
+      - "8080:8080"
+  tools:
+    image: tools
+    profiles: [tools]
+    ports:
+      - "9000:9000"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		profiles   []string
+		services   []string
+		collisions int
+	}{
+		{nil, []string{"debug", "tools", "web"}, 1},
+		{[]string{"tools"}, []string{"tools", "web"}, 0},
+		{[]string{"dev"}, []string{"debug", "web"}, 1},
+	}
+	for _, tt := range tests {
+		result, err := ScanWithOptions(dir, Options{Profiles: tt.profiles})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+
+		var services []string
+		for _, b := range result.PortBindings {
+			services = append(services, b.Service)
+		}
+		sort.Strings(services)
+		if !reflect.DeepEqual(services, tt.services) {
+			t.Errorf("profiles %v: services = %v, want %v", tt.profiles, services, tt.services)
+		}
+		if n := len(result.FilterByType("collision")); n != tt.collisions {
+			t.Errorf("profiles %v: expected %d collisions, got %d", tt.profiles, tt.collisions, n)
+		}
+	}
+}
+
+func TestScan_HostModeCollision(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  edge:
+    image: traefik
+    ports:
+      - target: 80
+        published: 8080
+        host_ip: 10.0.0.1
+        mode: host
+  proxy:
+    image: nginx
+    ports:
+      - target: 80
+        published: 8080
+        host_ip: 10.0.0.2
+        mode: host
+  api:
+    image: api
+    ports:
+      - target: 3000
+        published: 3000
+        host_ip: 10.0.0.1
+        mode: host
+  web:
+    image: web
+    ports:
+      - target: 3000
+        published: 3000
+        host_ip: 10.0.0.2
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	for _, b := range result.PortMap[8080] {
+		if b.Mode != "host" {
+			t.Errorf("Expected mode host on %s, got %q", b.Service, b.Mode)
+		}
+	}
+
+	collisions := result.FilterByType("collision")
+	if len(collisions) != 1 || collisions[0].Port != 8080 {
+		t.Fatalf("Expected only the two mode: host bindings on 8080 to collide, got %+v", collisions)
+	}
+	if !strings.Contains(collisions[0].Description, "mode: host") {
+		t.Errorf("Expected the collision to name mode: host, got %q", collisions[0].Description)
+	}
+
+	if n := len(result.FilterByType("host_mode")); n != 3 {
+		t.Errorf("Expected an info issue per mode: host binding, got %d", n)
+	}
+}
+
+func TestScan_BindingPositions(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `x-ports: &ports
+  ports:
+    - "9000:9000"
+services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - target: 443
+        published: 8443
+  worker:
+    <<: *ports
+    image: worker
+  api:
+    extends: web
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	want := map[string][2]int{
+		"web/8080":    {8, 9},
+		"web/8443":    {9, 9},
+		"worker/9000": {3, 7},
+		"api/8080":    {8, 9},
+		"api/8443":    {9, 9},
+	}
+	for _, b := range result.PortBindings {
+		key := fmt.Sprintf("%s/%d", b.Service, b.HostPort)
+		if pos := [2]int{b.Line, b.Column}; pos != want[key] {
+			t.Errorf("%s declared at %d:%d, want %d:%d", key, b.Line, b.Column, want[key][0], want[key][1])
+		}
+	}
+	if len(result.PortBindings) != len(want) {
+		t.Errorf("Expected %d bindings, got %+v", len(want), result.PortBindings)
+	}
+}
+
+func TestScanFiles_MergesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	if err := os.MkdirAll(config, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"stack.yml": `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`,
+		"stack.dev.yml": `services:
+  web:
+    ports:
+      - "9080:80"
+`,
+		// Found by discovery, but not listed, so never scanned
+		"docker-compose.yml": `services:
+  other:
+    image: other
+    ports:
+      - "9080:80"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(config, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := ScanFiles([]string{filepath.Join(config, "stack.yml"), filepath.Join(config, "stack.dev.yml")}, Options{})
+	if err != nil {
+		t.Fatalf("ScanFiles failed: %v", err)
+	}
+
+	ports := make(map[string]int)
+	for _, b := range result.PortBindings {
+		ports[b.Service] = b.HostPort
+	}
+	if !reflect.DeepEqual(ports, map[string]int{"web": 9080, "db": 5432}) {
+		t.Errorf("Expected stack.dev.yml to override web, got %v", ports)
+	}
+	if n := len(result.FilterByType("collision")); n != 0 {
+		t.Errorf("Expected no collision with the unlisted file, got %+v", result.Issues)
+	}
+
+	if _, err := ScanFiles([]string{filepath.Join(config, "missing.yml")}, Options{}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestDiscoverComposeFiles_Depth(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"docker-compose.yml",
+		"api/docker-compose.yml",
+		"services/billing/compose.yml",
+		"services/billing/worker/compose.yml",
+		"node_modules/pkg/compose.yml",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("services: {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	depth := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"base only", Options{MaxDepth: depth(0)}, []string{"docker-compose.yml"}},
+		{"default", Options{}, []string{"docker-compose.yml", "api/docker-compose.yml"}},
+		{"unlimited", Options{MaxDepth: depth(-1)}, []string{
+			"docker-compose.yml", "api/docker-compose.yml",
+			"services/billing/compose.yml", "services/billing/worker/compose.yml",
+		}},
+		{"unlimited with skipped dirs", Options{MaxDepth: depth(-1), NoSkipDirs: true}, []string{
+			"docker-compose.yml", "api/docker-compose.yml", "node_modules/pkg/compose.yml",
+			"services/billing/compose.yml", "services/billing/worker/compose.yml",
+		}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, f := range discoverComposeFiles(dir, tt.opts) {
+			rel, _ := filepath.Rel(dir, f)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: discovered %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScan_IgnoreRules(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+      - "8080:8080"
+  blue:
+    image: app
+    ports:
+      - "9000:9000"
+  green:
+    image: app
+    ports:
+      - "9000:9000"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	ignore := "# intentional blue/green reuse\nport:9000\n\ntype:privileged\n"
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFile), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ScanWithOptions(dir, Options{Ignore: []IgnoreRule{{Field: "file", Value: "missing.yml"}}})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	for _, issue := range result.Issues {
+		if issue.Port == 9000 || issue.Type == "privileged" {
+			t.Errorf("Issue should be suppressed: %+v", issue)
+		}
+	}
+	if result.Suppressed == 0 || result.Suppressed+len(result.Issues) != len(all.Issues) {
+		t.Errorf("Expected %d issues split between kept and suppressed, got %d kept and %d suppressed",
+			len(all.Issues), len(result.Issues), result.Suppressed)
+	}
+
+	result, err = ScanWithOptions(dir, Options{Ignore: []IgnoreRule{{Field: "service", Value: "web"}}})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(result.Issues) != 0 {
+		t.Errorf("Expected programmatic and file rules to combine, got %+v", result.Issues)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("colour:blue\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Scan(dir); err == nil || !strings.Contains(err.Error(), IgnoreFile+":1") {
+		t.Errorf("Expected an error naming the bad line, got %v", err)
+	}
+}
+
+func TestScan_KubernetesNodePortCollision(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "30080:80"
+`
+	manifests := `apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+spec:
+  type: NodePort
+  ports:
+    - port: 80
+      nodePort: 30080
+    - port: 53
+      protocol: UDP
+      nodePort: 30053
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  port: "30080"
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: monitoring
+spec:
+  template:
+    spec:
+      containers:
+        - name: agent
+          image: redis
+          ports:
+            - containerPort: 6379
+              hostPort: 6379
+            - containerPort: 9100
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	k8sDir := filepath.Join(dir, "k8s")
+	if err := os.Mkdir(k8sDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(k8sDir, "stack.yaml")
+	if err := os.WriteFile(manifest, []byte(manifests), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ScanWithOptions(dir, Options{Kubernetes: []string{k8sDir}})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(result.Manifests) != 1 || result.Manifests[0] != manifest {
+		t.Errorf("Expected the manifest to be listed, got %v", result.Manifests)
+	}
+
+	sources := make(map[string]string)
+	for _, b := range result.PortBindings {
+		sources[fmt.Sprintf("%s %d/%s", b.Service, b.HostPort, b.Protocol)] = b.Source
+	}
+	want := map[string]string{
+		"web 30080/tcp":                       "",
+		"Service/frontend 30080/tcp":          SourceKubernetes,
+		"Service/frontend 30053/udp":          SourceKubernetes,
+		"monitoring/DaemonSet/agent 6379/tcp": SourceKubernetes,
+	}
+	if fmt.Sprint(sources) != fmt.Sprint(want) {
+		t.Errorf("Bindings = %v, want %v", sources, want)
+	}
+
+	collisions := result.FilterByType("collision")
+	if len(collisions) != 1 || collisions[0].Port != 30080 {
+		t.Fatalf("Expected the compose port to collide with the nodePort, got %+v", collisions)
+	}
+	if !strings.Contains(collisions[0].Description, "between web and Service/frontend") {
+		t.Errorf("Description should name both sides, got %q", collisions[0].Description)
+	}
+	for _, b := range collisions[0].Bindings {
+		if b.Source == SourceKubernetes && b.Line != 8 {
+			t.Errorf("Expected the nodePort entry on line 8, got %d", b.Line)
+		}
+	}
+	if countIssues(result, "exposed_datastore", 6379) != 1 {
+		t.Errorf("Expected the hostPort redis to be checked as a datastore, got %+v", result.Issues)
+	}
+}
+
+func TestScanReader(t *testing.T) {
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+`
+	result, err := ScanReader(strings.NewReader(compose), StdinLabel, Options{})
+	if err != nil {
+		t.Fatalf("ScanReader failed: %v", err)
+	}
+
+	if len(result.ComposeFiles) != 1 || result.ComposeFiles[0] != StdinLabel {
+		t.Errorf("Expected only %s to be scanned, got %v", StdinLabel, result.ComposeFiles)
+	}
+	collisions := result.FilterByType("collision")
+	if len(collisions) != 1 {
+		t.Fatalf("Expected a collision on 8080, got %+v", result.Issues)
+	}
+	for _, b := range collisions[0].Bindings {
+		if b.File != StdinLabel || b.Line == 0 {
+			t.Errorf("Expected bindings located in %s, got %+v", StdinLabel, b)
+		}
+	}
+
+	broken, err := ScanReader(strings.NewReader("services: [\n"), "generated.yml", Options{})
+	if err != nil {
+		t.Fatalf("ScanReader failed: %v", err)
+	}
+	if issues := broken.FilterByType("parse_error"); len(issues) != 1 || !strings.Contains(issues[0].Description, "generated.yml") {
+		t.Errorf("Expected a parse error naming the label, got %+v", broken.Issues)
+	}
+}
+
+func TestScan_KnownPorts(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  metrics:
+    image: prom/prometheus
+    ports:
+      - "9090:9090"
+  cache:
+    image: redis
+    ports:
+      - "127.0.0.2:6380:6379"
+      - "6379:6379"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	knownFile := filepath.Join(dir, "known-ports.yaml")
+	if err := os.WriteFile(knownFile, []byte("8080: Team proxy\n9090: Prometheus\n6379: \"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	known, err := LoadKnownPorts(knownFile)
+	if err != nil {
+		t.Fatalf("LoadKnownPorts failed: %v", err)
+	}
+	result, err := ScanWithOptions(dir, Options{KnownPorts: known})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	names := make(map[int]string)
+	for _, issue := range result.FilterByType("common_port") {
+		names[issue.Port] = issue.Description
+	}
+	if !strings.Contains(names[8080], "Team proxy") {
+		t.Errorf("Expected the custom name to override the built-in one, got %q", names[8080])
+	}
+	if !strings.Contains(names[9090], "Prometheus") {
+		t.Errorf("Expected a common_port issue for the custom port, got %q", names[9090])
+	}
+	if _, ok := names[6379]; ok {
+		t.Errorf("An empty name should drop the built-in port, got %q", names[6379])
+	}
+
+	disabled, err := ScanWithOptions(dir, Options{KnownPorts: known, NoCommonPorts: true})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if issues := disabled.FilterByType("common_port"); len(issues) != 0 {
+		t.Errorf("Expected no common_port issues when disabled, got %+v", issues)
+	}
+
+	if err := os.WriteFile(knownFile, []byte("70000: Nope\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKnownPorts(knownFile); err == nil {
+		t.Error("Expected an error for an out-of-range port")
+	}
+}
+
+func TestScan_StringLongSyntaxAndMappingPorts(t *testing.T) {
+	dir := t.TempDir()
+
+	compose := `services:
+  api:
+    image: node
+    ports:
+      - published: "8080"
+        target: "3000"
+        host_ip: "127.0.0.1"
+      - "9000": "90"
+      - 9001: 91
+  web:
+    image: nginx
+    ports:
+      - "127.0.0.1:8080:80"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	got := make(map[int]int)
+	for _, b := range result.PortBindings {
+		if b.Service == "api" {
+			got[b.HostPort] = b.ContainerPort
+		}
+	}
+	want := map[int]int{8080: 3000, 9000: 90, 9001: 91}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("api bindings = %v, want %v", got, want)
+	}
+	if b := result.PortMap[8080]; len(b) != 2 || b[0].HostIP != "127.0.0.1" || b[1].HostIP != "127.0.0.1" {
+		t.Errorf("Expected the string host_ip to be kept, got %+v", b)
+	}
+	if len(result.FilterByType("potential_collision")) != 1 {
+		t.Errorf("Expected api and web to share 127.0.0.1:8080, got %+v", result.Issues)
+	}
+	if len(result.FilterByType("parse", "random_port")) != 0 {
+		t.Errorf("Expected every entry to parse, got %+v", result.Issues)
+	}
+}
+
+func TestScan_ScannedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"docker-compose.yml": `include:
+  - db.yml
+services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - "8443:443"
+`,
+		"db.yml": `services:
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`,
+		"compose.yaml": "services: [\n",
+		"tools/docker-compose.yml": `services:
+  worker:
+    image: busybox
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	reports := make(map[string]FileReport)
+	for _, f := range result.ScannedFiles {
+		rel, _ := filepath.Rel(dir, f.Path)
+		reports[rel] = f
+	}
+	if len(reports) != 4 {
+		t.Fatalf("Expected 4 file reports, got %+v", result.ScannedFiles)
+	}
+
+	if f := reports["docker-compose.yml"]; !f.Parsed || f.Bindings != 2 {
+		t.Errorf("Expected the main file to parse with 2 bindings, got %+v", f)
+	}
+	if f := reports["db.yml"]; !f.Parsed || f.Bindings != 1 || f.IncludedBy != filepath.Join(dir, "docker-compose.yml") {
+		t.Errorf("Expected db.yml included with 1 binding, got %+v", f)
+	}
+	if f := reports["compose.yaml"]; f.Parsed || f.Unreadable || f.Error == "" {
+		t.Errorf("Expected compose.yaml to fail parsing, got %+v", f)
+	}
+	if f := reports[filepath.Join("tools", "docker-compose.yml")]; !f.Parsed || f.Bindings != 0 {
+		t.Errorf("Expected tools compose file to parse without bindings, got %+v", f)
+	}
+}
+
+func TestScan_DuplicateBindingWithinService(t *testing.T) {
+	dir := t.TempDir()
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - "8080:80/tcp"
+      - "5353:53/udp"
+      - "5353:53/udp"
+      - "9000:9000"
+      - "9000:9000/udp"
+      - "7000:7000"
+      - "0.0.0.0:7000:7000"
+      - "127.0.0.1:6000:80"
+      - "192.168.1.5:6000:80"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	duplicates := make(map[string]bool)
+	for _, issue := range result.Issues {
+		switch issue.Type {
+		case "duplicate_binding":
+			if issue.Severity != "warning" {
+				t.Errorf("Expected duplicate_binding to be a warning, got %s", issue.Severity)
+			}
+			duplicates[fmt.Sprintf("%d/%s", issue.Port, issue.Bindings[0].Protocol)] = true
+		case "collision":
+			t.Errorf("Expected no collision for a service repeating its own port, got %s", issue.Description)
+		}
+	}
+	for _, want := range []string{"8080/tcp", "5353/udp", "7000/tcp"} {
+		if !duplicates[want] {
+			t.Errorf("Expected a duplicate_binding for %s, got %v", want, duplicates)
+		}
+	}
+	if duplicates["9000/tcp"] || duplicates["9000/udp"] {
+		t.Errorf("Expected tcp and udp on port 9000 not to be duplicates, got %v", duplicates)
+	}
+	if duplicates["6000/tcp"] {
+		t.Errorf("Expected port 6000 on distinct specific IPs not to be a duplicate, got %v", duplicates)
+	}
+}
+
+func TestScan_Cache(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+`)
+	write("compose.yaml", `services:
+  api:
+    image: node
+    ports:
+      - "${API_PORT:-8080}:3000"
+`)
+
+	scan := func() *Result {
+		t.Helper()
+		result, err := ScanWithOptions(dir, Options{Cache: OpenCache(cacheDir)})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		return result
+	}
+
+	first := scan()
+	cached := OpenCache(cacheDir)
+	if len(cached.entries) != 1 {
+		t.Fatalf("Expected only the file without variables to be cached, got %d entries", len(cached.entries))
+	}
+	second := scan()
+	if !reflect.DeepEqual(first.Issues, second.Issues) || !reflect.DeepEqual(first.PortBindings, second.PortBindings) {
+		t.Errorf("Expected a cached scan to match the first one:\n%+v\n%+v", first.Issues, second.Issues)
+	}
+
+	// A changed file is parsed again
+	write("docker-compose.yml", `services:
+  web:
+    image: nginx
+    ports:
+      - "9090:80"
+`)
+	third := scan()
+	for _, issue := range third.Issues {
+		if issue.Type == "collision" {
+			t.Errorf("Expected the changed file to be parsed again, got %s", issue.Description)
+		}
+	}
+	if len(OpenCache(cacheDir).entries) != 1 {
+		t.Errorf("Expected the entry for the old contents to be replaced")
+	}
+
+	if err := ClearCache(cacheDir); err != nil {
+		t.Fatalf("ClearCache failed: %v", err)
+	}
+	if len(OpenCache(cacheDir).entries) != 0 {
+		t.Errorf("Expected an empty cache after ClearCache")
+	}
+	if err := ClearCache(cacheDir); err != nil {
+		t.Errorf("Expected clearing an empty cache to succeed, got %v", err)
+	}
+}
+
+func BenchmarkScan_Cache(b *testing.B) {
+	dir := b.TempDir()
+	for i := 0; i < 200; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("svc%03d", i))
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			b.Fatal(err)
+		}
+		compose := fmt.Sprintf(`services:
+  app:
+    image: app
+    ports:
+      - "%d:80"
+      - "127.0.0.1:%d:443"
+    expose:
+      - "9000"
+  worker:
+    image: worker
+    ports:
+      - "%d-%d:7000-7002"
+`, 10000+i, 20000+i, 30000+i*3, 30002+i*3)
+		if err := os.WriteFile(filepath.Join(sub, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("no-cache", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ScanWithOptions(dir, Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cacheDir := b.TempDir()
+		if _, err := ScanWithOptions(dir, Options{Cache: OpenCache(cacheDir)}); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := ScanWithOptions(dir, Options{Cache: OpenCache(cacheDir)}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestScan_ParallelParsingIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 40; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("svc%02d", i))
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+		// Every project publishes 8080, so each scan reports many collisions
+		compose := fmt.Sprintf("services:\n  app:\n    image: app\n    ports:\n      - \"8080:80\"\n      - \"%d:443\"\n", 9000+i)
+		if err := os.WriteFile(filepath.Join(sub, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	for run := 0; run < 5; run++ {
+		result, err := Scan(dir)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if !reflect.DeepEqual(first.PortBindings, result.PortBindings) || !reflect.DeepEqual(first.Issues, result.Issues) {
+			t.Fatalf("Expected every scan to report bindings and issues in the same order")
+		}
+	}
+	if len(first.PortBindings) != 80 {
+		t.Errorf("Expected 80 bindings, got %d", len(first.PortBindings))
+	}
+}
+
+func BenchmarkParseFiles(b *testing.B) {
+	dir := b.TempDir()
+	var paths []string
+	for i := 0; i < 100; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("compose-%03d.yml", i))
+		var compose strings.Builder
+		compose.WriteString("services:\n")
+		for s := 0; s < 20; s++ {
+			fmt.Fprintf(&compose, "  svc%d:\n    image: app\n    ports:\n      - \"%d:80\"\n      - \"127.0.0.1:%d:443/tcp\"\n",
+				s, 10000+i*40+s, 10020+i*40+s)
+		}
+		if err := os.WriteFile(path, []byte(compose.String()), 0644); err != nil {
+			b.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	project := func(string) string { return "bench" }
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, path := range paths {
+				parseFile(path, project(path))
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Options{}.parseFiles(paths, project)
+		}
+	})
+}
+
+func TestScan_PrivilegedPortInterfaces(t *testing.T) {
+	dir := t.TempDir()
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+      - "127.0.0.1:443:443"
+      - "53"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, allow := range []bool{false, true} {
+		result, err := ScanWithOptions(dir, Options{AllowPrivileged: allow})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		want := "warning"
+		if allow {
+			want = "info"
+		}
+
+		descriptions := make(map[int]string)
+		for _, issue := range result.Issues {
+			if issue.Type != "privileged" {
+				continue
+			}
+			if issue.Severity != want {
+				t.Errorf("AllowPrivileged %v: expected %s, got %s for %s", allow, want, issue.Severity, issue.Description)
+			}
+			descriptions[issue.Port] = issue.Description
+		}
+		if len(descriptions) != 2 {
+			t.Fatalf("Expected privileged issues for 80 and 443 only, not the random host port, got %v", descriptions)
+		}
+		if !strings.Contains(descriptions[80], "all interfaces") {
+			t.Errorf("Expected the wildcard bind to name all interfaces, got %q", descriptions[80])
+		}
+		if !strings.Contains(descriptions[443], "127.0.0.1") {
+			t.Errorf("Expected the specific bind to name its address, got %q", descriptions[443])
+		}
+	}
+}
+
+func TestScan_HostIPValidation(t *testing.T) {
+	dir := t.TempDir()
+	compose := `services:
+  web:
+    image: nginx
+    ports:
+      - "127.0.0.256:8080:80"
+      - "10.0.0.1:7000-7001:7000-7001"
+      - target: 443
+        published: 8443
+        host_ip: "::1"
+      - target: 80
+        published: 8081
+        host_ip: ""
+      - target: 80
+        published: 8082
+        host_ip: localhost
+      - "localhost:8083:80"
+      - "[::1]:8084:80"
+      - "[::1]:7100-7101:7100-7101/udp"
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if parse := result.FilterByType("parse"); len(parse) != 0 {
+		t.Errorf("Expected every short syntax host to parse, got %+v", parse)
+	}
+
+	invalid := make(map[int]bool)
+	for _, issue := range result.Issues {
+		if issue.Type == "invalid_host_ip" {
+			invalid[issue.Port] = true
+		}
+	}
+	if !invalid[8080] || !invalid[8082] || !invalid[8083] || len(invalid) != 3 {
+		t.Errorf("Expected invalid_host_ip for 127.0.0.256 and both localhost entries only, got %v", invalid)
+	}
+
+	// Bindings with an invalid host IP are still recorded
+	if len(result.PortBindings) != 10 {
+		t.Errorf("Expected 10 bindings, got %d: %+v", len(result.PortBindings), result.PortBindings)
+	}
+	if len(result.PortMap[8080]) != 1 || result.PortMap[8080][0].HostIP != "127.0.0.256" {
+		t.Errorf("Expected 8080 recorded with its host IP, got %+v", result.PortMap[8080])
+	}
+	if b := result.PortMap[8083]; len(b) != 1 || b[0].HostIP != "localhost" || b[0].ContainerPort != 80 {
+		t.Errorf("Expected localhost:8083:80 recorded with its host, got %+v", b)
+	}
+	if b := result.PortMap[8084]; len(b) != 1 || b[0].HostIP != "[::1]" || b[0].Exposure != ExposureLocal {
+		t.Errorf("Expected [::1]:8084:80 recorded as a loopback binding, got %+v", b)
+	}
+	if b := result.PortMap[7101]; len(b) != 1 || b[0].HostIP != "[::1]" || b[0].Protocol != "udp" {
+		t.Errorf("Expected the bracketed IPv6 range expanded, got %+v", b)
+	}
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxSniffSize bounds the files parsed by content sniffing so large
+// unrelated YAML, such as data dumps, is skipped without reading it
+const maxSniffSize = 1 << 20
+
+// sniffComposeFiles returns the *.yml and *.yaml files in dir that look
+// like compose files, skipping those already in known
+func sniffComposeFiles(dir string, known map[string]bool) []string {
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, path := range matches {
+			if !known[path] && looksLikeCompose(path) {
+				known[path] = true
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
+
+// looksLikeCompose reports whether a file has a top-level services key
+// with at least one service publishing ports
+func looksLikeCompose(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxSniffSize {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	// Bail out before parsing when there is no services key at all
+	data = stripBOM(data)
+	if !bytes.HasPrefix(data, []byte("services:")) && !bytes.Contains(data, []byte("\nservices:")) {
+		return false
+	}
+
+	var compose composeFile
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return false
+	}
+	for _, svc := range compose.Services {
+		if len(svc.Ports) > 0 {
+			return true
+		}
+	}
+	return false
+}
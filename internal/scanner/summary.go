@@ -0,0 +1,65 @@
+package scanner
+
+// Summary counts the issues by severity and the inputs of a scan
+type Summary struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	Info     int `json:"info"`
+	Bindings int `json:"bindings"`
+	Files    int `json:"files"`
+}
+
+// Summary returns the issue counts and input sizes of r
+func (r *Result) Summary() Summary {
+	s := Summary{
+		Bindings: len(r.PortBindings),
+		Files:    len(r.ComposeFiles),
+	}
+	for _, issue := range r.Issues {
+		switch issue.Severity {
+		case "error":
+			s.Errors++
+		case "warning":
+			s.Warnings++
+		default:
+			s.Info++
+		}
+	}
+	return s
+}
+
+// Exit codes for the most severe issue of a scan
+const (
+	ExitClean    = 0
+	ExitErrors   = 1
+	ExitWarnings = 2
+	ExitInfo     = 3
+)
+
+// ExitCode maps the most severe issue to an exit code: ExitErrors,
+// ExitWarnings or ExitInfo. The code is only nonzero when that issue is at
+// least as severe as failOn; an empty or unknown failOn never fails.
+func (s Summary) ExitCode(failOn string) int {
+	threshold, ok := severityRanks[failOn]
+	if !ok {
+		return ExitClean
+	}
+
+	var worst string
+	var code int
+	switch {
+	case s.Errors > 0:
+		worst, code = "error", ExitErrors
+	case s.Warnings > 0:
+		worst, code = "warning", ExitWarnings
+	case s.Info > 0:
+		worst, code = "info", ExitInfo
+	default:
+		return ExitClean
+	}
+
+	if severityRank(worst) > threshold {
+		return ExitClean
+	}
+	return code
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// utf8BOM is prepended to files by some editors
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+var yamlLineRegex = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// stripBOM removes a leading UTF-8 byte order mark
+func stripBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, utf8BOM)
+}
+
+// describeYAMLError rewrites a yaml.v3 syntax error into one that names the
+// line and column and explains tab indentation, which yaml reports only as
+// an unexpected character
+func describeYAMLError(data []byte, err error) error {
+	m := yamlLineRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return err
+	}
+	text := lines[line-1]
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+
+	if col := strings.IndexByte(indent, '\t'); col >= 0 {
+		return fmt.Errorf("line %d, column %d: tab character used for indentation; YAML requires spaces", line, col+1)
+	}
+	return fmt.Errorf("line %d, column %d: %s", line, len(indent)+1, m[2])
+}
@@ -0,0 +1,217 @@
+// Package watch re-scans compose files when they change and reports what
+// changed between scans
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/portcheck/internal/baseline"
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+// DefaultInterval is how often compose files are checked for changes
+const DefaultInterval = 2 * time.Second
+
+// DefaultDebounce is how long files must stay unchanged before
+// WaitForChange reports them, so one editor save triggers one scan
+const DefaultDebounce = 300 * time.Millisecond
+
+// Watcher polls the compose files under Path and re-scans them on change
+type Watcher struct {
+	Path     string
+	Options  scanner.Options
+	Interval time.Duration
+	Debounce time.Duration
+	Files    []string // also watched, e.g. the ComposeFiles of the last scan
+
+	stamp   string
+	last    *scanner.Result
+	waiting bool
+}
+
+// New returns a Watcher for path using opts
+func New(path string, opts scanner.Options) *Watcher {
+	return &Watcher{Path: path, Options: opts, Interval: DefaultInterval, Debounce: DefaultDebounce}
+}
+
+// Poll re-scans when the compose files differ from the previous poll. It
+// returns the new and previous results and whether a scan happened; the
+// first poll always scans and has no previous result.
+func (w *Watcher) Poll() (curr, prev *scanner.Result, changed bool, err error) {
+	stamp := w.fingerprint()
+	if w.last != nil && stamp == w.stamp {
+		return w.last, w.last, false, nil
+	}
+
+	curr, err = scanner.ScanWithOptions(w.Path, w.Options)
+	if err != nil {
+		return nil, w.last, false, err
+	}
+	prev, w.last, w.stamp = w.last, curr, stamp
+	return curr, prev, true, nil
+}
+
+// Run polls every Interval until ctx is cancelled, calling onScan after
+// each scan
+func (w *Watcher) Run(ctx context.Context, onScan func(curr, prev *scanner.Result) error) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		curr, prev, changed, err := w.Poll()
+		if err != nil {
+			return err
+		}
+		if changed {
+			if err := onScan(curr, prev); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForChange blocks until the watched files differ from the previous
+// call, checking every Interval, then waits until they have been stable
+// for Debounce. Files added under Path count as a change. The first call
+// returns at once. It returns ctx.Err() once ctx is cancelled.
+func (w *Watcher) WaitForChange(ctx context.Context) error {
+	if !w.waiting {
+		w.waiting = true
+		w.stamp = w.fingerprint()
+		return nil
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		stamp := w.fingerprint()
+		if stamp == w.stamp {
+			continue
+		}
+		for w.Debounce > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(w.Debounce):
+			}
+			settled := w.fingerprint()
+			if settled == stamp {
+				break
+			}
+			stamp = settled
+		}
+		w.stamp = stamp
+		return nil
+	}
+}
+
+// fingerprint identifies the current state of the discovered compose
+// files, Files and the files of the last scan
+func (w *Watcher) fingerprint() string {
+	files := append(scanner.DiscoverComposeFilesWithOptions(w.Path, w.Options), w.Files...)
+	if w.last != nil {
+		files = append(files, w.last.ComposeFiles...)
+	}
+	return fingerprint(files)
+}
+
+// fingerprint identifies the current state of files by name, size and
+// modification time
+func fingerprint(files []string) string {
+	sorted := append([]string{}, files...)
+	sort.Strings(sorted)
+
+	var sb strings.Builder
+	for i, f := range sorted {
+		if i > 0 && f == sorted[i-1] {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s|%d|%d\n", f, info.Size(), info.ModTime().UnixNano()))
+	}
+	return sb.String()
+}
+
+// EventIssue is an issue as it appears in an event
+type EventIssue struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	Type        string `json:"type"`
+	Port        int    `json:"port"`
+	Description string `json:"description"`
+}
+
+// Event describes one scan for event stream consumers
+type Event struct {
+	Timestamp      time.Time       `json:"timestamp"`
+	Type           string          `json:"type"`
+	Summary        scanner.Summary `json:"summary"`
+	NewIssues      []EventIssue    `json:"new_issues"`
+	ResolvedIssues []EventIssue    `json:"resolved_issues"`
+}
+
+// NewEvent describes curr relative to prev. With no previous scan every
+// issue is new.
+func NewEvent(curr, prev *scanner.Result, now time.Time) Event {
+	var before []scanner.Issue
+	if prev != nil {
+		before = prev.Issues
+	}
+	_, added := baseline.FromIssues(before).Split(curr.Issues)
+	_, resolved := baseline.FromIssues(curr.Issues).Split(before)
+
+	return Event{
+		Timestamp:      now.UTC(),
+		Type:           "scan",
+		Summary:        curr.Summary(),
+		NewIssues:      eventIssues(added),
+		ResolvedIssues: eventIssues(resolved),
+	}
+}
+
+func eventIssues(issues []scanner.Issue) []EventIssue {
+	out := []EventIssue{}
+	for _, issue := range issues {
+		out = append(out, EventIssue{
+			ID:          issue.ID,
+			Severity:    issue.Severity,
+			Type:        issue.Type,
+			Port:        issue.Port,
+			Description: issue.Description,
+		})
+	}
+	return out
+}
+
+// WriteEvent writes e as a single NDJSON line in one write, so consumers
+// see each event as soon as it is emitted
+func WriteEvent(w io.Writer, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
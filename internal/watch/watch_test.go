@@ -0,0 +1,151 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/portcheck/internal/scanner"
+)
+
+func TestWatch_EventsAcrossFileChange(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "docker-compose.yml")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write(`services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: node
+    ports:
+      - "8080:3000"
+`, start)
+
+	w := New(dir, scanner.Options{})
+	var buf bytes.Buffer
+	poll := func() bool {
+		t.Helper()
+		curr, prev, changed, err := w.Poll()
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if changed {
+			if err := WriteEvent(&buf, NewEvent(curr, prev, time.Unix(0, 0))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return changed
+	}
+
+	if !poll() {
+		t.Fatal("Expected the first poll to scan")
+	}
+	if poll() {
+		t.Error("Expected no scan while files are unchanged")
+	}
+
+	// Resolve the collision
+	write(`services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  api:
+    image: node
+    ports:
+      - "8081:3000"
+`, start.Add(time.Minute))
+	if !poll() {
+		t.Fatal("Expected a scan after the file changed")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 event lines, got %d:\n%s", len(lines), buf.String())
+	}
+
+	var events []Event
+	for _, line := range lines {
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("Malformed event %q: %v", line, err)
+		}
+		if e.Type != "scan" || !e.Timestamp.Equal(time.Unix(0, 0)) {
+			t.Errorf("Unexpected event header %+v", e)
+		}
+		events = append(events, e)
+	}
+
+	if events[0].Summary.Errors != 1 || !hasIssue(events[0].NewIssues, "collision", 8080) {
+		t.Errorf("First event should report the new collision, got %+v", events[0])
+	}
+	if len(events[0].ResolvedIssues) != 0 {
+		t.Errorf("First event should resolve nothing, got %+v", events[0].ResolvedIssues)
+	}
+	if events[1].Summary.Errors != 0 || !hasIssue(events[1].ResolvedIssues, "collision", 8080) {
+		t.Errorf("Second event should report the resolved collision, got %+v", events[1])
+	}
+	if hasIssue(events[1].NewIssues, "collision", 8080) {
+		t.Errorf("Second event should not report the collision as new, got %+v", events[1].NewIssues)
+	}
+}
+
+func hasIssue(issues []EventIssue, typ string, port int) bool {
+	for _, issue := range issues {
+		if issue.Type == typ && issue.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWatch_WaitForChangeDebouncesAndSeesNewFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "docker-compose.yml")
+	if err := os.WriteFile(file, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(dir, scanner.Options{})
+	w.Interval = 5 * time.Millisecond
+	w.Debounce = 20 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.WaitForChange(ctx); err != nil {
+		t.Fatalf("First wait should return at once, got %v", err)
+	}
+
+	// A new override file appears next to the base file
+	override := filepath.Join(dir, "docker-compose.override.yml")
+	if err := os.WriteFile(override, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WaitForChange(ctx); err != nil {
+		t.Fatalf("Expected the new file to be seen, got %v", err)
+	}
+
+	// Nothing changes any more
+	short, stop := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer stop()
+	if err := w.WaitForChange(short); err == nil {
+		t.Error("Expected no change to be reported while files are unchanged")
+	}
+}